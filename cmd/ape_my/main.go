@@ -1,9 +1,13 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
+	"math/rand"
 	"os"
+	"sort"
+	"time"
 
 	"github.com/ticktockbent/ape_my/internal/cli"
 	"github.com/ticktockbent/ape_my/internal/schema"
@@ -38,20 +42,84 @@ func main() {
 		os.Exit(1)
 	}
 
+	switch config.Command {
+	case cli.CommandValidate:
+		validate(config)
+	case cli.CommandExport:
+		export(config)
+	default:
+		serve(config)
+	}
+}
+
+// validate loads the schema and seed data, reporting the first problem found.
+// Every with-any seed file is checked, not just one picked at random.
+func validate(config *cli.Config) {
+	loader := loadSchema(config)
+
+	runs := []*cli.Config{config}
+	if len(config.SeedChoices) > 0 {
+		runs = nil
+		for _, choice := range config.SeedChoices {
+			single := *config
+			single.SeedChoices, single.SeedFile = nil, choice.File
+			runs = append(runs, &single)
+		}
+	}
+
+	for _, run := range runs {
+		seedData := loadSeedData(run, loader)
+
+		count := 0
+		for _, entities := range seedData {
+			count += len(entities)
+		}
+		fmt.Fprintf(os.Stderr, "%s is valid: %d entities, %d seed records\n", config.SchemaFile, len(loader.GetEntityNames()), count)
+	}
+}
+
+// export prints the validated seed data to stdout as a single seed file, in
+// the same shape as GET /__export
+func export(config *cli.Config) {
+	loader := loadSchema(config)
+	seedData := loadSeedData(config, loader)
+
+	out := make(map[string][]map[string]interface{})
+	for _, entityName := range loader.GetEntityNames() {
+		entities := seedData[entityName]
+		if entities == nil {
+			entities = []map[string]interface{}{}
+		}
+		sort.SliceStable(entities, func(a, b int) bool {
+			return fmt.Sprintf("%v", entities[a]["id"]) < fmt.Sprintf("%v", entities[b]["id"])
+		})
+		out[entityName] = entities
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(out); err != nil {
+		log.Fatalf("Failed to write export: %v", err)
+	}
+}
+
+// serve runs the mock API server until it stops
+func serve(config *cli.Config) {
 	// Print configuration
 	fmt.Fprintf(os.Stderr, "ape_my v%s\n", cli.Version)
 	fmt.Fprintf(os.Stderr, "Configuration: %s\n\n", config.String())
 
 	// Phase 2: Load and parse schema
-	log.Println("Loading schema...")
-	loader := schema.NewLoader()
-	if err := loader.LoadFromFile(config.SchemaFile); err != nil {
-		log.Fatalf("Failed to load schema: %v", err)
-	}
+	loader := loadSchema(config)
 
 	entityNames := loader.GetEntityNames()
 	log.Printf("Loaded %d entities: %v", len(entityNames), entityNames)
 
+	if port := config.ResolvePort(loader.GetSchema().Port); port != config.Port {
+		config.Port = port
+		log.Printf("Using port %d from the schema", port)
+	}
+
 	// Build route map
 	routeMap, err := loader.BuildRouteMap()
 	if err != nil {
@@ -64,31 +132,60 @@ func main() {
 	if err := store.Initialize(entityNames); err != nil {
 		log.Fatalf("Failed to initialize storage: %v", err)
 	}
+	server.ConfigureStore(store, loader)
+	if lag := loader.ConsistencyLag(); lag > 0 {
+		log.Printf("Simulating eventual consistency: writes visible to reads after %v", lag)
+	}
 
-	// Load seed data if provided
-	if config.SeedFile != "" {
-		log.Printf("Loading seed data from %s...", config.SeedFile)
-		seedData, err := schema.LoadSeedData(config.SeedFile)
-		if err != nil {
-			log.Fatalf("Failed to load seed data: %v", err)
+	// Load seed data into storage
+	for entityName, entities := range loadSeedData(config, loader) {
+		if err := store.Seed(entityName, entities); err != nil {
+			log.Fatalf("Failed to seed %s: %v", entityName, err)
 		}
+		log.Printf("Seeded %d %s", len(entities), entityName)
+	}
 
-		// Validate seed data against schema
-		if err := loader.ValidateSeedData(seedData); err != nil {
-			log.Fatalf("Seed data validation failed: %v", err)
+	// Phase 4: Start HTTP server
+	opts := server.Options{
+		LogLevel:            server.LogNormal,
+		RequestTimeout:      config.RequestTimeout,
+		AllowExport:         config.AllowExport,
+		AllowReset:          config.AllowReset,
+		Debug:               config.Debug,
+		OpenBrowser:         config.OpenBrowser,
+		AllowMaintenance:    config.AllowMaintenance,
+		AllowMockOverride:   config.AllowMockOverride,
+		AllowMethodOverride: config.AllowMethodOverride,
+		AllowBulkUpdate:     config.AllowBulkUpdate,
+		AllowSchemaEdit:     config.AllowSchemaEdit,
+		JSONSchema:          config.SchemaFormat == cli.SchemaFormatJSONSchema,
+		Metrics:             config.Metrics,
+		StaticDir:           config.StaticDir,
+		LenientContentType:  config.LenientContentType,
+		MaxConcurrent:       config.MaxConcurrent,
+		QueueTimeout:        config.QueueTimeout,
+	}
+	if config.CaptureFile != "" {
+		captureFile, err := os.OpenFile(config.CaptureFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			log.Fatalf("Failed to open capture file: %v", err)
 		}
+		defer captureFile.Close()
+		opts.Capture = captureFile
+	}
+	if config.Verbose {
+		opts.LogLevel = server.LogVerbose
+	} else if config.Quiet {
+		opts.LogLevel = server.LogQuiet
+	}
 
-		// Load seed data into storage
-		for entityName, entities := range seedData {
-			if err := store.Seed(entityName, entities); err != nil {
-				log.Fatalf("Failed to seed %s: %v", entityName, err)
-			}
-			log.Printf("Seeded %d %s", len(entities), entityName)
-		}
+	// Time store operations from here on; seeding above is not counted
+	var served storage.Store = store
+	if config.Metrics {
+		served = storage.NewTimedStore(store)
 	}
 
-	// Phase 4: Start HTTP server
-	srv := server.New(config.Port, store, routeMap, loader)
+	srv := server.NewWithOptions(config.Port, served, routeMap, loader, opts)
 	srv.RegisterRoutes()
 
 	log.Printf("\n=== Ape_my is ready! ===")
@@ -97,6 +194,48 @@ func main() {
 		log.Printf("  - %s (GET, POST)", route.CollectionPath)
 		log.Printf("  - %s/<id> (GET, PUT, PATCH, DELETE)", route.CollectionPath)
 	}
+	log.Printf("  - /__routes (GET, route introspection)")
+	if config.AllowSchemaEdit {
+		log.Printf("  - /__schema (GET, PUT, inspect or replace the schema)")
+	} else {
+		log.Printf("  - /__schema (GET, inspect the schema)")
+	}
+	if config.AllowExport {
+		log.Printf("  - /__export (GET, dump data as a seed file)")
+	}
+	if config.AllowReset {
+		log.Printf("  - /__import (POST, load seed data)")
+	}
+	if config.Debug {
+		log.Printf("  - /__echo (POST, echo the parsed request)")
+	}
+	if config.Metrics {
+		log.Printf("  - /__metrics (GET, store operation timings)")
+	}
+	if config.AllowMaintenance {
+		log.Printf("  - /__maintenance (POST, toggle maintenance mode)")
+	}
+	if config.StaticDir != "" {
+		log.Printf("  - / (GET, static files from %s)", config.StaticDir)
+	}
+	if config.CaptureFile != "" {
+		log.Printf("Capturing requests and responses to %s", config.CaptureFile)
+	}
+	if config.AllowMockOverride {
+		log.Printf("X-Mock-Response overrides are enabled")
+	}
+	if config.AllowMethodOverride {
+		log.Printf("X-HTTP-Method-Override is honored on POST requests")
+	}
+	if config.AllowBulkUpdate {
+		log.Printf("PATCH on collections updates every matching entity")
+	}
+	if config.LenientContentType {
+		log.Printf("Writes without a Content-Type are treated as JSON")
+	}
+	if config.MaxConcurrent > 0 {
+		log.Printf("At most %d requests are processed at once (queue timeout %v)", config.MaxConcurrent, config.QueueTimeout)
+	}
 	log.Println()
 
 	// Start server (blocks until shutdown)
@@ -104,3 +243,83 @@ func main() {
 		log.Fatalf("Server error: %v", err)
 	}
 }
+
+// loadSchema loads the schema file in the configured format, exiting on error
+func loadSchema(config *cli.Config) *schema.Loader {
+	log.Println("Loading schema...")
+	loader := schema.NewLoader()
+	loader.SetLimits(schema.Limits{MaxEntities: config.MaxEntities, MaxFields: config.MaxFields})
+	if config.SchemaFormat == cli.SchemaFormatJSONSchema {
+		warnings, err := loader.LoadFromJSONSchemaFile(config.SchemaFile)
+		for _, warning := range warnings {
+			log.Printf("Warning: %s", warning)
+		}
+		if err != nil {
+			log.Fatalf("Failed to load schema: %v", err)
+		}
+	} else if err := loader.LoadFromFile(config.SchemaFile); err != nil {
+		log.Fatalf("Failed to load schema: %v", err)
+	}
+	return loader
+}
+
+// loadSeedData loads seed data if provided (per-entity files from a
+// directory, then a single seed file) and validates it against the schema,
+// exiting on error
+func loadSeedData(config *cli.Config, loader *schema.Loader) map[string][]map[string]interface{} {
+	if len(config.SeedChoices) > 0 {
+		seed := config.RandomSeed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		picked := *config
+		picked.SeedFile = cli.PickSeed(config.SeedChoices, rand.New(rand.NewSource(seed)))
+		picked.SeedChoices = nil
+		log.Printf("Picked seed file %s of %d (--random-seed %d)", picked.SeedFile, len(config.SeedChoices), seed)
+		config = &picked
+	}
+
+	seedData := make(map[string][]map[string]interface{})
+	if config.SeedDir != "" {
+		log.Printf("Loading seed data from directory %s...", config.SeedDir)
+		dirData, err := schema.LoadSeedDir(config.SeedDir)
+		if err != nil {
+			log.Fatalf("Failed to load seed data: %v", err)
+		}
+		schema.MergeSeedData(seedData, dirData)
+	}
+	if config.SeedFile != "" {
+		log.Printf("Loading seed data from %s...", config.SeedFile)
+		fileData, err := schema.LoadSeedData(config.SeedFile)
+		if err != nil {
+			log.Fatalf("Failed to load seed data: %v", err)
+		}
+		schema.MergeSeedData(seedData, fileData)
+	}
+
+	if len(seedData) > 0 && config.SeedLenient {
+		// Drop what doesn't match the schema and load the rest
+		if errs := loader.SeedDataErrors(seedData); len(errs) > 0 {
+			skipped := 0
+			for _, err := range errs {
+				log.Printf("Skipping invalid seed data: %v", err)
+				if err.Index < 0 {
+					skipped += len(seedData[err.Entity])
+				} else {
+					skipped++
+				}
+			}
+			seedData = schema.WithoutInvalidSeed(seedData, errs)
+			log.Printf("Skipped %d invalid seed records (--seed-lenient)", skipped)
+		}
+	} else if len(seedData) > 0 {
+		// Validate seed data against schema
+		if err := loader.ValidateSeedData(seedData); err != nil {
+			log.Fatalf("Seed data validation failed: %v", err)
+		}
+	}
+	if err := loader.CheckRequiredSeed(seedData); err != nil {
+		log.Fatalf("%v", err)
+	}
+	return seedData
+}
@@ -0,0 +1,34 @@
+// Package browser opens URLs in the user's default web browser.
+package browser
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// Open launches the default browser at url without waiting for it to exit
+func Open(url string) error {
+	name, args := command(runtime.GOOS, url)
+	cmd := exec.Command(name, args...)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open browser: %w", err)
+	}
+
+	// Reap the launcher process in the background
+	go cmd.Wait()
+
+	return nil
+}
+
+// command returns the platform-specific command that opens url
+func command(goos, url string) (string, []string) {
+	switch goos {
+	case "darwin":
+		return "open", []string{url}
+	case "windows":
+		return "rundll32", []string{"url.dll,FileProtocolHandler", url}
+	default:
+		return "xdg-open", []string{url}
+	}
+}
@@ -3,19 +3,34 @@ package cli
 import (
 	"errors"
 	"fmt"
+	"math/rand"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
 	// DefaultPort is the default port for the server
 	DefaultPort = 8080
 
+	// SchemaFormatNative is ape_my's own schema format (the default)
+	SchemaFormatNative = "native"
+
+	// SchemaFormatJSONSchema selects JSON Schema (draft-07) import
+	SchemaFormatJSONSchema = "jsonschema"
+
 	// Version is the current version
 	Version = "0.1.0"
 )
 
+// Subcommands; a bare schema file runs CommandServe
+const (
+	CommandServe    = "serve"    // run the mock server
+	CommandValidate = "validate" // check the schema and seed data, then exit
+	CommandExport   = "export"   // print the seed data in export format, then exit
+)
+
 var (
 	// ErrNoSchemaFile is returned when no schema file is provided
 	ErrNoSchemaFile = errors.New("no schema file provided")
@@ -29,17 +44,115 @@ var (
 
 // Config holds the parsed CLI configuration
 type Config struct {
+	Command     string // serve, validate or export
 	SchemaFile  string
 	SeedFile    string
+	SeedDir     string
+	SeedChoices []SeedChoice // with-any: one is picked as SeedFile per run
+	RandomSeed  int64        // makes the with-any pick reproducible (0 = pick from the clock)
+	SeedLenient bool         // skip invalid seed records with a warning instead of failing
 	Port        int
+	PortSet     bool // Port came from "on <port>" rather than the default
 	ShowHelp    bool
 	ShowVersion bool
+	Verbose     bool
+	Quiet       bool
+
+	// RequestTimeout bounds per-request handler processing (0 = no timeout)
+	RequestTimeout time.Duration
+
+	// AllowExport enables the /__export endpoint
+	AllowExport bool
+
+	// AllowReset enables the /__import endpoint
+	AllowReset bool
+
+	// AllowMaintenance enables the /__maintenance endpoint
+	AllowMaintenance bool
+
+	// AllowMockOverride lets the X-Mock-Response header force responses
+	AllowMockOverride bool
+
+	// AllowMethodOverride lets a POST carry its real method in
+	// X-HTTP-Method-Override
+	AllowMethodOverride bool
+
+	// AllowBulkUpdate enables PATCH on collections to update every entity
+	// matching the query's filters
+	AllowBulkUpdate bool
+
+	// AllowSchemaEdit enables PUT /__schema to replace the schema at runtime
+	AllowSchemaEdit bool
+
+	// LenientContentType accepts writes without a Content-Type as JSON
+	LenientContentType bool
+
+	// StaticDir is served at / for paths that aren't API routes
+	StaticDir string
+
+	// CaptureFile receives every request and response as NDJSON (appended)
+	CaptureFile string
+
+	// Metrics times store operations and reports them on /__metrics
+	Metrics bool
+
+	// MaxConcurrent bounds simultaneously processed requests (0 = unlimited);
+	// QueueTimeout is how long an excess request waits before a 503
+	MaxConcurrent int
+	QueueTimeout  time.Duration
+
+	// SchemaFormat selects the schema file parser (native or jsonschema)
+	SchemaFormat string
+
+	// Debug enables the /__echo endpoint and _index in create responses
+	Debug bool
+
+	// OpenBrowser opens /__routes in the default browser after startup
+	OpenBrowser bool
+
+	// MaxEntities and MaxFields cap the schema size (0 = unlimited)
+	MaxEntities int
+	MaxFields   int
+}
+
+// ResolvePort returns the port to listen on: "on <port>" if given, else the
+// schema's port if set, else DefaultPort
+func (c *Config) ResolvePort(schemaPort int) int {
+	if c.PortSet || schemaPort == 0 {
+		return c.Port
+	}
+	return schemaPort
+}
+
+// SeedChoice is one with-any seed file and its relative weight
+type SeedChoice struct {
+	File   string
+	Weight int
 }
 
-// Parse parses command line arguments and returns a Config
+// PickSeed chooses one of the seed files at random, in proportion to their weights
+func PickSeed(choices []SeedChoice, rng *rand.Rand) string {
+	total := 0
+	for _, choice := range choices {
+		total += choice.Weight
+	}
+	n := rng.Intn(total)
+	for _, choice := range choices {
+		if n < choice.Weight {
+			return choice.File
+		}
+		n -= choice.Weight
+	}
+	return choices[len(choices)-1].File
+}
+
+// Parse parses command line arguments and returns a Config. The first
+// argument may name a subcommand; a bare schema file implies serve.
 func Parse(args []string) (*Config, error) {
 	config := &Config{
-		Port: DefaultPort,
+		Command:      CommandServe,
+		Port:         DefaultPort,
+		SchemaFormat: SchemaFormatNative,
 	}
 
 	// Handle empty args
@@ -47,6 +160,15 @@ func Parse(args []string) (*Config, error) {
 		return nil, ErrNoSchemaFile
 	}
 
+	switch args[0] {
+	case CommandServe, CommandValidate, CommandExport:
+		config.Command = args[0]
+		args = args[1:]
+		if len(args) == 0 {
+			return nil, ErrNoSchemaFile
+		}
+	}
+
 	// Check for flags
 	if args[0] == "--help" || args[0] == "-h" {
 		config.ShowHelp = true
@@ -61,18 +183,18 @@ func Parse(args []string) (*Config, error) {
 	// First argument should be the schema file
 	config.SchemaFile = args[0]
 
+	if config.Command == CommandServe {
+		return parseServe(config, args[1:])
+	}
+	return parseOffline(config, args[1:])
+}
+
+// parseServe parses the arguments following the schema file for serve
+func parseServe(config *Config, args []string) (*Config, error) {
 	// Parse remaining arguments in natural language style
-	i := 1
+	i := 0
 	for i < len(args) {
 		switch args[i] {
-		case "with":
-			// Next argument should be seed file
-			if i+1 >= len(args) {
-				return nil, fmt.Errorf("expected seed file after 'with'")
-			}
-			config.SeedFile = args[i+1]
-			i += 2
-
 		case "on":
 			// Next argument should be port
 			if i+1 >= len(args) {
@@ -85,17 +207,253 @@ func Parse(args []string) (*Config, error) {
 			if port < 1 || port > 65535 {
 				return nil, fmt.Errorf("%w: must be between 1 and 65535", ErrInvalidPort)
 			}
-			config.Port = port
+			config.Port, config.PortSet = port, true
+			i += 2
+
+		case "--request-timeout":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("expected duration after '--request-timeout'")
+			}
+			timeout, err := time.ParseDuration(args[i+1])
+			if err != nil || timeout <= 0 {
+				return nil, fmt.Errorf("invalid request timeout %q: must be a positive duration like 5s", args[i+1])
+			}
+			config.RequestTimeout = timeout
+			i += 2
+
+		case "--max-concurrent":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("expected number after '--max-concurrent'")
+			}
+			limit, err := strconv.Atoi(args[i+1])
+			if err != nil || limit <= 0 {
+				return nil, fmt.Errorf("invalid max-concurrent %q: must be a positive number", args[i+1])
+			}
+			config.MaxConcurrent = limit
+			i += 2
+
+		case "--queue-timeout":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("expected duration after '--queue-timeout'")
+			}
+			timeout, err := time.ParseDuration(args[i+1])
+			if err != nil || timeout <= 0 {
+				return nil, fmt.Errorf("invalid queue timeout %q: must be a positive duration like 2s", args[i+1])
+			}
+			config.QueueTimeout = timeout
+			i += 2
+
+		case "--verbose":
+			config.Verbose = true
+			i++
+
+		case "--quiet":
+			config.Quiet = true
+			i++
+
+		case "--debug":
+			config.Debug = true
+			i++
+
+		case "--open":
+			config.OpenBrowser = true
+			i++
+
+		case "--allow-export":
+			config.AllowExport = true
+			i++
+
+		case "--allow-reset":
+			config.AllowReset = true
+			i++
+
+		case "--allow-maintenance":
+			config.AllowMaintenance = true
+			i++
+
+		case "--allow-mock-override":
+			config.AllowMockOverride = true
+			i++
+
+		case "--allow-method-override":
+			config.AllowMethodOverride = true
+			i++
+
+		case "--allow-bulk-update":
+			config.AllowBulkUpdate = true
+			i++
+
+		case "--allow-schema-edit":
+			config.AllowSchemaEdit = true
+			i++
+
+		case "--metrics":
+			config.Metrics = true
+			i++
+
+		case "--static":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("expected directory after '--static'")
+			}
+			config.StaticDir = args[i+1]
 			i += 2
 
+		case "--capture":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("expected file after '--capture'")
+			}
+			config.CaptureFile = args[i+1]
+			i += 2
+
+		case "--lenient-content-type":
+			config.LenientContentType = true
+			i++
+
 		default:
-			return nil, fmt.Errorf("unexpected argument: %s", args[i])
+			next, err := parseSourceArg(config, args, i)
+			if err != nil {
+				return nil, err
+			}
+			i = next
 		}
 	}
 
+	if config.Verbose && config.Quiet {
+		return nil, fmt.Errorf("--verbose and --quiet cannot be used together")
+	}
+	if config.QueueTimeout > 0 && config.MaxConcurrent == 0 {
+		return nil, fmt.Errorf("--queue-timeout requires --max-concurrent")
+	}
+
+	return config, nil
+}
+
+// parseOffline parses the arguments following the schema file for validate
+// and export, which only take schema and seed options
+func parseOffline(config *Config, args []string) (*Config, error) {
+	i := 0
+	for i < len(args) {
+		next, err := parseSourceArg(config, args, i)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", config.Command, err)
+		}
+		i = next
+	}
 	return config, nil
 }
 
+// parseSourceArg parses the schema and seed option at args[i], shared by all
+// subcommands, and returns the index of the next argument
+func parseSourceArg(config *Config, args []string, i int) (int, error) {
+	switch args[i] {
+	case "with":
+		// Next argument should be seed file
+		if i+1 >= len(args) {
+			return 0, fmt.Errorf("expected seed file after 'with'")
+		}
+		if len(config.SeedChoices) > 0 {
+			return 0, fmt.Errorf("'with' and 'with-any' cannot be used together")
+		}
+		config.SeedFile = args[i+1]
+		return i + 2, nil
+
+	case "with-any":
+		// Following arguments, up to the next keyword or flag, are seed
+		// files to pick from, each optionally suffixed with :<weight>
+		if config.SeedFile != "" {
+			return 0, fmt.Errorf("'with' and 'with-any' cannot be used together")
+		}
+		next := i + 1
+		for next < len(args) && !isKeyword(args[next]) {
+			choice, err := parseSeedChoice(args[next])
+			if err != nil {
+				return 0, err
+			}
+			config.SeedChoices = append(config.SeedChoices, choice)
+			next++
+		}
+		if next == i+1 {
+			return 0, fmt.Errorf("expected seed files after 'with-any'")
+		}
+		return next, nil
+
+	case "--random-seed":
+		if i+1 >= len(args) {
+			return 0, fmt.Errorf("expected number after '--random-seed'")
+		}
+		seed, err := strconv.ParseInt(args[i+1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid random seed %q: must be an integer", args[i+1])
+		}
+		config.RandomSeed = seed
+		return i + 2, nil
+
+	case "--seed-lenient":
+		config.SeedLenient = true
+		return i + 1, nil
+
+	case "with-dir":
+		// Next argument should be a directory of per-entity seed files
+		if i+1 >= len(args) {
+			return 0, fmt.Errorf("expected seed directory after 'with-dir'")
+		}
+		config.SeedDir = args[i+1]
+		return i + 2, nil
+
+	case "--schema-format":
+		if i+1 >= len(args) {
+			return 0, fmt.Errorf("expected format after '--schema-format'")
+		}
+		format := args[i+1]
+		if format != SchemaFormatNative && format != SchemaFormatJSONSchema {
+			return 0, fmt.Errorf("invalid schema format %q: must be %s or %s", format, SchemaFormatNative, SchemaFormatJSONSchema)
+		}
+		config.SchemaFormat = format
+		return i + 2, nil
+
+	case "--max-entities", "--max-fields":
+		if i+1 >= len(args) {
+			return 0, fmt.Errorf("expected number after '%s'", args[i])
+		}
+		limit, err := strconv.Atoi(args[i+1])
+		if err != nil || limit <= 0 {
+			return 0, fmt.Errorf("invalid %s %q: must be a positive number", strings.TrimPrefix(args[i], "--"), args[i+1])
+		}
+		if args[i] == "--max-entities" {
+			config.MaxEntities = limit
+		} else {
+			config.MaxFields = limit
+		}
+		return i + 2, nil
+	}
+
+	return 0, fmt.Errorf("unexpected argument: %s", args[i])
+}
+
+// isKeyword reports whether arg starts a new option rather than continuing a
+// with-any file list
+func isKeyword(arg string) bool {
+	switch arg {
+	case "on", "with", "with-dir", "with-any":
+		return true
+	}
+	return strings.HasPrefix(arg, "-")
+}
+
+// parseSeedChoice parses a with-any entry: a file, or file:weight with a
+// positive integer weight
+func parseSeedChoice(arg string) (SeedChoice, error) {
+	if colon := strings.LastIndex(arg, ":"); colon > 0 {
+		if weight, err := strconv.Atoi(arg[colon+1:]); err == nil {
+			if weight <= 0 {
+				return SeedChoice{}, fmt.Errorf("invalid weight in %q: must be a positive integer", arg)
+			}
+			return SeedChoice{File: arg[:colon], Weight: weight}, nil
+		}
+	}
+	return SeedChoice{File: arg, Weight: 1}, nil
+}
+
 // Validate checks if the configuration is valid and files exist
 func (c *Config) Validate() error {
 	// Skip validation for help/version
@@ -115,6 +473,33 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	for _, choice := range c.SeedChoices {
+		if _, err := os.Stat(choice.File); os.IsNotExist(err) {
+			return fmt.Errorf("seed file not found: %s", choice.File)
+		}
+	}
+
+	// Check if seed directory exists (if provided)
+	if c.SeedDir != "" {
+		info, err := os.Stat(c.SeedDir)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("seed directory not found: %s", c.SeedDir)
+		}
+		if err == nil && !info.IsDir() {
+			return fmt.Errorf("seed directory is not a directory: %s", c.SeedDir)
+		}
+	}
+
+	if c.StaticDir != "" {
+		info, err := os.Stat(c.StaticDir)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("static directory not found: %s", c.StaticDir)
+		}
+		if err == nil && !info.IsDir() {
+			return fmt.Errorf("static directory is not a directory: %s", c.StaticDir)
+		}
+	}
+
 	return nil
 }
 
@@ -123,16 +508,68 @@ func PrintHelp() {
 	help := `ape_my - A minimalist mock API server
 
 USAGE:
-    ape_my <schema.json> [with <seed.json>] [on <port>]
+    ape_my [serve] <schema.json> [with <seed.json>] [with-dir <dir>] [on <port>] [flags]
+    ape_my validate <schema.json> [with <seed.json>] [with-dir <dir>] [schema flags]
+    ape_my export <schema.json> [with <seed.json>] [with-dir <dir>] [schema flags]
+    (with-any <a.json> <b.json>... may replace with <seed.json>)
     ape_my --help
     ape_my --version
 
+COMMANDS:
+    serve               Run the mock API server (the default)
+    validate            Check the schema and seed data, then exit
+    export              Print the validated seed data as one seed file
+
 ARGUMENTS:
     <schema.json>       Path to the JSON schema file (required)
 
-OPTIONS:
+SCHEMA FLAGS:
     with <seed.json>    Load initial seed data from a JSON file
+    with-dir <dir>      Load seed data from <dir>/<entity>.json files
+    with-any <a.json[:weight]> <b.json[:weight]>...
+                        Load one of several seed files, picked at random
+                        per run (weights default to 1)
+    --random-seed <n>   Make the with-any pick reproducible
+    --seed-lenient      Skip invalid seed records with a warning instead of
+                        refusing to start
+    --schema-format <native|jsonschema>
+                        Parse the schema as ape_my's format (default) or
+                        JSON Schema draft-07 definitions
+    --max-entities <n>  Refuse to start if the schema has more than n entities
+    --max-fields <n>    Refuse to start if an entity has more than n fields
+
+SERVE FLAGS:
     on <port>           Specify the port to run on (default: 8080)
+    --verbose           Log request/response headers and bodies (auth redacted)
+    --quiet             Only log requests that end in an error status
+    --request-timeout <duration>
+                        Return 503 when a request takes longer (e.g. 5s)
+    --max-concurrent <n>
+                        Process at most n requests at once; the rest get 503
+    --queue-timeout <duration>
+                        With --max-concurrent, let excess requests wait this
+                        long for a slot before the 503 (default: no waiting)
+    --debug             Enable POST /__echo, which returns the parsed request,
+                        and add the created entity's list position as _index
+    --open              Open /__routes in the default browser once listening
+    --allow-export      Enable GET /__export to dump all data as a seed file
+    --allow-reset       Enable POST /__import to load seed data at runtime
+    --allow-maintenance Enable POST /__maintenance to toggle 503 responses
+    --allow-mock-override
+                        Let an X-Mock-Response: <status> request header (and
+                        optional X-Mock-Body) force that request's response
+    --allow-method-override
+                        Treat a POST with X-HTTP-Method-Override: PUT, PATCH
+                        or DELETE as that method
+    --allow-bulk-update Enable PATCH /<entity>?field=value to patch every match
+    --allow-schema-edit Enable PUT /__schema to replace the schema at runtime
+    --metrics           Time store operations and report them on GET /__metrics
+    --static <dir>      Serve files from <dir> at / for paths that aren't API routes
+    --capture <file>    Append every request and response to <file> as NDJSON
+    --lenient-content-type
+                        Treat POST/PUT/PATCH without a Content-Type as JSON
+
+OTHER FLAGS:
     --help, -h          Show this help message
     --version, -v       Show version information
 
@@ -143,12 +580,24 @@ EXAMPLES:
     # Start with seed data
     ape_my schema.json with seed.json
 
+    # Start with one of two fixtures, the first picked 3 times in 4
+    ape_my schema.json with-any small.json:3 large.json
+
+    # Start with one seed file per entity (users.json, posts.json, ...)
+    ape_my schema.json with-dir seeds/
+
     # Start on a custom port
     ape_my schema.json on 3000
 
     # Combine options
     ape_my schema.json with seed.json on 8080
 
+    # Check a schema and seed file in CI
+    ape_my validate schema.json with seed.json
+
+    # Merge per-entity seed files into one
+    ape_my export schema.json with-dir seeds/ > seed.json
+
 DOCUMENTATION:
     See README.md for complete documentation
     Schema format: docs/schema_format.md
@@ -171,6 +620,18 @@ func (c *Config) String() string {
 		parts = append(parts, fmt.Sprintf("Seed: %s", c.SeedFile))
 	}
 
+	if len(c.SeedChoices) > 0 {
+		files := make([]string, len(c.SeedChoices))
+		for i, choice := range c.SeedChoices {
+			files[i] = choice.File
+		}
+		parts = append(parts, fmt.Sprintf("Seed: one of %s", strings.Join(files, ", ")))
+	}
+
+	if c.SeedDir != "" {
+		parts = append(parts, fmt.Sprintf("Seed dir: %s", c.SeedDir))
+	}
+
 	parts = append(parts, fmt.Sprintf("Port: %d", c.Port))
 
 	return strings.Join(parts, ", ")
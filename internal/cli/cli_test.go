@@ -1,9 +1,12 @@
 package cli
 
 import (
+	"math/rand"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestParse(t *testing.T) {
@@ -133,12 +136,331 @@ func TestParse(t *testing.T) {
 			wantErr:     true,
 			errContains: "must be between 1 and 65535",
 		},
+		{
+			name: "verbose flag",
+			args: []string{"schema.json", "on", "3000", "--verbose"},
+			want: &Config{
+				SchemaFile: "schema.json",
+				Port:       3000,
+				Verbose:    true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "quiet flag",
+			args: []string{"schema.json", "--quiet"},
+			want: &Config{
+				SchemaFile: "schema.json",
+				Port:       DefaultPort,
+				Quiet:      true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "request timeout",
+			args: []string{"schema.json", "--request-timeout", "5s"},
+			want: &Config{
+				SchemaFile:     "schema.json",
+				Port:           DefaultPort,
+				RequestTimeout: 5 * time.Second,
+			},
+			wantErr: false,
+		},
+		{
+			name: "seed directory",
+			args: []string{"schema.json", "with-dir", "seeds", "on", "3000"},
+			want: &Config{
+				SchemaFile: "schema.json",
+				SeedDir:    "seeds",
+				Port:       3000,
+			},
+			wantErr: false,
+		},
+		{
+			name: "seed choices",
+			args: []string{"schema.json", "with-any", "a.json", "b.json:3", "--random-seed", "42", "on", "3000"},
+			want: &Config{
+				SchemaFile:  "schema.json",
+				SeedChoices: []SeedChoice{{File: "a.json", Weight: 1}, {File: "b.json", Weight: 3}},
+				RandomSeed:  42,
+				Port:        3000,
+			},
+		},
+		{
+			name: "lenient seed",
+			args: []string{"schema.json", "with", "seed.json", "--seed-lenient"},
+			want: &Config{
+				SchemaFile:  "schema.json",
+				SeedFile:    "seed.json",
+				SeedLenient: true,
+				Port:        DefaultPort,
+			},
+		},
+		{
+			name:        "empty seed choices",
+			args:        []string{"schema.json", "with-any", "on", "3000"},
+			wantErr:     true,
+			errContains: "expected seed files after 'with-any'",
+		},
+		{
+			name:        "zero seed weight",
+			args:        []string{"schema.json", "with-any", "a.json:0"},
+			wantErr:     true,
+			errContains: "invalid weight",
+		},
+		{
+			name:        "with and with-any",
+			args:        []string{"schema.json", "with", "seed.json", "with-any", "a.json"},
+			wantErr:     true,
+			errContains: "cannot be used together",
+		},
+		{
+			name:        "missing seed directory",
+			args:        []string{"schema.json", "with-dir"},
+			wantErr:     true,
+			errContains: "expected seed directory",
+		},
+		{
+			name: "json schema format",
+			args: []string{"schema.json", "--schema-format", "jsonschema"},
+			want: &Config{
+				SchemaFile:   "schema.json",
+				Port:         DefaultPort,
+				SchemaFormat: SchemaFormatJSONSchema,
+			},
+			wantErr: false,
+		},
+		{
+			name:        "invalid schema format",
+			args:        []string{"schema.json", "--schema-format", "yaml"},
+			wantErr:     true,
+			errContains: "invalid schema format",
+		},
+		{
+			name: "allow export",
+			args: []string{"schema.json", "--allow-export"},
+			want: &Config{
+				SchemaFile:  "schema.json",
+				Port:        DefaultPort,
+				AllowExport: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "allow reset",
+			args: []string{"schema.json", "--allow-reset"},
+			want: &Config{
+				SchemaFile: "schema.json",
+				Port:       DefaultPort,
+				AllowReset: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "allow mock override",
+			args: []string{"schema.json", "--allow-mock-override"},
+			want: &Config{
+				SchemaFile:        "schema.json",
+				Port:              DefaultPort,
+				AllowMockOverride: true,
+			},
+		},
+		{
+			name: "allow method override",
+			args: []string{"schema.json", "--allow-method-override"},
+			want: &Config{
+				SchemaFile:          "schema.json",
+				Port:                DefaultPort,
+				AllowMethodOverride: true,
+			},
+		},
+		{
+			name: "allow bulk update",
+			args: []string{"schema.json", "--allow-bulk-update"},
+			want: &Config{
+				SchemaFile:      "schema.json",
+				Port:            DefaultPort,
+				AllowBulkUpdate: true,
+			},
+		},
+		{
+			name: "allow schema edit",
+			args: []string{"schema.json", "--allow-schema-edit"},
+			want: &Config{
+				SchemaFile:      "schema.json",
+				Port:            DefaultPort,
+				AllowSchemaEdit: true,
+			},
+		},
+		{
+			name: "max concurrent with queue",
+			args: []string{"schema.json", "--max-concurrent", "10", "--queue-timeout", "2s"},
+			want: &Config{
+				SchemaFile:    "schema.json",
+				Port:          DefaultPort,
+				MaxConcurrent: 10,
+				QueueTimeout:  2 * time.Second,
+			},
+		},
+		{
+			name: "static directory",
+			args: []string{"schema.json", "--static", "./public"},
+			want: &Config{
+				SchemaFile: "schema.json",
+				Port:       DefaultPort,
+				StaticDir:  "./public",
+			},
+		},
+		{
+			name: "capture file",
+			args: []string{"schema.json", "--capture", "traffic.ndjson"},
+			want: &Config{
+				SchemaFile:  "schema.json",
+				Port:        DefaultPort,
+				CaptureFile: "traffic.ndjson",
+			},
+		},
+		{
+			name:    "capture without file",
+			args:    []string{"schema.json", "--capture"},
+			wantErr: true,
+		},
+		{
+			name: "lenient content type",
+			args: []string{"schema.json", "--lenient-content-type"},
+			want: &Config{
+				SchemaFile:         "schema.json",
+				Port:               DefaultPort,
+				LenientContentType: true,
+			},
+		},
+		{
+			name: "metrics",
+			args: []string{"schema.json", "--metrics"},
+			want: &Config{
+				SchemaFile: "schema.json",
+				Port:       DefaultPort,
+				Metrics:    true,
+			},
+		},
+		{
+			name: "allow maintenance",
+			args: []string{"schema.json", "--allow-maintenance"},
+			want: &Config{
+				SchemaFile:       "schema.json",
+				Port:             DefaultPort,
+				AllowMaintenance: true,
+			},
+		},
+		{
+			name: "debug",
+			args: []string{"schema.json", "--debug"},
+			want: &Config{
+				SchemaFile: "schema.json",
+				Port:       DefaultPort,
+				Debug:      true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "open browser",
+			args: []string{"schema.json", "--open"},
+			want: &Config{
+				SchemaFile:  "schema.json",
+				Port:        DefaultPort,
+				OpenBrowser: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "schema limits",
+			args: []string{"schema.json", "--max-entities", "10", "--max-fields", "50"},
+			want: &Config{
+				SchemaFile:  "schema.json",
+				Port:        DefaultPort,
+				MaxEntities: 10,
+				MaxFields:   50,
+			},
+			wantErr: false,
+		},
+		{
+			name:        "invalid max fields",
+			args:        []string{"schema.json", "--max-fields", "0"},
+			wantErr:     true,
+			errContains: "invalid max-fields",
+		},
+		{
+			name:        "invalid request timeout",
+			args:        []string{"schema.json", "--request-timeout", "soon"},
+			wantErr:     true,
+			errContains: "invalid request timeout",
+		},
+		{
+			name:        "invalid max concurrent",
+			args:        []string{"schema.json", "--max-concurrent", "0"},
+			wantErr:     true,
+			errContains: "invalid max-concurrent",
+		},
+		{
+			name:        "queue timeout without max concurrent",
+			args:        []string{"schema.json", "--queue-timeout", "1s"},
+			wantErr:     true,
+			errContains: "--queue-timeout requires --max-concurrent",
+		},
+		{
+			name:        "verbose and quiet together",
+			args:        []string{"schema.json", "--verbose", "--quiet"},
+			wantErr:     true,
+			errContains: "cannot be used together",
+		},
 		{
 			name:        "unexpected argument",
 			args:        []string{"schema.json", "invalid"},
 			wantErr:     true,
 			errContains: "unexpected argument",
 		},
+		{
+			name: "explicit serve",
+			args: []string{"serve", "schema.json", "on", "3000"},
+			want: &Config{
+				Command:    CommandServe,
+				SchemaFile: "schema.json",
+				Port:       3000,
+			},
+		},
+		{
+			name: "validate with seed",
+			args: []string{"validate", "schema.json", "with", "seed.json", "--max-fields", "5"},
+			want: &Config{
+				Command:    CommandValidate,
+				SchemaFile: "schema.json",
+				SeedFile:   "seed.json",
+				Port:       DefaultPort,
+				MaxFields:  5,
+			},
+		},
+		{
+			name: "export with seed directory",
+			args: []string{"export", "schema.json", "with-dir", "seeds"},
+			want: &Config{
+				Command:    CommandExport,
+				SchemaFile: "schema.json",
+				SeedDir:    "seeds",
+				Port:       DefaultPort,
+			},
+		},
+		{
+			name:        "subcommand without schema",
+			args:        []string{"validate"},
+			wantErr:     true,
+			errContains: "no schema file provided",
+		},
+		{
+			name:        "serve flag on validate",
+			args:        []string{"validate", "schema.json", "on", "3000"},
+			wantErr:     true,
+			errContains: "validate: unexpected argument: on",
+		},
 	}
 
 	for _, tt := range tests {
@@ -163,6 +485,18 @@ func TestParse(t *testing.T) {
 				if got.SeedFile != tt.want.SeedFile {
 					t.Errorf("Parse() SeedFile = %v, want %v", got.SeedFile, tt.want.SeedFile)
 				}
+				if got.SeedDir != tt.want.SeedDir {
+					t.Errorf("Parse() SeedDir = %v, want %v", got.SeedDir, tt.want.SeedDir)
+				}
+				if !reflect.DeepEqual(got.SeedChoices, tt.want.SeedChoices) {
+					t.Errorf("Parse() SeedChoices = %v, want %v", got.SeedChoices, tt.want.SeedChoices)
+				}
+				if got.RandomSeed != tt.want.RandomSeed {
+					t.Errorf("Parse() RandomSeed = %v, want %v", got.RandomSeed, tt.want.RandomSeed)
+				}
+				if got.SeedLenient != tt.want.SeedLenient {
+					t.Errorf("Parse() SeedLenient = %v, want %v", got.SeedLenient, tt.want.SeedLenient)
+				}
 				if got.Port != tt.want.Port {
 					t.Errorf("Parse() Port = %v, want %v", got.Port, tt.want.Port)
 				}
@@ -172,6 +506,74 @@ func TestParse(t *testing.T) {
 				if got.ShowVersion != tt.want.ShowVersion {
 					t.Errorf("Parse() ShowVersion = %v, want %v", got.ShowVersion, tt.want.ShowVersion)
 				}
+				if got.Verbose != tt.want.Verbose {
+					t.Errorf("Parse() Verbose = %v, want %v", got.Verbose, tt.want.Verbose)
+				}
+				if got.Quiet != tt.want.Quiet {
+					t.Errorf("Parse() Quiet = %v, want %v", got.Quiet, tt.want.Quiet)
+				}
+				if got.RequestTimeout != tt.want.RequestTimeout {
+					t.Errorf("Parse() RequestTimeout = %v, want %v", got.RequestTimeout, tt.want.RequestTimeout)
+				}
+				if got.AllowExport != tt.want.AllowExport {
+					t.Errorf("Parse() AllowExport = %v, want %v", got.AllowExport, tt.want.AllowExport)
+				}
+				wantFormat := tt.want.SchemaFormat
+				if wantFormat == "" {
+					wantFormat = SchemaFormatNative
+				}
+				if got.SchemaFormat != wantFormat {
+					t.Errorf("Parse() SchemaFormat = %v, want %v", got.SchemaFormat, wantFormat)
+				}
+				if got.AllowMockOverride != tt.want.AllowMockOverride {
+					t.Errorf("Parse() AllowMockOverride = %v, want %v", got.AllowMockOverride, tt.want.AllowMockOverride)
+				}
+				if got.AllowMethodOverride != tt.want.AllowMethodOverride {
+					t.Errorf("Parse() AllowMethodOverride = %v, want %v", got.AllowMethodOverride, tt.want.AllowMethodOverride)
+				}
+				if got.AllowBulkUpdate != tt.want.AllowBulkUpdate {
+					t.Errorf("Parse() AllowBulkUpdate = %v, want %v", got.AllowBulkUpdate, tt.want.AllowBulkUpdate)
+				}
+				if got.AllowSchemaEdit != tt.want.AllowSchemaEdit {
+					t.Errorf("Parse() AllowSchemaEdit = %v, want %v", got.AllowSchemaEdit, tt.want.AllowSchemaEdit)
+				}
+				if got.StaticDir != tt.want.StaticDir {
+					t.Errorf("Parse() StaticDir = %v, want %v", got.StaticDir, tt.want.StaticDir)
+				}
+				if got.CaptureFile != tt.want.CaptureFile {
+					t.Errorf("Parse() CaptureFile = %v, want %v", got.CaptureFile, tt.want.CaptureFile)
+				}
+				if got.LenientContentType != tt.want.LenientContentType {
+					t.Errorf("Parse() LenientContentType = %v, want %v", got.LenientContentType, tt.want.LenientContentType)
+				}
+				if got.Metrics != tt.want.Metrics {
+					t.Errorf("Parse() Metrics = %v, want %v", got.Metrics, tt.want.Metrics)
+				}
+				if got.MaxConcurrent != tt.want.MaxConcurrent || got.QueueTimeout != tt.want.QueueTimeout {
+					t.Errorf("Parse() MaxConcurrent, QueueTimeout = %d, %v, want %d, %v", got.MaxConcurrent, got.QueueTimeout, tt.want.MaxConcurrent, tt.want.QueueTimeout)
+				}
+				if got.AllowMaintenance != tt.want.AllowMaintenance {
+					t.Errorf("Parse() AllowMaintenance = %v, want %v", got.AllowMaintenance, tt.want.AllowMaintenance)
+				}
+				if got.AllowReset != tt.want.AllowReset {
+					t.Errorf("Parse() AllowReset = %v, want %v", got.AllowReset, tt.want.AllowReset)
+				}
+				if got.Debug != tt.want.Debug {
+					t.Errorf("Parse() Debug = %v, want %v", got.Debug, tt.want.Debug)
+				}
+				if got.OpenBrowser != tt.want.OpenBrowser {
+					t.Errorf("Parse() OpenBrowser = %v, want %v", got.OpenBrowser, tt.want.OpenBrowser)
+				}
+				wantCommand := tt.want.Command
+				if wantCommand == "" {
+					wantCommand = CommandServe
+				}
+				if got.Command != wantCommand {
+					t.Errorf("Parse() Command = %v, want %v", got.Command, wantCommand)
+				}
+				if got.MaxEntities != tt.want.MaxEntities || got.MaxFields != tt.want.MaxFields {
+					t.Errorf("Parse() limits = %d/%d, want %d/%d", got.MaxEntities, got.MaxFields, tt.want.MaxEntities, tt.want.MaxFields)
+				}
 			}
 		})
 	}
@@ -230,6 +632,42 @@ func TestConfigValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid seed directory",
+			config: &Config{
+				SchemaFile: schemaFile,
+				SeedDir:    tmpDir,
+				Port:       8080,
+			},
+			wantErr: false,
+		},
+		{
+			name: "seed directory not found",
+			config: &Config{
+				SchemaFile: schemaFile,
+				SeedDir:    filepath.Join(tmpDir, "nonexistent"),
+				Port:       8080,
+			},
+			wantErr: true,
+		},
+		{
+			name: "seed directory is a file",
+			config: &Config{
+				SchemaFile: schemaFile,
+				SeedDir:    seedFile,
+				Port:       8080,
+			},
+			wantErr: true,
+		},
+		{
+			name: "static directory not found",
+			config: &Config{
+				SchemaFile: schemaFile,
+				StaticDir:  filepath.Join(tmpDir, "nonexistent"),
+				Port:       8080,
+			},
+			wantErr: true,
+		},
 		{
 			name: "help flag skips validation",
 			config: &Config{
@@ -283,6 +721,15 @@ func TestConfigString(t *testing.T) {
 			},
 			want: "Schema: schema.json, Seed: seed.json, Port: 3000",
 		},
+		{
+			name: "schema with seed directory",
+			config: &Config{
+				SchemaFile: "schema.json",
+				SeedDir:    "seeds",
+				Port:       8080,
+			},
+			want: "Schema: schema.json, Seed dir: seeds, Port: 8080",
+		},
 	}
 
 	for _, tt := range tests {
@@ -295,6 +742,53 @@ func TestConfigString(t *testing.T) {
 	}
 }
 
+func TestPickSeed(t *testing.T) {
+	choices := []SeedChoice{{File: "a.json", Weight: 1}, {File: "b.json", Weight: 3}}
+
+	// The same random seed always picks the same file
+	first := PickSeed(choices, rand.New(rand.NewSource(7)))
+	for i := 0; i < 5; i++ {
+		if got := PickSeed(choices, rand.New(rand.NewSource(7))); got != first {
+			t.Fatalf("PickSeed() = %s, want %s for the same seed", got, first)
+		}
+	}
+
+	counts := map[string]int{}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 4000; i++ {
+		counts[PickSeed(choices, rng)]++
+	}
+	if counts["b.json"] < 2700 || counts["b.json"] > 3300 {
+		t.Errorf("b.json picked %d of 4000 times, want about 3000", counts["b.json"])
+	}
+}
+
+func TestResolvePort(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		schemaPort int
+		want       int
+	}{
+		{"default", []string{"schema.json"}, 0, DefaultPort},
+		{"schema port", []string{"schema.json"}, 3000, 3000},
+		{"cli overrides schema", []string{"schema.json", "on", "4000"}, 3000, 4000},
+		{"cli default port overrides schema", []string{"schema.json", "on", "8080"}, 3000, 8080},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := Parse(tt.args)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := config.ResolvePort(tt.schemaPort); got != tt.want {
+				t.Errorf("ResolvePort(%d) = %d, want %d", tt.schemaPort, got, tt.want)
+			}
+		})
+	}
+}
+
 // Helper function to check if a string contains a substring
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 ||
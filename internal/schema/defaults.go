@@ -0,0 +1,115 @@
+package schema
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+// Dynamic tokens that may appear in string defaults
+const (
+	TokenNow       = "{{now}}"       // current time (the field's layout for datetime fields, RFC3339 otherwise)
+	TokenTimestamp = "{{timestamp}}" // Unix time in seconds
+	TokenUUID      = "{{uuid}}"      // random version 4 UUID
+)
+
+// tokenPattern matches {{name}} tokens
+var tokenPattern = regexp.MustCompile(`\{\{[^{}]*\}\}`)
+
+// knownTokens lists every supported token
+var knownTokens = map[string]bool{
+	TokenNow:       true,
+	TokenTimestamp: true,
+	TokenUUID:      true,
+}
+
+// validateDefault checks that a field's default is a scalar, uses only known
+// tokens, and expands to a value the field accepts
+func validateDefault(field *types.Field) error {
+	switch value := field.Default.(type) {
+	case string:
+		for _, token := range tokenPattern.FindAllString(value, -1) {
+			if !knownTokens[token] {
+				return fmt.Errorf("unknown token %s in default (supported: %s, %s, %s)", token, TokenNow, TokenTimestamp, TokenUUID)
+			}
+		}
+	case float64, json.Number, bool, []interface{}, map[string]interface{}:
+	default:
+		return fmt.Errorf("default must be a JSON value, got %T", field.Default)
+	}
+
+	expanded := ExpandDefault(field, time.Now())
+	if err := validateFieldValue(field.Type, expanded); err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+	if err := CheckFieldConstraints(field, expanded); err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+	return nil
+}
+
+// ExpandDefault returns the value to store for a field's default, expanding
+// dynamic tokens against now. A default that is exactly {{timestamp}} on a
+// number or integer field yields a number; tokens inside longer strings are
+// substituted as text. Array and object defaults are deep-copied so entities
+// never share them.
+func ExpandDefault(field *types.Field, now time.Time) interface{} {
+	str, ok := field.Default.(string)
+	if !ok {
+		return types.CopyValue(field.Default)
+	}
+
+	if str == TokenTimestamp && (field.Type == types.FieldTypeNumber || field.Type == types.FieldTypeInteger) {
+		return json.Number(strconv.FormatInt(now.Unix(), 10))
+	}
+
+	return tokenPattern.ReplaceAllStringFunc(str, func(token string) string {
+		switch token {
+		case TokenNow:
+			layout := time.RFC3339
+			if field.Type == types.FieldTypeDatetime {
+				layout = DatetimeLayout(field)
+			}
+			return now.UTC().Format(layout)
+		case TokenTimestamp:
+			return strconv.FormatInt(now.Unix(), 10)
+		case TokenUUID:
+			return newUUID()
+		}
+		return token
+	})
+}
+
+// ApplyDefaults sets each absent field that has a default. Fields present in
+// data, including explicit nulls, are left alone.
+func ApplyDefaults(entity *types.Entity, data map[string]interface{}, now time.Time) {
+	for fieldName, field := range entity.Fields {
+		if field.Default == nil {
+			continue
+		}
+		if _, present := data[fieldName]; present {
+			continue
+		}
+		data[fieldName] = ExpandDefault(field, now)
+	}
+}
+
+// newUUID returns a random (version 4) UUID string
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand only fails if the OS source is unavailable
+		panic(fmt.Sprintf("failed to generate UUID: %v", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+
+	hex := fmt.Sprintf("%x", b[:])
+	return strings.Join([]string{hex[0:8], hex[8:12], hex[12:16], hex[16:20], hex[20:32]}, "-")
+}
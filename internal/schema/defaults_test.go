@@ -0,0 +1,116 @@
+package schema
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+func TestExpandDefault(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		field *types.Field
+		want  interface{}
+	}{
+		{
+			name:  "static string",
+			field: &types.Field{Type: types.FieldTypeString, Default: "new"},
+			want:  "new",
+		},
+		{
+			name:  "static boolean",
+			field: &types.Field{Type: types.FieldTypeBoolean, Default: true},
+			want:  true,
+		},
+		{
+			name:  "now on datetime uses layout",
+			field: &types.Field{Type: types.FieldTypeDatetime, Layout: "2006-01-02", Default: "{{now}}"},
+			want:  "2024-03-01",
+		},
+		{
+			name:  "now on string is RFC3339",
+			field: &types.Field{Type: types.FieldTypeString, Default: "{{now}}"},
+			want:  "2024-03-01T12:30:00Z",
+		},
+		{
+			name:  "timestamp on integer is a number",
+			field: &types.Field{Type: types.FieldTypeInteger, Default: "{{timestamp}}"},
+			want:  json.Number("1709296200"),
+		},
+		{
+			name:  "embedded token",
+			field: &types.Field{Type: types.FieldTypeString, Default: "created-{{timestamp}}"},
+			want:  "created-1709296200",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExpandDefault(tt.field, now); got != tt.want {
+				t.Errorf("ExpandDefault() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandDefaultUUID(t *testing.T) {
+	field := &types.Field{Type: types.FieldTypeString, Default: "{{uuid}}"}
+	pattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	first, _ := ExpandDefault(field, time.Now()).(string)
+	second, _ := ExpandDefault(field, time.Now()).(string)
+	if !pattern.MatchString(first) {
+		t.Errorf("ExpandDefault() = %q, want a v4 UUID", first)
+	}
+	if first == second {
+		t.Errorf("expected a fresh UUID per call, got %q twice", first)
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	entity := &types.Entity{Fields: map[string]*types.Field{
+		"id":     {Type: types.FieldTypeString},
+		"status": {Type: types.FieldTypeString, Default: "new"},
+		"note":   {Type: types.FieldTypeString, Default: "none"},
+	}}
+	data := map[string]interface{}{"note": nil}
+
+	ApplyDefaults(entity, data, time.Now())
+
+	if data["status"] != "new" {
+		t.Errorf("status = %v, want default %q", data["status"], "new")
+	}
+	if data["note"] != nil {
+		t.Errorf("note = %v, want explicit null kept", data["note"])
+	}
+	if _, exists := data["id"]; exists {
+		t.Error("fields without a default should stay absent")
+	}
+}
+
+func TestApplyDefaultsCopiesArraysAndObjects(t *testing.T) {
+	entity := &types.Entity{Fields: map[string]*types.Field{
+		"tags":  {Type: types.FieldTypeArray, Default: []interface{}{"new"}},
+		"prefs": {Type: types.FieldTypeObject, Default: map[string]interface{}{"theme": "dark"}},
+	}}
+
+	first := map[string]interface{}{}
+	second := map[string]interface{}{}
+	ApplyDefaults(entity, first, time.Now())
+	ApplyDefaults(entity, second, time.Now())
+
+	first["tags"].([]interface{})[0] = "changed"
+	first["prefs"].(map[string]interface{})["theme"] = "light"
+
+	if second["tags"].([]interface{})[0] != "new" || second["prefs"].(map[string]interface{})["theme"] != "dark" {
+		t.Errorf("entities share default values: %v", second)
+	}
+	if entity.Fields["tags"].Default.([]interface{})[0] != "new" {
+		t.Error("the schema default was modified")
+	}
+}
@@ -0,0 +1,65 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+// resolveExtends merges the fields of each entity's extended definition into
+// the entity. Fields the entity declares itself are kept as they are.
+func (l *Loader) resolveExtends() error {
+	for name, entity := range l.schema.Entities {
+		if entity == nil || entity.Extends == "" {
+			continue
+		}
+		inherited, err := l.definitionFields(entity.Extends, nil)
+		if err != nil {
+			return fmt.Errorf("entity %q: %w", name, err)
+		}
+		if entity.Fields == nil {
+			entity.Fields = make(map[string]*types.Field, len(inherited))
+		}
+		for fieldName, field := range inherited {
+			if _, own := entity.Fields[fieldName]; !own {
+				copied := *field
+				entity.Fields[fieldName] = &copied
+			}
+		}
+	}
+	return nil
+}
+
+// definitionFields returns a definition's fields together with those of the
+// definitions it extends. chain holds the definitions already being resolved,
+// so a cycle is reported instead of recursing forever.
+func (l *Loader) definitionFields(name string, chain []string) (map[string]*types.Field, error) {
+	for i, seen := range chain {
+		if seen == name {
+			return nil, fmt.Errorf("circular extends: %s", strings.Join(append(chain[i:], name), " -> "))
+		}
+	}
+	definition, exists := l.schema.Definitions[name]
+	if !exists || definition == nil {
+		return nil, fmt.Errorf("extends unknown definition %q", name)
+	}
+
+	fields := make(map[string]*types.Field, len(definition.Fields))
+	if definition.Extends != "" {
+		parent, err := l.definitionFields(definition.Extends, append(chain, name))
+		if err != nil {
+			return nil, err
+		}
+		for fieldName, field := range parent {
+			fields[fieldName] = field
+		}
+	}
+	for fieldName, field := range definition.Fields {
+		if field == nil {
+			return nil, fmt.Errorf("definition %q: field %q has no definition", name, fieldName)
+		}
+		fields[fieldName] = field
+	}
+	return fields, nil
+}
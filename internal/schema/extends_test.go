@@ -0,0 +1,88 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+func TestResolveExtends(t *testing.T) {
+	schemaJSON := `{
+		"definitions": {
+			"record": {"fields": {"id": {"type": "string", "required": true}, "createdAt": {"type": "datetime"}}},
+			"person": {"extends": "record", "fields": {"name": {"type": "string", "required": true}, "email": {"type": "string"}}}
+		},
+		"entities": {
+			"users": {"extends": "person"},
+			"admins": {"extends": "person", "fields": {"email": {"type": "string", "required": true}, "level": {"type": "integer"}}}
+		}
+	}`
+	var schema types.Schema
+	if err := json.Unmarshal([]byte(schemaJSON), &schema); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	loader := NewLoader()
+	loader.schema = &schema
+	if err := loader.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	users, _ := loader.GetEntity("users")
+	for _, name := range []string{"id", "createdAt", "name", "email"} {
+		if _, ok := users.Fields[name]; !ok {
+			t.Errorf("users missing inherited field %q", name)
+		}
+	}
+
+	admins, _ := loader.GetEntity("admins")
+	if len(admins.Fields) != 5 {
+		t.Errorf("admins has %d fields, want 5", len(admins.Fields))
+	}
+	if !admins.Fields["email"].Required {
+		t.Error("admins.email should keep its own definition")
+	}
+	if users.Fields["email"].Required {
+		t.Error("overriding email on admins should not affect users")
+	}
+}
+
+func TestResolveExtendsErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		schemaJSON  string
+		errContains string
+	}{
+		{
+			name: "circular",
+			schemaJSON: `{
+				"definitions": {
+					"a": {"extends": "b", "fields": {"id": {"type": "string"}}},
+					"b": {"extends": "a", "fields": {}}
+				},
+				"entities": {"users": {"extends": "a"}}
+			}`,
+			errContains: "circular extends: a -> b -> a",
+		},
+		{
+			name:        "unknown definition",
+			schemaJSON:  `{"entities": {"users": {"extends": "person", "fields": {"id": {"type": "string"}}}}}`,
+			errContains: `extends unknown definition "person"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schema types.Schema
+			if err := json.Unmarshal([]byte(tt.schemaJSON), &schema); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			loader := NewLoader()
+			loader.schema = &schema
+			err := loader.Validate()
+			if err == nil || !contains(err.Error(), tt.errContains) {
+				t.Errorf("Validate() error = %v, want error containing %q", err, tt.errContains)
+			}
+		})
+	}
+}
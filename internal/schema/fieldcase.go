@@ -0,0 +1,88 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+// ConvertFieldCase renders a schema field name in the given fieldCase style.
+// An empty style and the id field are returned unchanged.
+func ConvertFieldCase(style, name string) string {
+	if name == "id" {
+		return name
+	}
+	switch style {
+	case types.FieldCaseCamel:
+		return toCamel(name)
+	case types.FieldCaseSnake:
+		return toSnake(name)
+	}
+	return name
+}
+
+// toCamel converts snake_case to camelCase ("first_name" -> "firstName").
+// Leading underscores are kept so "_private" stays distinct from "private".
+func toCamel(name string) string {
+	trimmed := strings.TrimLeft(name, "_")
+	var b strings.Builder
+	b.WriteString(name[:len(name)-len(trimmed)])
+	for i, part := range strings.Split(trimmed, "_") {
+		if part == "" {
+			continue
+		}
+		if i == 0 {
+			b.WriteString(part)
+			continue
+		}
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
+
+// toSnake converts camelCase to snake_case ("userID" -> "user_id")
+func toSnake(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (nextLower && unicode.IsUpper(runes[i-1])) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// validateFieldCase checks the fieldCase style and that no two fields of an
+// entity map to the same external name
+func validateFieldCase(style string, entities map[string]*types.Entity) error {
+	switch style {
+	case "":
+		return nil
+	case types.FieldCaseCamel, types.FieldCaseSnake:
+	default:
+		return fmt.Errorf("invalid fieldCase %q: must be %q or %q", style, types.FieldCaseCamel, types.FieldCaseSnake)
+	}
+
+	for entityName, entity := range entities {
+		seen := make(map[string]string, len(entity.Fields))
+		for fieldName := range entity.Fields {
+			external := ConvertFieldCase(style, fieldName)
+			if other, clash := seen[external]; clash {
+				return fmt.Errorf("entity %q: fields %q and %q both map to %q with fieldCase %q", entityName, other, fieldName, external, style)
+			}
+			seen[external] = fieldName
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+func TestConvertFieldCase(t *testing.T) {
+	tests := []struct {
+		style string
+		name  string
+		want  string
+	}{
+		{types.FieldCaseCamel, "first_name", "firstName"},
+		{types.FieldCaseCamel, "created_at_utc", "createdAtUtc"},
+		{types.FieldCaseCamel, "email", "email"},
+		{types.FieldCaseCamel, "_private", "_private"},
+		{types.FieldCaseSnake, "firstName", "first_name"},
+		{types.FieldCaseSnake, "userID", "user_id"},
+		{types.FieldCaseSnake, "HTTPStatus", "http_status"},
+		{types.FieldCaseSnake, "address2Line", "address2_line"},
+		{types.FieldCaseSnake, "id", "id"},
+		{"", "first_name", "first_name"},
+	}
+
+	for _, tt := range tests {
+		if got := ConvertFieldCase(tt.style, tt.name); got != tt.want {
+			t.Errorf("ConvertFieldCase(%q, %q) = %q, want %q", tt.style, tt.name, got, tt.want)
+		}
+	}
+}
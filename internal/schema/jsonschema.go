@@ -0,0 +1,219 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+// jsonSchemaNode is the subset of a JSON Schema (draft-07) document that maps
+// onto ape_my entities and fields
+type jsonSchemaNode struct {
+	Type            interface{}                `json:"type"` // string or []string
+	Format          string                     `json:"format"`
+	ContentEncoding string                     `json:"contentEncoding"`
+	Properties      map[string]*jsonSchemaNode `json:"properties"`
+	Required        []string                   `json:"required"`
+	Minimum         *float64                   `json:"minimum"`
+	Maximum         *float64                   `json:"maximum"`
+	Definitions     map[string]*jsonSchemaNode `json:"definitions"`
+	Defs            map[string]*jsonSchemaNode `json:"$defs"`
+
+	// Constructs that have no equivalent and are reported as warnings
+	Ref   string        `json:"$ref"`
+	AllOf []interface{} `json:"allOf"`
+	AnyOf []interface{} `json:"anyOf"`
+	OneOf []interface{} `json:"oneOf"`
+	Enum  []interface{} `json:"enum"`
+}
+
+// ErrNoDefinitions is returned when a JSON Schema has no entity definitions
+var ErrNoDefinitions = errors.New("JSON Schema has no definitions (expected \"definitions\" or \"$defs\")")
+
+// LoadFromJSONSchemaFile loads entities from a JSON Schema (draft-07) file.
+// Each object schema under "definitions" (or "$defs") becomes an entity named
+// by its key; "properties", "required", "type", "format", "minimum" and
+// "maximum" become fields. Constructs with no ape_my equivalent are skipped
+// and returned as warnings instead of failing the load.
+func (l *Loader) LoadFromJSONSchemaFile(filepath string) ([]string, error) {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema file: %w", err)
+	}
+
+	return l.LoadJSONSchema(data)
+}
+
+// LoadJSONSchema loads entities from a JSON Schema (draft-07) document, as
+// LoadFromJSONSchemaFile does for a file
+func (l *Loader) LoadJSONSchema(data []byte) ([]string, error) {
+	var root jsonSchemaNode
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON Schema: %w", err)
+	}
+
+	schema, warnings, err := translateJSONSchema(&root)
+	if err != nil {
+		return warnings, err
+	}
+
+	l.schema = schema
+	if err := l.Validate(); err != nil {
+		return warnings, fmt.Errorf("schema validation failed: %w", err)
+	}
+
+	return warnings, nil
+}
+
+// translateJSONSchema maps definitions onto types.Schema
+func translateJSONSchema(root *jsonSchemaNode) (*types.Schema, []string, error) {
+	definitions := root.Definitions
+	if len(definitions) == 0 {
+		definitions = root.Defs
+	}
+	if len(definitions) == 0 {
+		return nil, nil, ErrNoDefinitions
+	}
+
+	var warnings []string
+	schema := &types.Schema{Entities: make(map[string]*types.Entity)}
+
+	for _, name := range sortedNodeKeys(definitions) {
+		def := definitions[name]
+		if def == nil {
+			continue
+		}
+		if defType, _ := nodeType(def); defType != "object" {
+			warnings = append(warnings, fmt.Sprintf("definition %q: skipped, only object schemas become entities", name))
+			continue
+		}
+
+		entity, entityWarnings := translateEntity(name, def)
+		warnings = append(warnings, entityWarnings...)
+		schema.Entities[name] = entity
+	}
+
+	return schema, warnings, nil
+}
+
+// translateEntity maps an object schema onto an entity
+func translateEntity(name string, def *jsonSchemaNode) (*types.Entity, []string) {
+	var warnings []string
+	warn := func(format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf("definition %q: ", name)+fmt.Sprintf(format, args...))
+	}
+
+	required := make(map[string]bool, len(def.Required))
+	for _, fieldName := range def.Required {
+		required[fieldName] = true
+	}
+
+	entity := &types.Entity{Fields: make(map[string]*types.Field)}
+	for _, fieldName := range sortedNodeKeys(def.Properties) {
+		prop := def.Properties[fieldName]
+		if prop == nil {
+			continue
+		}
+		if unsupported := unsupportedConstructs(prop); len(unsupported) > 0 {
+			warn("property %q: ignoring unsupported %s", fieldName, strings.Join(unsupported, ", "))
+		}
+
+		fieldType, ok := nodeType(prop)
+		if !ok {
+			warn("property %q: skipped, no single type", fieldName)
+			continue
+		}
+
+		field := &types.Field{Type: fieldType, Required: required[fieldName]}
+		switch fieldType {
+		case "string":
+			switch {
+			case prop.Format == "date-time":
+				field.Type = types.FieldTypeDatetime
+			case prop.ContentEncoding == "base64":
+				field.Type = types.FieldTypeBinary
+			case prop.Format != "":
+				warn("property %q: format %q not enforced", fieldName, prop.Format)
+			}
+		case "number", "integer":
+			field.Min = prop.Minimum
+			field.Max = prop.Maximum
+		case "boolean", "object", "array":
+		default:
+			warn("property %q: skipped, unsupported type %q", fieldName, fieldType)
+			continue
+		}
+		entity.Fields[fieldName] = field
+	}
+
+	// ape_my identifies entities by a string id
+	if idField, exists := entity.Fields["id"]; !exists {
+		warn("no \"id\" property, adding a string id")
+		entity.Fields["id"] = &types.Field{Type: types.FieldTypeString, Required: true}
+	} else if idField.Type != types.FieldTypeString {
+		warn("\"id\" is %s, using string ids", idField.Type)
+		entity.Fields["id"] = &types.Field{Type: types.FieldTypeString, Required: idField.Required}
+	}
+
+	return entity, warnings
+}
+
+// nodeType returns the node's single JSON type. A ["T", "null"] union maps to T
+// since ape_my fields already accept null.
+func nodeType(node *jsonSchemaNode) (string, bool) {
+	switch t := node.Type.(type) {
+	case string:
+		return t, true
+	case []interface{}:
+		var nonNull []string
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				nonNull = append(nonNull, s)
+			}
+		}
+		if len(nonNull) == 1 {
+			return nonNull[0], true
+		}
+	}
+	// Untyped nodes with properties are objects by convention
+	if node.Type == nil && node.Properties != nil {
+		return "object", true
+	}
+	return "", false
+}
+
+// unsupportedConstructs lists keywords on a node that cannot be represented
+func unsupportedConstructs(node *jsonSchemaNode) []string {
+	var found []string
+	if node.Ref != "" {
+		found = append(found, "$ref")
+	}
+	if node.AllOf != nil {
+		found = append(found, "allOf")
+	}
+	if node.AnyOf != nil {
+		found = append(found, "anyOf")
+	}
+	if node.OneOf != nil {
+		found = append(found, "oneOf")
+	}
+	if node.Enum != nil {
+		found = append(found, "enum")
+	}
+	return found
+}
+
+// sortedNodeKeys returns map keys in sorted order so warnings are deterministic
+func sortedNodeKeys(m map[string]*jsonSchemaNode) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
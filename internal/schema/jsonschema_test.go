@@ -0,0 +1,124 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+func TestLoadFromJSONSchemaFile(t *testing.T) {
+	schemaJSON := `{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"definitions": {
+			"users": {
+				"type": "object",
+				"required": ["name"],
+				"properties": {
+					"id":     {"type": "integer"},
+					"name":   {"type": "string"},
+					"age":    {"type": "integer", "minimum": 0, "maximum": 150},
+					"joined": {"type": "string", "format": "date-time"},
+					"avatar": {"type": "string", "contentEncoding": "base64"},
+					"nick":   {"type": ["string", "null"]},
+					"role":   {"type": "string", "enum": ["admin", "user"]},
+					"group":  {"$ref": "#/definitions/groups"}
+				}
+			},
+			"groups": {
+				"properties": {
+					"title": {"type": "string"}
+				}
+			},
+			"status": {"type": "string"}
+		}
+	}`
+	path := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(path, []byte(schemaJSON), 0644); err != nil {
+		t.Fatalf("failed to create schema file: %v", err)
+	}
+
+	loader := NewLoader()
+	warnings, err := loader.LoadFromJSONSchemaFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromJSONSchemaFile() error = %v", err)
+	}
+
+	users, ok := loader.GetEntity("users")
+	if !ok {
+		t.Fatal("expected users entity")
+	}
+	wantTypes := map[string]string{
+		"id":     types.FieldTypeString,
+		"name":   types.FieldTypeString,
+		"age":    types.FieldTypeInteger,
+		"joined": types.FieldTypeDatetime,
+		"avatar": types.FieldTypeBinary,
+		"nick":   types.FieldTypeString,
+		"role":   types.FieldTypeString,
+	}
+	for name, wantType := range wantTypes {
+		field, exists := users.Fields[name]
+		if !exists {
+			t.Errorf("missing field %q", name)
+			continue
+		}
+		if field.Type != wantType {
+			t.Errorf("field %q type = %q, want %q", name, field.Type, wantType)
+		}
+	}
+	if !users.Fields["name"].Required || users.Fields["age"].Required {
+		t.Error("required flags not translated")
+	}
+	if age := users.Fields["age"]; age.Min == nil || *age.Min != 0 || age.Max == nil || *age.Max != 150 {
+		t.Errorf("age bounds not translated: %+v", age)
+	}
+	if _, exists := users.Fields["group"]; exists {
+		t.Error("$ref property should be skipped")
+	}
+
+	// Untyped object schemas become entities and get a string id
+	groups, ok := loader.GetEntity("groups")
+	if !ok || groups.Fields["id"] == nil || groups.Fields["id"].Type != types.FieldTypeString {
+		t.Errorf("groups entity = %+v, want entity with string id", groups)
+	}
+	if _, ok := loader.GetEntity("status"); ok {
+		t.Error("non-object definition should not become an entity")
+	}
+
+	wantWarnings := []string{`"status"`, `"id" is integer`, "enum", "$ref", `no "id" property`}
+	joined := strings.Join(warnings, "\n")
+	for _, want := range wantWarnings {
+		if !strings.Contains(joined, want) {
+			t.Errorf("warnings missing %q:\n%s", want, joined)
+		}
+	}
+}
+
+func TestLoadFromJSONSchemaFileErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		schemaJSON  string
+		errContains string
+	}{
+		{"invalid JSON", `{nope`, "failed to parse JSON Schema"},
+		{"no definitions", `{"type": "object", "properties": {}}`, "has no definitions"},
+		{"no object definitions", `{"$defs": {"status": {"type": "string"}}}`, "schema contains no entities"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "schema.json")
+			if err := os.WriteFile(path, []byte(tt.schemaJSON), 0644); err != nil {
+				t.Fatalf("failed to create schema file: %v", err)
+			}
+
+			_, err := NewLoader().LoadFromJSONSchemaFile(path)
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("error = %v, want error containing %q", err, tt.errContains)
+			}
+		})
+	}
+}
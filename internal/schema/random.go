@@ -0,0 +1,108 @@
+package schema
+
+import (
+	"encoding/base64"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+// Bounds used for random numbers when the field sets no min or max
+const (
+	randomMinDefault = 0
+	randomMaxDefault = 1000
+)
+
+// randomAlphabet is used for random string values
+const randomAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// randomEpoch and randomSpan bound random datetimes (2000-01-01 through 2029)
+var (
+	randomEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	randomSpan  = time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC).Sub(randomEpoch)
+)
+
+// maxRandomInteger bounds min and max on random integer fields. Past 2^53
+// the bounds are no longer exact as float64, and their range can overflow
+// int64.
+const maxRandomInteger = 1 << 53
+
+// validateRandom checks that a random field has a type and bounds values can
+// be generated for
+func validateRandom(name string, field *types.Field) error {
+	if name == "id" {
+		return fmt.Errorf("the id field cannot be random")
+	}
+	if field.Default != nil {
+		return fmt.Errorf("random and default cannot be used together")
+	}
+	switch field.Type {
+	case types.FieldTypeObject, types.FieldTypeArray:
+		return fmt.Errorf("random is not supported on %s fields", field.Type)
+	case types.FieldTypeInteger:
+		for _, bound := range []*float64{field.Min, field.Max} {
+			if bound != nil && math.Abs(*bound) > maxRandomInteger {
+				return fmt.Errorf("random integer min and max must be within ±%d", int64(maxRandomInteger))
+			}
+		}
+	case types.FieldTypeNumber:
+		if field.Min != nil && field.Max != nil && math.IsInf(*field.Max-*field.Min, 0) {
+			return fmt.Errorf("random number range from min to max is too wide")
+		}
+	}
+	return nil
+}
+
+// RandomValue generates a value for a random field. The generator is seeded
+// from entityName, id and fieldName, so the same entity always gets the same
+// value, across restarts too. Numbers respect the field's min and max.
+func RandomValue(field *types.Field, entityName, id, fieldName string) interface{} {
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%s\x00%s\x00%s", entityName, id, fieldName)
+	rng := rand.New(rand.NewSource(int64(hash.Sum64())))
+
+	low, high := float64(randomMinDefault), float64(randomMaxDefault)
+	if field.Min != nil {
+		low = *field.Min
+		if field.Max == nil {
+			high = low + randomMaxDefault
+		}
+	}
+	if field.Max != nil {
+		high = *field.Max
+		if field.Min == nil && high < low {
+			low = high - randomMaxDefault
+		}
+	}
+
+	switch field.Type {
+	case types.FieldTypeNumber:
+		value := low + rng.Float64()*(high-low)
+		return math.Round(value*100) / 100
+	case types.FieldTypeInteger:
+		low, high = math.Ceil(low), math.Floor(high)
+		if high < low {
+			return int64(low)
+		}
+		return int64(low) + rng.Int63n(int64(high-low)+1)
+	case types.FieldTypeBoolean:
+		return rng.Intn(2) == 1
+	case types.FieldTypeBinary:
+		raw := make([]byte, 16)
+		rng.Read(raw)
+		return base64.StdEncoding.EncodeToString(raw)
+	case types.FieldTypeDatetime:
+		at := randomEpoch.Add(time.Duration(rng.Int63n(int64(randomSpan))))
+		return at.Truncate(time.Second).Format(DatetimeLayout(field))
+	}
+
+	value := make([]byte, 12)
+	for i := range value {
+		value[i] = randomAlphabet[rng.Intn(len(randomAlphabet))]
+	}
+	return string(value)
+}
@@ -0,0 +1,68 @@
+package schema
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+func TestRandomValue(t *testing.T) {
+	low, high := 10.0, 20.0
+	tests := []struct {
+		name  string
+		field *types.Field
+		check func(value interface{}) bool
+	}{
+		{"string", &types.Field{Type: types.FieldTypeString}, func(v interface{}) bool {
+			s, ok := v.(string)
+			return ok && len(s) == 12
+		}},
+		{"integer within bounds", &types.Field{Type: types.FieldTypeInteger, Min: &low, Max: &high}, func(v interface{}) bool {
+			n, ok := v.(int64)
+			return ok && n >= 10 && n <= 20
+		}},
+		{"number within bounds", &types.Field{Type: types.FieldTypeNumber, Min: &low, Max: &high}, func(v interface{}) bool {
+			n, ok := v.(float64)
+			return ok && n >= 10 && n <= 20
+		}},
+		{"boolean", &types.Field{Type: types.FieldTypeBoolean}, func(v interface{}) bool {
+			_, ok := v.(bool)
+			return ok
+		}},
+		{"datetime", &types.Field{Type: types.FieldTypeDatetime, Layout: "2006-01-02"}, func(v interface{}) bool {
+			s, ok := v.(string)
+			if !ok {
+				return false
+			}
+			_, err := time.Parse("2006-01-02", s)
+			return err == nil
+		}},
+		{"binary", &types.Field{Type: types.FieldTypeBinary}, func(v interface{}) bool {
+			return validateFieldValue(types.FieldTypeBinary, v) == nil
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, id := range []string{"1", "2", "42"} {
+				value := RandomValue(tt.field, "users", id, "value")
+				if !tt.check(value) {
+					t.Errorf("RandomValue() for id %s = %#v, not a valid %s", id, value, tt.field.Type)
+				}
+				if again := RandomValue(tt.field, "users", id, "value"); again != value {
+					t.Errorf("RandomValue() for id %s = %#v then %#v, want the same value", id, value, again)
+				}
+			}
+		})
+	}
+
+	// Different ids and fields draw from different seeds
+	field := &types.Field{Type: types.FieldTypeString}
+	if RandomValue(field, "users", "1", "name") == RandomValue(field, "users", "2", "name") {
+		t.Error("ids 1 and 2 produced the same value")
+	}
+	if RandomValue(field, "users", "1", "name") == RandomValue(field, "users", "1", "city") {
+		t.Error("fields name and city produced the same value")
+	}
+}
@@ -12,6 +12,10 @@ type RouteInfo struct {
 	ItemPath       string // e.g., "/users/{id}"
 }
 
+// HistoryPath is the item sub-path serving an entity's history when its
+// type sets trackHistory: GET /users/1/history
+const HistoryPath = "history"
+
 // RouteMap maps entity names to their route information
 type RouteMap map[string]*RouteInfo
 
@@ -31,6 +35,31 @@ func NormalizeBasePath(basePath string) string {
 	return basePath
 }
 
+// PathParams returns the parameter names from a route path using :param syntax
+func PathParams(path string) []string {
+	var names []string
+	for _, part := range strings.Split(path, "/") {
+		if strings.HasPrefix(part, ":") {
+			names = append(names, part[1:])
+		}
+	}
+	return names
+}
+
+// CustomRoutePath prefixes a custom route path with the normalized base path.
+// Paths that already start with the base path are returned unchanged so they
+// are not double-prefixed.
+func CustomRoutePath(basePath, path string) string {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	prefix := NormalizeBasePath(basePath)
+	if prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/") {
+		return path
+	}
+	return prefix + path
+}
+
 // BuildRouteMap creates a route map from the loaded schema
 func (l *Loader) BuildRouteMap() (RouteMap, error) {
 	if l.schema == nil {
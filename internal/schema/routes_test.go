@@ -113,3 +113,63 @@ func TestBuildRouteMapWithBasePath(t *testing.T) {
 		})
 	}
 }
+
+func TestCustomRoutePathWithBasePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		basePath string
+		path     string
+		wantPath string
+	}{
+		{
+			name:     "no base path",
+			basePath: "",
+			path:     "/users/me",
+			wantPath: "/users/me",
+		},
+		{
+			name:     "api prefix",
+			basePath: "/api/v1",
+			path:     "/users/me",
+			wantPath: "/api/v1/users/me",
+		},
+		{
+			name:     "already prefixed",
+			basePath: "/api/v1",
+			path:     "/api/v1/users/me",
+			wantPath: "/api/v1/users/me",
+		},
+		{
+			name:     "shared prefix text is not the base path",
+			basePath: "/api",
+			path:     "/apis/list",
+			wantPath: "/api/apis/list",
+		},
+		{
+			name:     "base path without leading slash",
+			basePath: "2",
+			path:     "/tweets/search",
+			wantPath: "/2/tweets/search",
+		},
+		{
+			name:     "base path with trailing slash",
+			basePath: "/2/",
+			path:     "/tweets/search",
+			wantPath: "/2/tweets/search",
+		},
+		{
+			name:     "path without leading slash",
+			basePath: "/api/v1",
+			path:     "users/me",
+			wantPath: "/api/v1/users/me",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CustomRoutePath(tt.basePath, tt.path); got != tt.wantPath {
+				t.Errorf("CustomRoutePath(%q, %q) = %q, want %q", tt.basePath, tt.path, got, tt.wantPath)
+			}
+		})
+	}
+}
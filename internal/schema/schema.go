@@ -1,10 +1,19 @@
 package schema
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ticktockbent/ape_my/pkg/types"
 )
@@ -26,6 +35,24 @@ var (
 // Loader handles loading and validating schemas
 type Loader struct {
 	schema *types.Schema
+	limits Limits
+}
+
+// Limits caps schema size as a guard against runaway generated schemas.
+// Zero means unlimited.
+type Limits struct {
+	MaxEntities int // maximum number of entity types
+	MaxFields   int // maximum number of fields per entity
+}
+
+// SetLimits sets the size limits enforced by Validate
+func (l *Loader) SetLimits(limits Limits) {
+	l.limits = limits
+}
+
+// Limits returns the size limits enforced by Validate
+func (l *Loader) Limits() Limits {
+	return l.limits
 }
 
 // NewLoader creates a new schema loader
@@ -41,6 +68,11 @@ func (l *Loader) LoadFromFile(filepath string) error {
 		return fmt.Errorf("failed to read schema file: %w", err)
 	}
 
+	return l.Load(data)
+}
+
+// Load parses and validates a schema from JSON
+func (l *Loader) Load(data []byte) error {
 	// Parse JSON
 	var schema types.Schema
 	if err := json.Unmarshal(data, &schema); err != nil {
@@ -68,6 +100,24 @@ func (l *Loader) Validate() error {
 		return ErrEmptySchema
 	}
 
+	// Everything below sees entities with their inherited fields
+	if err := l.resolveExtends(); err != nil {
+		return err
+	}
+
+	if err := l.validateLimits(); err != nil {
+		return err
+	}
+
+	// Names become URL path segments and query parameters
+	if invalid := unsafeNames(l.schema.Entities); len(invalid) > 0 {
+		return fmt.Errorf("invalid entity or field names %s: names may only contain letters, digits, '-', '_', '.' and '~'", strings.Join(invalid, ", "))
+	}
+
+	if err := validateBasePath(l.schema.BasePath); err != nil {
+		return err
+	}
+
 	// Validate each entity
 	for entityName, entity := range l.schema.Entities {
 		if err := l.validateEntity(entityName, entity); err != nil {
@@ -75,6 +125,480 @@ func (l *Loader) Validate() error {
 		}
 	}
 
+	if l.schema.Pagination != nil {
+		if err := validatePaginationKeys(l.schema.Pagination.Keys); err != nil {
+			return fmt.Errorf("pagination: %w", err)
+		}
+		if l.schema.Pagination.MaxReportedCount < 0 {
+			return fmt.Errorf("pagination: maxReportedCount must not be negative, got %d", l.schema.Pagination.MaxReportedCount)
+		}
+	}
+
+	if err := validateListFormat(l.schema.ListFormat); err != nil {
+		return err
+	}
+
+	if err := validateFieldCase(l.schema.FieldCase, l.schema.Entities); err != nil {
+		return err
+	}
+
+	if err := validateFormat(l.schema); err != nil {
+		return err
+	}
+
+	if err := validateMiddleware(l.schema.Middleware); err != nil {
+		return err
+	}
+
+	if l.schema.Port < 0 || l.schema.Port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535, got %d", l.schema.Port)
+	}
+
+	for _, route := range l.schema.Routes {
+		if route == nil {
+			continue
+		}
+		if err := l.validateRoute(route); err != nil {
+			return fmt.Errorf("route %s %s: %w", route.Method, route.Path, err)
+		}
+	}
+
+	if l.schema.APIVersions != nil {
+		if err := validateAPIVersions(l.schema.APIVersions); err != nil {
+			return fmt.Errorf("apiVersions: %w", err)
+		}
+	}
+
+	if l.schema.Consistency != nil {
+		if _, err := parseLag(l.schema.Consistency.Lag); err != nil {
+			return fmt.Errorf("consistency: %w", err)
+		}
+	}
+
+	if l.schema.AsyncComplete != nil {
+		if err := l.validateAsyncComplete(l.schema.AsyncComplete); err != nil {
+			return fmt.Errorf("asyncComplete: %w", err)
+		}
+	}
+
+	if l.schema.Warmup != nil {
+		if _, _, err := parseWarmup(l.schema.Warmup); err != nil {
+			return fmt.Errorf("warmup: %w", err)
+		}
+	}
+
+	if l.schema.LazyInit != nil {
+		if delay, err := time.ParseDuration(l.schema.LazyInit.Delay); err != nil || delay <= 0 {
+			return fmt.Errorf("lazyInit: invalid delay %q: must be a positive duration like 1s", l.schema.LazyInit.Delay)
+		}
+	}
+
+	for entityName, minimum := range l.schema.RequireSeed {
+		if _, exists := l.schema.Entities[entityName]; !exists {
+			return fmt.Errorf("requireSeed: unknown entity %q", entityName)
+		}
+		if minimum < 1 {
+			return fmt.Errorf("requireSeed: %s must require at least 1 record, got %d", entityName, minimum)
+		}
+	}
+
+	return nil
+}
+
+// validateRoute checks that a custom route has either a fixed response or a
+// declared entity, that each path parameter names one of the entity's fields
+// directly or through the route's filters, and its deprecation settings
+func (l *Loader) validateRoute(route *types.CustomRoute) error {
+	if route.Response != nil {
+		if route.Entity != "" {
+			return errors.New("set either entity or response, not both")
+		}
+		if err := validateStatus(route.Status); err != nil {
+			return err
+		}
+		for i, candidate := range route.Responses {
+			if len(candidate.Match) == 0 {
+				return fmt.Errorf("responses[%d]: match must list at least one field", i)
+			}
+			if candidate.Response == nil {
+				return fmt.Errorf("responses[%d]: response is required", i)
+			}
+			if err := validateStatus(candidate.Status); err != nil {
+				return fmt.Errorf("responses[%d]: %w", i, err)
+			}
+		}
+		return validateSunset(route.Deprecated, route.Sunset)
+	}
+	if route.Entity == "" {
+		return errors.New("set either entity or response")
+	}
+	if route.Status != 0 {
+		return errors.New("status only applies to routes with a response")
+	}
+	if len(route.Responses) > 0 {
+		return errors.New("responses need a default response")
+	}
+
+	entity, exists := l.schema.Entities[route.Entity]
+	if !exists || entity == nil {
+		return fmt.Errorf("unknown entity %q", route.Entity)
+	}
+
+	seen := make(map[string]bool)
+	for _, param := range PathParams(route.Path) {
+		if seen[param] {
+			return fmt.Errorf("path parameter %q appears more than once", param)
+		}
+		seen[param] = true
+
+		field := param
+		if mapped, ok := route.Filters[param]; ok {
+			field = mapped
+		}
+		if _, exists := entity.Fields[field]; !exists {
+			return fmt.Errorf("path parameter %q: entity %q has no field %q", param, route.Entity, field)
+		}
+	}
+	return validateSunset(route.Deprecated, route.Sunset)
+}
+
+// validateStatus checks an optional status code (0 means the default)
+func validateStatus(status int) error {
+	if status != 0 && (status < 100 || status > 599) {
+		return fmt.Errorf("invalid status %d", status)
+	}
+	return nil
+}
+
+// validateMaxFields checks that maxFields is not negative and leaves room for
+// every required field without a default, so creates can still succeed
+func validateMaxFields(entity *types.Entity) error {
+	if entity.MaxFields < 0 {
+		return fmt.Errorf("maxFields must not be negative, got %d", entity.MaxFields)
+	}
+	if entity.MaxFields == 0 {
+		return nil
+	}
+	required := 0
+	for fieldName, field := range entity.Fields {
+		if fieldName != "id" && field.Required && field.Default == nil {
+			required++
+		}
+	}
+	if required > entity.MaxFields {
+		return fmt.Errorf("maxFields %d is less than the %d required fields", entity.MaxFields, required)
+	}
+	return nil
+}
+
+// validateAsyncComplete checks that an asyncComplete block names a declared
+// entity and field, a positive delay, and a value the field accepts
+func (l *Loader) validateAsyncComplete(config *types.AsyncCompleteConfig) error {
+	entity, exists := l.schema.Entities[config.Entity]
+	if !exists {
+		return fmt.Errorf("unknown entity %q", config.Entity)
+	}
+	if entity.ReadOnly {
+		return fmt.Errorf("entity %q is read-only", config.Entity)
+	}
+	field, exists := entity.Fields[config.Field]
+	if !exists {
+		return fmt.Errorf("entity %q has no field %q", config.Entity, config.Field)
+	}
+	if config.Field == "id" {
+		return fmt.Errorf("the id field cannot be updated")
+	}
+	after, err := time.ParseDuration(config.After)
+	if err != nil || after <= 0 {
+		return fmt.Errorf("invalid after %q: must be a positive duration like 3s", config.After)
+	}
+	if err := validateFieldValue(field.Type, config.Value); err != nil {
+		return fmt.Errorf("value: %w", err)
+	}
+	return CheckFieldConstraints(field, config.Value)
+}
+
+// AsyncComplete returns the asyncComplete settings and their parsed delay, or
+// nil when not configured
+func (l *Loader) AsyncComplete() (*types.AsyncCompleteConfig, time.Duration) {
+	if l.schema == nil || l.schema.AsyncComplete == nil {
+		return nil, 0
+	}
+	after, _ := time.ParseDuration(l.schema.AsyncComplete.After)
+	return l.schema.AsyncComplete, after
+}
+
+// Warmup returns the warmup's initial latency and window, or zeros when not
+// configured
+func (l *Loader) Warmup() (initialLatency, window time.Duration) {
+	if l.schema == nil || l.schema.Warmup == nil {
+		return 0, 0
+	}
+	initialLatency, window, _ = parseWarmup(l.schema.Warmup)
+	return initialLatency, window
+}
+
+// LazyInitDelay returns the delay for each entity's first request (zero if unset)
+func (l *Loader) LazyInitDelay() time.Duration {
+	if l.schema == nil || l.schema.LazyInit == nil {
+		return 0
+	}
+	delay, _ := time.ParseDuration(l.schema.LazyInit.Delay)
+	return delay
+}
+
+// parseWarmup parses a warmup block's two positive durations
+func parseWarmup(config *types.WarmupConfig) (time.Duration, time.Duration, error) {
+	initialLatency, err := time.ParseDuration(config.InitialLatency)
+	if err != nil || initialLatency <= 0 {
+		return 0, 0, fmt.Errorf("invalid initialLatency %q: must be a positive duration like 2s", config.InitialLatency)
+	}
+	window, err := time.ParseDuration(config.Duration)
+	if err != nil || window <= 0 {
+		return 0, 0, fmt.Errorf("invalid duration %q: must be a positive duration like 30s", config.Duration)
+	}
+	return initialLatency, window, nil
+}
+
+// validateLimits enforces the configured entity and field counts
+func (l *Loader) validateLimits() error {
+	if limit := l.limits.MaxEntities; limit > 0 && len(l.schema.Entities) > limit {
+		return fmt.Errorf("schema has %d entities, exceeding the limit of %d", len(l.schema.Entities), limit)
+	}
+	if limit := l.limits.MaxFields; limit > 0 {
+		names := l.GetEntityNames()
+		sort.Strings(names)
+		for _, entityName := range names {
+			entity := l.schema.Entities[entityName]
+			if entity != nil && len(entity.Fields) > limit {
+				return fmt.Errorf("entity %q has %d fields, exceeding the limit of %d", entityName, len(entity.Fields), limit)
+			}
+		}
+	}
+	return nil
+}
+
+// unsafeNames returns the quoted entity and field names (as entity.field)
+// that contain characters outside the URL-safe unreserved set, sorted
+func unsafeNames(entities map[string]*types.Entity) []string {
+	var invalid []string
+	for entityName, entity := range entities {
+		if !isSafeName(entityName) {
+			invalid = append(invalid, strconv.Quote(entityName))
+		}
+		if entity == nil {
+			continue
+		}
+		for fieldName := range entity.Fields {
+			if !isSafeName(fieldName) {
+				invalid = append(invalid, strconv.Quote(entityName+"."+fieldName))
+			}
+		}
+	}
+	sort.Strings(invalid)
+	return invalid
+}
+
+// isSafeName reports whether name is non-empty and only uses URL unreserved
+// characters, so it can be used as-is in a path or query string
+func isSafeName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, ch := range name {
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+		case ch == '-', ch == '_', ch == '.', ch == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// validateBasePath checks that the base path is a fixed prefix: route
+// parameter markers would never match, and other characters need escaping
+func validateBasePath(basePath string) error {
+	normalized := NormalizeBasePath(basePath)
+	if normalized == "" {
+		return nil
+	}
+	if strings.ContainsAny(normalized, ":{}") {
+		return fmt.Errorf("invalid basePath %q: it must not contain route parameters", basePath)
+	}
+	for _, segment := range strings.Split(normalized[1:], "/") {
+		if !isSafeName(segment) || segment == "." || segment == ".." {
+			return fmt.Errorf("invalid basePath %q: segments may only contain letters, digits, '-', '_', '.' and '~'", basePath)
+		}
+	}
+	return nil
+}
+
+// validateListFormat checks a listFormat value (empty means the default)
+func validateListFormat(format string) error {
+	switch format {
+	case "", types.ListFormatArray, types.ListFormatMap:
+		return nil
+	}
+	return fmt.Errorf("invalid listFormat %q: must be %q or %q", format, types.ListFormatArray, types.ListFormatMap)
+}
+
+// validateFormat checks the body format, which replaces the responseWrapper
+// and apiVersions envelopes rather than combining with them
+func validateFormat(schema *types.Schema) error {
+	switch schema.Format {
+	case "":
+		return nil
+	case types.FormatJSONAPI:
+		if schema.ResponseWrapper != nil || schema.APIVersions != nil {
+			return fmt.Errorf("format %q cannot be combined with responseWrapper or apiVersions", schema.Format)
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid format %q: must be %q", schema.Format, types.FormatJSONAPI)
+}
+
+// validateMiddleware checks that a middleware list names known steps at most once
+func validateMiddleware(names []string) error {
+	known := make(map[string]bool, len(types.DefaultMiddleware))
+	for _, name := range types.DefaultMiddleware {
+		known[name] = true
+	}
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if !known[name] {
+			return fmt.Errorf("unknown middleware %q (must be one of: %s)", name, strings.Join(types.DefaultMiddleware, ", "))
+		}
+		if seen[name] {
+			return fmt.Errorf("middleware %q is listed more than once", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// checkFieldCaseCollisions rejects field names that differ only by case,
+// such as "Email" and "email", which case-insensitive lookups can't tell apart
+func checkFieldCaseCollisions(fields map[string]*types.Field) error {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	seen := make(map[string]string, len(names))
+	for _, name := range names {
+		folded := strings.ToLower(name)
+		if other, exists := seen[folded]; exists {
+			return fmt.Errorf("fields %q and %q differ only by case", other, name)
+		}
+		seen[folded] = name
+	}
+	return nil
+}
+
+// sunsetLayouts are the accepted formats for a deprecation sunset date
+var sunsetLayouts = []string{"2006-01-02", time.RFC3339, "Mon, 02 Jan 2006 15:04:05 GMT"}
+
+// ParseSunset parses a sunset date in any of sunsetLayouts
+func ParseSunset(value string) (time.Time, error) {
+	for _, layout := range sunsetLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid sunset %q: must be a date like 2025-12-31, RFC 3339, or an HTTP date", value)
+}
+
+// validateSunset checks that a sunset date parses and is only set on
+// something deprecated
+func validateSunset(deprecated bool, sunset string) error {
+	if sunset == "" {
+		return nil
+	}
+	if !deprecated {
+		return errors.New("sunset requires deprecated to be true")
+	}
+	_, err := ParseSunset(sunset)
+	return err
+}
+
+// validateAPIVersions checks that apiVersions defines at least one named variant
+func validateAPIVersions(config *types.APIVersionsConfig) error {
+	if len(config.Variants) == 0 {
+		return fmt.Errorf("variants must define at least one version")
+	}
+	for version, variant := range config.Variants {
+		if version == "" {
+			return fmt.Errorf("version names must not be empty")
+		}
+		if variant == nil {
+			return fmt.Errorf("version %q has no definition", version)
+		}
+	}
+	return nil
+}
+
+// ConsistencyLag returns the configured read-after-write lag (zero if unset)
+func (l *Loader) ConsistencyLag() time.Duration {
+	if l.schema == nil || l.schema.Consistency == nil {
+		return 0
+	}
+	lag, _ := parseLag(l.schema.Consistency.Lag)
+	return lag
+}
+
+// parseLag parses a non-negative duration such as "500ms"
+func parseLag(value string) (time.Duration, error) {
+	lag, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid lag %q: must be a duration like 500ms", value)
+	}
+	if lag < 0 {
+		return 0, fmt.Errorf("lag must not be negative, got %s", value)
+	}
+	return lag, nil
+}
+
+// validatePaginationKeys checks that overridden envelope keys don't collide
+func validatePaginationKeys(keys *types.PaginationKeys) error {
+	if keys == nil {
+		return nil
+	}
+
+	withDefault := func(key, fallback string) string {
+		if key == "" {
+			return fallback
+		}
+		return key
+	}
+
+	dataKey := withDefault(keys.Data, "data")
+	metaKey := withDefault(keys.Meta, "meta")
+	metaKeys := []string{
+		withDefault(keys.ResultCount, "result_count"),
+		withDefault(keys.NextToken, "next_token"),
+		keys.TotalCount,
+		keys.HasMore,
+	}
+
+	seen := map[string]bool{}
+	if metaKey == "-" {
+		// Meta keys share the top level with the data key
+		seen[dataKey] = true
+	} else if metaKey == dataKey {
+		return fmt.Errorf("keys.data and keys.meta must differ, both are %q", dataKey)
+	}
+	for _, key := range metaKeys {
+		if key == "" {
+			continue
+		}
+		if seen[key] {
+			return fmt.Errorf("duplicate pagination key %q", key)
+		}
+		seen[key] = true
+	}
+
 	return nil
 }
 
@@ -100,11 +624,75 @@ func (l *Loader) validateEntity(name string, entity *types.Entity) error {
 		return fmt.Errorf("id field must be of type 'string', got '%s'", idField.Type)
 	}
 
+	if err := checkFieldCaseCollisions(entity.Fields); err != nil {
+		return err
+	}
+
 	// Validate each field
 	for fieldName, field := range entity.Fields {
 		if err := l.validateField(fieldName, field); err != nil {
 			return fmt.Errorf("field %q: %w", fieldName, err)
 		}
+		if field.SlugFrom != "" {
+			if err := validateSlugFrom(fieldName, field, entity.Fields); err != nil {
+				return fmt.Errorf("field %q: %w", fieldName, err)
+			}
+		}
+	}
+
+	if err := validateListFormat(entity.ListFormat); err != nil {
+		return err
+	}
+
+	if _, exists := entity.Fields[HistoryPath]; exists && entity.TrackHistory {
+		return fmt.Errorf("field %q would be hidden by the trackHistory route", HistoryPath)
+	}
+
+	if err := validateMiddleware(entity.Middleware); err != nil {
+		return err
+	}
+
+	seenIndexes := make(map[string]bool, len(entity.Indexes))
+	for _, field := range entity.Indexes {
+		if _, exists := entity.Fields[field]; !exists {
+			return fmt.Errorf("indexes: unknown field %q", field)
+		}
+		if seenIndexes[field] {
+			return fmt.Errorf("indexes: field %q is listed more than once", field)
+		}
+		seenIndexes[field] = true
+	}
+
+	if err := validateSunset(entity.Deprecated, entity.Sunset); err != nil {
+		return err
+	}
+
+	if entity.StartID < 0 {
+		return fmt.Errorf("startId must not be negative, got %d", entity.StartID)
+	}
+
+	if err := validateMaxFields(entity); err != nil {
+		return err
+	}
+
+	if field, exists := entity.Fields["version"]; exists && entity.Versioning && field.Type != types.FieldTypeInteger {
+		return fmt.Errorf("versioning requires the version field to be an integer, got %q", field.Type)
+	}
+
+	switch entity.Links.(type) {
+	case nil, bool, map[string]interface{}:
+	default:
+		return fmt.Errorf("links must be true or a template object, got %T", entity.Links)
+	}
+	if _, clash := entity.Fields["_links"]; clash && (l.schema.AutoLinks || entity.Links != nil && entity.Links != false) {
+		return errors.New("links cannot be used with a field named _links")
+	}
+
+	// Example payloads must look like real entities
+	for i, example := range entity.ExampleWhenEmpty {
+		if err := l.validateEntityData(name, entity, example); err != nil {
+			return fmt.Errorf("exampleWhenEmpty[%d]: %w", i, err)
+		}
 	}
 
 	return nil
@@ -118,15 +706,76 @@ func (l *Loader) validateField(name string, field *types.Field) error {
 
 	// Validate field type
 	validTypes := map[string]bool{
-		types.FieldTypeString:  true,
-		types.FieldTypeNumber:  true,
-		types.FieldTypeBoolean: true,
-		types.FieldTypeObject:  true,
-		types.FieldTypeArray:   true,
+		types.FieldTypeString:   true,
+		types.FieldTypeNumber:   true,
+		types.FieldTypeInteger:  true,
+		types.FieldTypeBoolean:  true,
+		types.FieldTypeObject:   true,
+		types.FieldTypeArray:    true,
+		types.FieldTypeBinary:   true,
+		types.FieldTypeDatetime: true,
 	}
 
 	if !validTypes[field.Type] {
-		return fmt.Errorf("%w: %s (must be one of: string, number, boolean, object, array)", ErrInvalidFieldType, field.Type)
+		return fmt.Errorf("%w: %s (must be one of: string, number, integer, boolean, object, array, binary, datetime)", ErrInvalidFieldType, field.Type)
+	}
+
+	// Validate datetime layout
+	if field.Layout != "" && field.Type != types.FieldTypeDatetime {
+		return fmt.Errorf("layout is only supported on datetime fields")
+	}
+
+	// Validate numeric bounds
+	if field.Min != nil || field.Max != nil {
+		if field.Type != types.FieldTypeNumber && field.Type != types.FieldTypeInteger {
+			return fmt.Errorf("min/max are only supported on number and integer fields")
+		}
+		if field.Min != nil && field.Max != nil && *field.Min > *field.Max {
+			return fmt.Errorf("min (%v) must not be greater than max (%v)", *field.Min, *field.Max)
+		}
+	}
+
+	// Validate output transformations
+	if field.Mask != "" {
+		switch field.Mask {
+		case types.MaskEmail, types.MaskLast4, types.MaskAll:
+		default:
+			return fmt.Errorf("invalid mask %q (must be one of: email, last4, all)", field.Mask)
+		}
+		if field.Type != types.FieldTypeString {
+			return fmt.Errorf("mask is only supported on string fields")
+		}
+		if field.Redact {
+			return fmt.Errorf("mask and redact cannot be used together")
+		}
+	}
+	if name == "id" && (field.Mask != "" || field.Redact) {
+		return fmt.Errorf("the id field cannot be masked or redacted")
+	}
+
+	// Validate default value
+	if field.Default != nil {
+		if name == "id" {
+			return fmt.Errorf("the id field cannot have a default")
+		}
+		if err := validateDefault(field); err != nil {
+			return err
+		}
+	}
+
+	// Validate generated values
+	if field.Random {
+		if err := validateRandom(name, field); err != nil {
+			return err
+		}
+	}
+
+	// Validate size limit
+	if field.MaxSize < 0 {
+		return fmt.Errorf("maxSize must not be negative, got %d", field.MaxSize)
+	}
+	if field.MaxSize > 0 && field.Type != types.FieldTypeBinary {
+		return fmt.Errorf("maxSize is only supported on binary fields")
 	}
 
 	return nil
@@ -169,37 +818,216 @@ func LoadSeedData(filepath string) (map[string][]map[string]interface{}, error)
 		return nil, fmt.Errorf("failed to read seed file: %w", err)
 	}
 
-	// Parse JSON
+	// Parse JSON, keeping numbers as json.Number so large integers round-trip exactly
 	var seedData map[string][]map[string]interface{}
-	if err := json.Unmarshal(data, &seedData); err != nil {
+	if err := DecodeJSON(data, &seedData); err != nil {
 		return nil, fmt.Errorf("failed to parse seed JSON: %w", err)
 	}
 
 	return seedData, nil
 }
 
-// ValidateSeedData validates that seed data matches the schema
+// LoadSeedDir loads seed data from a directory where each *.json file holds
+// the seed array for the entity named by the file (e.g. users.json)
+func LoadSeedDir(dir string) (map[string][]map[string]interface{}, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list seed directory: %w", err)
+	}
+	sort.Strings(paths)
+
+	seedData := make(map[string][]map[string]interface{}, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read seed file: %w", err)
+		}
+
+		var entities []map[string]interface{}
+		if err := DecodeJSON(data, &entities); err != nil {
+			return nil, fmt.Errorf("failed to parse seed file %s: %w", filepath.Base(path), err)
+		}
+
+		entityName := strings.TrimSuffix(filepath.Base(path), ".json")
+		seedData[entityName] = entities
+	}
+
+	return seedData, nil
+}
+
+// MergeSeedData appends the entities in src to dst, entity by entity
+func MergeSeedData(dst, src map[string][]map[string]interface{}) {
+	for entityName, entities := range src {
+		dst[entityName] = append(dst[entityName], entities...)
+	}
+}
+
+// DecodeJSON decodes a single JSON document using json.Number for numbers,
+// so values such as 9007199254740993 are not rounded through float64
+func DecodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("unexpected data after top-level JSON value")
+	}
+	return nil
+}
+
+// NumberValue returns the float64 value of a decoded JSON number, which may be
+// a float64 or a json.Number
+func NumberValue(value interface{}) (float64, bool) {
+	switch num := value.(type) {
+	case float64:
+		return num, true
+	case json.Number:
+		f, err := num.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// IsWholeNumber reports whether a decoded JSON number has no fractional part.
+// json.Number values written without a fraction or exponent are whole even
+// when they exceed float64 precision.
+func IsWholeNumber(value interface{}) bool {
+	if num, ok := value.(json.Number); ok && !strings.ContainsAny(string(num), ".eE") {
+		return true
+	}
+	f, ok := NumberValue(value)
+	return ok && f == math.Trunc(f)
+}
+
+// ValidateSeedData validates that seed data matches the schema, returning
+// the first problem found
 func (l *Loader) ValidateSeedData(seedData map[string][]map[string]interface{}) error {
 	if l.schema == nil {
 		return errors.New("no schema loaded")
 	}
+	if errs := l.SeedDataErrors(seedData); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
 
-	// Check each entity in seed data
-	for entityName, entities := range seedData {
-		// Check if entity exists in schema
+// SeedRecordError is a seed record (or, with Index -1, a whole entity) that
+// doesn't match the schema
+type SeedRecordError struct {
+	Entity string
+	Index  int
+	Err    error
+}
+
+func (e *SeedRecordError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("seed data contains unknown entity: %s", e.Entity)
+	}
+	return fmt.Sprintf("seed data for %s[%d]: %v", e.Entity, e.Index, e.Err)
+}
+
+// Unwrap returns the validation error for the record
+func (e *SeedRecordError) Unwrap() error {
+	return e.Err
+}
+
+// SeedDataErrors validates every seed record and returns all the problems,
+// ordered by entity name and then record index
+func (l *Loader) SeedDataErrors(seedData map[string][]map[string]interface{}) []*SeedRecordError {
+	if l.schema == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(seedData))
+	for entityName := range seedData {
+		names = append(names, entityName)
+	}
+	sort.Strings(names)
+
+	var errs []*SeedRecordError
+	for _, entityName := range names {
 		entity, exists := l.schema.Entities[entityName]
 		if !exists {
-			return fmt.Errorf("seed data contains unknown entity: %s", entityName)
+			errs = append(errs, &SeedRecordError{Entity: entityName, Index: -1})
+			continue
+		}
+		for i, entityData := range seedData[entityName] {
+			err := validateSeedID(entityData)
+			if err == nil {
+				err = l.validateEntityData(entityName, entity, entityData)
+			}
+			if err != nil {
+				errs = append(errs, &SeedRecordError{Entity: entityName, Index: i, Err: err})
+			}
+		}
+	}
+	return errs
+}
+
+// validateSeedID requires a string id on every seed record, whether or not the
+// schema declares one, since the store cannot seed a record without it
+func validateSeedID(entityData map[string]interface{}) error {
+	id, exists := entityData["id"]
+	if !exists || id == nil {
+		return fmt.Errorf("required field %q is missing", "id")
+	}
+	if _, ok := id.(string); !ok {
+		return fmt.Errorf("field %q must be a string", "id")
+	}
+	return nil
+}
+
+// WithoutInvalidSeed returns a copy of seedData without the records (or
+// unknown entities) named in errs
+func WithoutInvalidSeed(seedData map[string][]map[string]interface{}, errs []*SeedRecordError) map[string][]map[string]interface{} {
+	skipped := make(map[string]map[int]bool)
+	for _, err := range errs {
+		if skipped[err.Entity] == nil {
+			skipped[err.Entity] = make(map[int]bool)
 		}
+		skipped[err.Entity][err.Index] = true
+	}
 
-		// Validate each entity instance
-		for i, entityData := range entities {
-			if err := l.validateEntityData(entityName, entity, entityData); err != nil {
-				return fmt.Errorf("seed data for %s[%d]: %w", entityName, i, err)
+	valid := make(map[string][]map[string]interface{}, len(seedData))
+	for entityName, entities := range seedData {
+		if skipped[entityName][-1] {
+			continue
+		}
+		kept := make([]map[string]interface{}, 0, len(entities))
+		for i, entity := range entities {
+			if !skipped[entityName][i] {
+				kept = append(kept, entity)
 			}
 		}
+		valid[entityName] = kept
 	}
+	return valid
+}
 
+// CheckRequiredSeed reports every entity in requireSeed that the seed data
+// gives fewer records than required
+func (l *Loader) CheckRequiredSeed(seedData map[string][]map[string]interface{}) error {
+	if l.schema == nil || len(l.schema.RequireSeed) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(l.schema.RequireSeed))
+	for entityName := range l.schema.RequireSeed {
+		names = append(names, entityName)
+	}
+	sort.Strings(names)
+
+	var missing []string
+	for _, entityName := range names {
+		minimum := l.schema.RequireSeed[entityName]
+		if got := len(seedData[entityName]); got < minimum {
+			missing = append(missing, fmt.Sprintf("%s has %d, needs at least %d", entityName, got, minimum))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("required seed data missing: %s", strings.Join(missing, "; "))
+	}
 	return nil
 }
 
@@ -227,6 +1055,10 @@ func (l *Loader) validateEntityData(entityName string, entity *types.Entity, dat
 		if err := validateFieldValue(field.Type, value); err != nil {
 			return fmt.Errorf("field %q: %w", fieldName, err)
 		}
+
+		if err := CheckFieldConstraints(field, value); err != nil {
+			return fmt.Errorf("field %q: %w", fieldName, err)
+		}
 	}
 
 	return nil
@@ -244,10 +1076,17 @@ func validateFieldValue(fieldType string, value interface{}) error {
 			return fmt.Errorf("expected string, got %T", value)
 		}
 	case types.FieldTypeNumber:
-		// JSON numbers can be float64
-		if _, ok := value.(float64); !ok {
+		// JSON numbers are float64 or json.Number
+		if _, ok := NumberValue(value); !ok {
 			return fmt.Errorf("expected number, got %T", value)
 		}
+	case types.FieldTypeInteger:
+		if _, ok := NumberValue(value); !ok {
+			return fmt.Errorf("expected integer, got %T", value)
+		}
+		if !IsWholeNumber(value) {
+			return fmt.Errorf("expected integer, got %v", value)
+		}
 	case types.FieldTypeBoolean:
 		if _, ok := value.(bool); !ok {
 			return fmt.Errorf("expected boolean, got %T", value)
@@ -260,6 +1099,64 @@ func validateFieldValue(fieldType string, value interface{}) error {
 		if _, ok := value.([]interface{}); !ok {
 			return fmt.Errorf("expected array, got %T", value)
 		}
+	case types.FieldTypeBinary:
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("expected base64 string, got %T", value)
+		}
+		if _, err := base64.StdEncoding.DecodeString(str); err != nil {
+			return fmt.Errorf("expected valid base64: %w", err)
+		}
+	case types.FieldTypeDatetime:
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("expected datetime string, got %T", value)
+		}
+	}
+
+	return nil
+}
+
+// DatetimeLayout returns the layout used to parse a datetime field
+func DatetimeLayout(field *types.Field) string {
+	if field.Layout != "" {
+		return field.Layout
+	}
+	return time.RFC3339
+}
+
+// CheckFieldConstraints validates a value against the field's constraints
+// beyond its basic type (e.g. binary size limits). It assumes the type has
+// already been checked.
+func CheckFieldConstraints(field *types.Field, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	if num, ok := NumberValue(value); ok {
+		if field.Min != nil && num < *field.Min {
+			return fmt.Errorf("value %v is less than min %v", num, *field.Min)
+		}
+		if field.Max != nil && num > *field.Max {
+			return fmt.Errorf("value %v is greater than max %v", num, *field.Max)
+		}
+	}
+
+	if field.Type == types.FieldTypeDatetime {
+		if str, ok := value.(string); ok {
+			layout := DatetimeLayout(field)
+			if _, err := time.Parse(layout, str); err != nil {
+				return fmt.Errorf("expected datetime in format %q, got %q", layout, str)
+			}
+		}
+	}
+
+	if field.Type == types.FieldTypeBinary && field.MaxSize > 0 {
+		if str, ok := value.(string); ok {
+			decoded, err := base64.StdEncoding.DecodeString(str)
+			if err == nil && len(decoded) > field.MaxSize {
+				return fmt.Errorf("binary value is %d bytes, exceeds maxSize of %d", len(decoded), field.MaxSize)
+			}
+		}
 	}
 
 	return nil
@@ -1,6 +1,8 @@
 package schema
 
 import (
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -48,17 +50,471 @@ func TestLoadFromFile(t *testing.T) {
 		}
 	}`
 
+	maxSizeOnStringSchema := `{
+		"entities": {
+			"users": {
+				"fields": {
+					"id": {"type": "string", "required": true},
+					"name": {"type": "string", "maxSize": 10}
+				}
+			}
+		}
+	}`
+
+	minOnStringSchema := `{
+		"entities": {
+			"users": {
+				"fields": {
+					"id": {"type": "string", "required": true},
+					"name": {"type": "string", "min": 1}
+				}
+			}
+		}
+	}`
+
 	tests := []struct {
 		name        string
 		schemaJSON  string
 		wantErr     bool
 		errContains string
 	}{
+		{
+			name:        "min on string field",
+			schemaJSON:  minOnStringSchema,
+			wantErr:     true,
+			errContains: "only supported on number and integer fields",
+		},
 		{
 			name:       "valid schema",
 			schemaJSON: validSchema,
 			wantErr:    false,
 		},
+		{
+			name:       "mask and redact",
+			schemaJSON: fieldSchema(`"email": {"type": "string", "mask": "email"}, "ssn": {"type": "string", "redact": true}`),
+			wantErr:    false,
+		},
+		{
+			name:       "static and dynamic defaults",
+			schemaJSON: fieldSchema(`"status": {"type": "string", "default": "new"}, "createdAt": {"type": "datetime", "default": "{{now}}"}, "seen": {"type": "integer", "default": "{{timestamp}}"}, "tags": {"type": "array", "default": []}, "prefs": {"type": "object", "default": {"theme": "dark"}}`),
+			wantErr:    false,
+		},
+		{
+			name:       "pagination key overrides",
+			schemaJSON: `{"pagination": {"style": "cursor", "keys": {"meta": "-", "data": "items", "nextToken": "nextPageToken", "hasMore": "hasMore"}}, "entities": {"users": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:    false,
+		},
+		{
+			name:        "pagination data and meta collide",
+			schemaJSON:  `{"pagination": {"style": "cursor", "keys": {"data": "results", "meta": "results"}}, "entities": {"users": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "must differ",
+		},
+		{
+			name:        "pagination flattened key collides with data",
+			schemaJSON:  `{"pagination": {"style": "cursor", "keys": {"meta": "-", "totalCount": "data"}}, "entities": {"users": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "duplicate pagination key",
+		},
+		{
+			name:        "invalid exampleWhenEmpty",
+			schemaJSON:  `{"entities": {"users": {"fields": {"id": {"type": "string"}, "age": {"type": "number"}}, "exampleWhenEmpty": [{"id": "1", "age": "old"}]}}}`,
+			wantErr:     true,
+			errContains: "exampleWhenEmpty[0]",
+		},
+		{
+			name:        "invalid consistency lag",
+			schemaJSON:  `{"consistency": {"lag": "soon"}, "entities": {"users": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "invalid lag",
+		},
+		{
+			name:        "unsafe entity and field names",
+			schemaJSON:  `{"entities": {"my users": {"fields": {"id": {"type": "string"}, "first name": {"type": "string"}}}, "a/b": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: `invalid entity or field names "a/b", "my users", "my users.first name"`,
+		},
+		{
+			name:        "links with string value",
+			schemaJSON:  `{"entities": {"users": {"links": "yes", "fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "links must be true or a template object",
+		},
+		{
+			name:        "versioning with string version field",
+			schemaJSON:  `{"entities": {"docs": {"versioning": true, "fields": {"id": {"type": "string"}, "version": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "version field to be an integer",
+		},
+		{
+			name:        "invalid listFormat",
+			schemaJSON:  `{"listFormat": "dict", "entities": {"users": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "invalid listFormat",
+		},
+		{
+			name:        "trackHistory with history field",
+			schemaJSON:  `{"entities": {"users": {"trackHistory": true, "fields": {"id": {"type": "string"}, "history": {"type": "array"}}}}}`,
+			wantErr:     true,
+			errContains: "field \"history\" would be hidden by the trackHistory route",
+		},
+		{
+			name:       "slugFrom",
+			schemaJSON: `{"entities": {"posts": {"fields": {"id": {"type": "string"}, "title": {"type": "string"}, "slug": {"type": "string", "slugFrom": "title"}}}}}`,
+			wantErr:    false,
+		},
+		{
+			name:        "slugFrom unknown field",
+			schemaJSON:  `{"entities": {"posts": {"fields": {"id": {"type": "string"}, "slug": {"type": "string", "slugFrom": "title"}}}}}`,
+			wantErr:     true,
+			errContains: "slugFrom: unknown field \"title\"",
+		},
+		{
+			name:        "slugFrom non-string source",
+			schemaJSON:  `{"entities": {"posts": {"fields": {"id": {"type": "string"}, "rank": {"type": "number"}, "slug": {"type": "string", "slugFrom": "rank"}}}}}`,
+			wantErr:     true,
+			errContains: "field \"rank\" must be a string",
+		},
+		{
+			name:        "slugFrom on non-string field",
+			schemaJSON:  `{"entities": {"posts": {"fields": {"id": {"type": "string"}, "title": {"type": "string"}, "slug": {"type": "number", "slugFrom": "title"}}}}}`,
+			wantErr:     true,
+			errContains: "slugFrom is only supported on string fields",
+		},
+		{
+			name:        "slugFrom with default",
+			schemaJSON:  `{"entities": {"posts": {"fields": {"id": {"type": "string"}, "title": {"type": "string"}, "slug": {"type": "string", "slugFrom": "title", "default": "x"}}}}}`,
+			wantErr:     true,
+			errContains: "slugFrom cannot be combined with default or random",
+		},
+		{
+			name:        "requireSeed unknown entity",
+			schemaJSON:  `{"requireSeed": {"posts": 1}, "entities": {"users": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "requireSeed: unknown entity \"posts\"",
+		},
+		{
+			name:        "requireSeed zero",
+			schemaJSON:  `{"requireSeed": {"users": 0}, "entities": {"users": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "must require at least 1 record",
+		},
+		{
+			name:        "negative maxReportedCount",
+			schemaJSON:  `{"pagination": {"style": "offset", "maxReportedCount": -1}, "entities": {"users": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "maxReportedCount must not be negative",
+		},
+		{
+			name:        "asyncComplete on read-only entity",
+			schemaJSON:  `{"asyncComplete": {"entity": "jobs", "after": "3s", "field": "status", "value": "done"}, "entities": {"jobs": {"readOnly": true, "fields": {"id": {"type": "string"}, "status": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "entity \"jobs\" is read-only",
+		},
+		{
+			name:        "asyncComplete unknown field",
+			schemaJSON:  `{"asyncComplete": {"entity": "jobs", "after": "3s", "field": "state", "value": "done"}, "entities": {"jobs": {"fields": {"id": {"type": "string"}, "status": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "has no field \"state\"",
+		},
+		{
+			name:        "asyncComplete invalid delay",
+			schemaJSON:  `{"asyncComplete": {"entity": "jobs", "after": "soon", "field": "status", "value": "done"}, "entities": {"jobs": {"fields": {"id": {"type": "string"}, "status": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "invalid after",
+		},
+		{
+			name:        "asyncComplete value of wrong type",
+			schemaJSON:  `{"asyncComplete": {"entity": "jobs", "after": "3s", "field": "status", "value": 1}, "entities": {"jobs": {"fields": {"id": {"type": "string"}, "status": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "asyncComplete: value",
+		},
+		{
+			name:        "random array field",
+			schemaJSON:  fieldSchema(`"tags": {"type": "array", "random": true}`),
+			wantErr:     true,
+			errContains: "random is not supported on array fields",
+		},
+		{
+			name:        "random with default",
+			schemaJSON:  fieldSchema(`"name": {"type": "string", "random": true, "default": "x"}`),
+			wantErr:     true,
+			errContains: "random and default cannot be used together",
+		},
+		{
+			name:        "random integer bounds too wide",
+			schemaJSON:  fieldSchema(`"score": {"type": "integer", "random": true, "min": -9e18, "max": 9e18}`),
+			wantErr:     true,
+			errContains: "random integer min and max must be within",
+		},
+		{
+			name:        "random number range too wide",
+			schemaJSON:  fieldSchema(`"score": {"type": "number", "random": true, "min": -1e308, "max": 1e308}`),
+			wantErr:     true,
+			errContains: "random number range from min to max is too wide",
+		},
+		{
+			name:        "negative maxFields",
+			schemaJSON:  `{"entities": {"users": {"maxFields": -1, "fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "maxFields must not be negative",
+		},
+		{
+			name:        "maxFields below required fields",
+			schemaJSON:  `{"entities": {"users": {"maxFields": 1, "fields": {"id": {"type": "string", "required": true}, "name": {"type": "string", "required": true}, "email": {"type": "string", "required": true}}}}}`,
+			wantErr:     true,
+			errContains: "maxFields 1 is less than the 2 required fields",
+		},
+		{
+			name:       "maxFields covering required fields",
+			schemaJSON: `{"entities": {"users": {"maxFields": 2, "fields": {"id": {"type": "string", "required": true}, "name": {"type": "string", "required": true}, "role": {"type": "string", "required": true, "default": "user"}}}}}`,
+			wantErr:    false,
+		},
+		{
+			name:        "negative startId",
+			schemaJSON:  `{"entities": {"users": {"startId": -1, "fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "startId must not be negative",
+		},
+		{
+			name:        "unknown middleware",
+			schemaJSON:  `{"middleware": ["auth", "ratelimit"], "entities": {"users": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "unknown middleware \"ratelimit\"",
+		},
+		{
+			name:        "duplicate entity middleware",
+			schemaJSON:  `{"entities": {"users": {"middleware": ["auth", "auth"], "fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "listed more than once",
+		},
+		{
+			name:        "fields differing only by case",
+			schemaJSON:  `{"entities": {"users": {"fields": {"id": {"type": "string"}, "email": {"type": "string"}, "Email": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: `fields "Email" and "email" differ only by case`,
+		},
+		{
+			name:        "index on unknown field",
+			schemaJSON:  `{"entities": {"users": {"indexes": ["status"], "fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: `indexes: unknown field "status"`,
+		},
+		{
+			name:       "nested basePath",
+			schemaJSON: `{"basePath": "/api/v1/", "entities": {"users": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:    false,
+		},
+		{
+			name:        "basePath with route parameter",
+			schemaJSON:  `{"basePath": "/api/:version", "entities": {"users": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "must not contain route parameters",
+		},
+		{
+			name:        "basePath with brace parameter",
+			schemaJSON:  `{"basePath": "/api/{version}", "entities": {"users": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "must not contain route parameters",
+		},
+		{
+			name:        "basePath with unsafe characters",
+			schemaJSON:  `{"basePath": "/my api//v1", "entities": {"users": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: `invalid basePath "/my api//v1"`,
+		},
+		{
+			name:       "schema port",
+			schemaJSON: `{"port": 3000, "entities": {"users": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:    false,
+		},
+		{
+			name:        "schema port out of range",
+			schemaJSON:  `{"port": 70000, "entities": {"users": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "port must be between 1 and 65535",
+		},
+		{
+			name:       "deprecated entity with sunset",
+			schemaJSON: `{"entities": {"users": {"deprecated": true, "sunset": "2025-12-31", "fields": {"id": {"type": "string"}}}}}`,
+			wantErr:    false,
+		},
+		{
+			name:        "sunset without deprecated",
+			schemaJSON:  `{"entities": {"users": {"sunset": "2025-12-31", "fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "sunset requires deprecated",
+		},
+		{
+			name:       "route with composite path parameters",
+			schemaJSON: `{"entities": {"users": {"fields": {"id": {"type": "string"}, "tenant_id": {"type": "string"}}}}, "routes": [{"method": "GET", "path": "/tenants/:tenant/users/:id", "entity": "users", "filters": {"tenant": "tenant_id"}, "single": true}]}`,
+			wantErr:    false,
+		},
+		{
+			name:        "route parameter naming no field",
+			schemaJSON:  `{"entities": {"users": {"fields": {"id": {"type": "string"}}}}, "routes": [{"method": "GET", "path": "/tenants/:tenant/users/:id", "entity": "users"}]}`,
+			wantErr:     true,
+			errContains: `path parameter "tenant": entity "users" has no field "tenant"`,
+		},
+		{
+			name:        "route parameter repeated",
+			schemaJSON:  `{"entities": {"users": {"fields": {"id": {"type": "string"}}}}, "routes": [{"method": "GET", "path": "/users/:id/copies/:id", "entity": "users"}]}`,
+			wantErr:     true,
+			errContains: `path parameter "id" appears more than once`,
+		},
+		{
+			name:       "route with fixed response",
+			schemaJSON: `{"entities": {"users": {"fields": {"id": {"type": "string"}}}}, "routes": [{"method": "GET", "path": "/status", "response": {"ok": true}, "status": 200}]}`,
+			wantErr:    false,
+		},
+		{
+			name:       "route with matched responses",
+			schemaJSON: `{"entities": {"users": {"fields": {"id": {"type": "string"}}}}, "routes": [{"method": "POST", "path": "/login", "responses": [{"match": {"user": "admin"}, "response": {"ok": true}}], "response": {"ok": false}, "status": 401}]}`,
+			wantErr:    false,
+		},
+		{
+			name:        "matched response without default",
+			schemaJSON:  `{"entities": {"users": {"fields": {"id": {"type": "string"}}}}, "routes": [{"method": "POST", "path": "/login", "responses": [{"match": {"user": "admin"}, "response": {"ok": true}}]}]}`,
+			wantErr:     true,
+			errContains: "set either entity or response",
+		},
+		{
+			name:        "matched response with empty match",
+			schemaJSON:  `{"entities": {"users": {"fields": {"id": {"type": "string"}}}}, "routes": [{"method": "POST", "path": "/login", "responses": [{"match": {}, "response": {"ok": true}}], "response": {"ok": false}}]}`,
+			wantErr:     true,
+			errContains: "responses[0]: match must list at least one field",
+		},
+		{
+			name:        "matched response without response",
+			schemaJSON:  `{"entities": {"users": {"fields": {"id": {"type": "string"}}}}, "routes": [{"method": "POST", "path": "/login", "responses": [{"match": {"user": "admin"}}], "response": {"ok": false}}]}`,
+			wantErr:     true,
+			errContains: "responses[0]: response is required",
+		},
+		{
+			name:        "matched response with invalid status",
+			schemaJSON:  `{"entities": {"users": {"fields": {"id": {"type": "string"}}}}, "routes": [{"method": "POST", "path": "/login", "responses": [{"match": {"user": "admin"}, "response": {}, "status": 1000}], "response": {"ok": false}}]}`,
+			wantErr:     true,
+			errContains: "responses[0]: invalid status 1000",
+		},
+		{
+			name:        "route with entity and response",
+			schemaJSON:  `{"entities": {"users": {"fields": {"id": {"type": "string"}}}}, "routes": [{"method": "GET", "path": "/status", "entity": "users", "response": {"ok": true}}]}`,
+			wantErr:     true,
+			errContains: "set either entity or response, not both",
+		},
+		{
+			name:        "route with neither entity nor response",
+			schemaJSON:  `{"entities": {"users": {"fields": {"id": {"type": "string"}}}}, "routes": [{"method": "GET", "path": "/status"}]}`,
+			wantErr:     true,
+			errContains: "set either entity or response",
+		},
+		{
+			name:        "route with invalid status",
+			schemaJSON:  `{"entities": {"users": {"fields": {"id": {"type": "string"}}}}, "routes": [{"method": "GET", "path": "/status", "response": {}, "status": 42}]}`,
+			wantErr:     true,
+			errContains: "invalid status 42",
+		},
+		{
+			name:        "route for unknown entity",
+			schemaJSON:  `{"entities": {"users": {"fields": {"id": {"type": "string"}}}}, "routes": [{"method": "GET", "path": "/things", "entity": "things"}]}`,
+			wantErr:     true,
+			errContains: `unknown entity "things"`,
+		},
+		{
+			name:        "invalid route sunset",
+			schemaJSON:  `{"entities": {"users": {"fields": {"id": {"type": "string"}}}}, "routes": [{"method": "GET", "path": "/old", "entity": "users", "deprecated": true, "sunset": "next year"}]}`,
+			wantErr:     true,
+			errContains: `invalid sunset "next year"`,
+		},
+		{
+			name:        "apiVersions without variants",
+			schemaJSON:  `{"apiVersions": {"default": "1", "variants": {}}, "entities": {"users": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "at least one version",
+		},
+		{
+			name:       "warmup",
+			schemaJSON: `{"warmup": {"initialLatency": "2s", "duration": "30s"}, "entities": {"users": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:    false,
+		},
+		{
+			name:        "warmup without duration",
+			schemaJSON:  `{"warmup": {"initialLatency": "2s"}, "entities": {"users": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "warmup: invalid duration",
+		},
+		{
+			name:        "lazyInit without delay",
+			schemaJSON:  `{"lazyInit": {}, "entities": {"users": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "lazyInit: invalid delay",
+		},
+		{
+			name:        "invalid format",
+			schemaJSON:  `{"format": "hal", "entities": {"users": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: `invalid format "hal"`,
+		},
+		{
+			name:        "jsonapi format with responseWrapper",
+			schemaJSON:  `{"format": "jsonapi", "responseWrapper": {"single": {"item": "$entity"}}, "entities": {"users": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "cannot be combined with responseWrapper",
+		},
+		{
+			name:        "invalid fieldCase",
+			schemaJSON:  `{"fieldCase": "kebab", "entities": {"users": {"fields": {"id": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "invalid fieldCase",
+		},
+		{
+			name:        "fieldCase collision",
+			schemaJSON:  `{"fieldCase": "camel", "entities": {"users": {"fields": {"id": {"type": "string"}, "first_name": {"type": "string"}, "firstName": {"type": "string"}}}}}`,
+			wantErr:     true,
+			errContains: "both map to \"firstName\"",
+		},
+		{
+			name:        "unknown default token",
+			schemaJSON:  fieldSchema(`"createdAt": {"type": "string", "default": "{{today}}"}`),
+			wantErr:     true,
+			errContains: "unknown token {{today}}",
+		},
+		{
+			name:        "default of wrong type",
+			schemaJSON:  fieldSchema(`"age": {"type": "number", "default": "{{now}}"}`),
+			wantErr:     true,
+			errContains: "default: expected number",
+		},
+		{
+			name:        "array default on object field",
+			schemaJSON:  fieldSchema(`"meta": {"type": "object", "default": []}`),
+			wantErr:     true,
+			errContains: "default: expected object",
+		},
+		{
+			name:        "unknown mask",
+			schemaJSON:  fieldSchema(`"email": {"type": "string", "mask": "stars"}`),
+			wantErr:     true,
+			errContains: "invalid mask",
+		},
+		{
+			name:        "mask on number field",
+			schemaJSON:  fieldSchema(`"age": {"type": "number", "mask": "all"}`),
+			wantErr:     true,
+			errContains: "mask is only supported on string fields",
+		},
+		{
+			name:        "mask with redact",
+			schemaJSON:  fieldSchema(`"ssn": {"type": "string", "mask": "last4", "redact": true}`),
+			wantErr:     true,
+			errContains: "cannot be used together",
+		},
+		{
+			name:        "maxSize on non-binary field",
+			schemaJSON:  maxSizeOnStringSchema,
+			wantErr:     true,
+			errContains: "only supported on binary fields",
+		},
 		{
 			name:        "invalid JSON",
 			schemaJSON:  invalidJSON,
@@ -110,6 +566,59 @@ func TestLoadFromFile(t *testing.T) {
 	}
 }
 
+// fieldSchema builds a single-entity schema with an id field plus the given field definitions
+func fieldSchema(fields string) string {
+	return `{
+		"entities": {
+			"users": {
+				"fields": {
+					"id": {"type": "string", "required": true},
+					` + fields + `
+				}
+			}
+		}
+	}`
+}
+
+func TestLimits(t *testing.T) {
+	schemaFile := filepath.Join(t.TempDir(), "schema.json")
+	schemaJSON := `{"entities": {
+		"users": {"fields": {"id": {"type": "string"}, "name": {"type": "string"}, "email": {"type": "string"}}},
+		"posts": {"fields": {"id": {"type": "string"}}}
+	}}`
+	if err := os.WriteFile(schemaFile, []byte(schemaJSON), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		limits      Limits
+		errContains string
+	}{
+		{name: "unlimited", limits: Limits{}},
+		{name: "within limits", limits: Limits{MaxEntities: 2, MaxFields: 3}},
+		{name: "too many entities", limits: Limits{MaxEntities: 1}, errContains: "schema has 2 entities, exceeding the limit of 1"},
+		{name: "too many fields", limits: Limits{MaxFields: 2}, errContains: `entity "users" has 3 fields, exceeding the limit of 2`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader := NewLoader()
+			loader.SetLimits(tt.limits)
+			err := loader.LoadFromFile(schemaFile)
+			if tt.errContains == "" {
+				if err != nil {
+					t.Errorf("LoadFromFile() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !contains(err.Error(), tt.errContains) {
+				t.Errorf("LoadFromFile() error = %v, want error containing %q", err, tt.errContains)
+			}
+		})
+	}
+}
+
 func TestGetEntityNames(t *testing.T) {
 	loader := NewLoader()
 	loader.schema = &types.Schema{
@@ -229,6 +738,98 @@ func TestLoadSeedData(t *testing.T) {
 	}
 }
 
+func TestLoadSeedDataPreservesLargeIntegers(t *testing.T) {
+	seedFile := filepath.Join(t.TempDir(), "seed.json")
+	seedJSON := `{"accounts": [{"id": "1", "balance": 9007199254740993}]}`
+	if err := os.WriteFile(seedFile, []byte(seedJSON), 0644); err != nil {
+		t.Fatalf("failed to create seed file: %v", err)
+	}
+
+	seedData, err := LoadSeedData(seedFile)
+	if err != nil {
+		t.Fatalf("LoadSeedData() error = %v", err)
+	}
+
+	balance, ok := seedData["accounts"][0]["balance"].(json.Number)
+	if !ok {
+		t.Fatalf("balance type = %T, want json.Number", seedData["accounts"][0]["balance"])
+	}
+	if balance.String() != "9007199254740993" {
+		t.Errorf("balance = %s, want 9007199254740993", balance)
+	}
+}
+
+func TestLoadSeedDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"users.json": `[{"id": "1", "name": "Alice"}, {"id": "2", "name": "Bob"}]`,
+		"posts.json": `[{"id": "1", "title": "Hello"}]`,
+		"notes.txt":  `ignored`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create seed file: %v", err)
+		}
+	}
+
+	seedData, err := LoadSeedDir(dir)
+	if err != nil {
+		t.Fatalf("LoadSeedDir() error = %v", err)
+	}
+	if len(seedData) != 2 {
+		t.Errorf("expected 2 entities, got %d", len(seedData))
+	}
+	if len(seedData["users"]) != 2 || len(seedData["posts"]) != 1 {
+		t.Errorf("unexpected seed data: %v", seedData)
+	}
+
+	// A file that isn't a seed array names the file in the error
+	if err := os.WriteFile(filepath.Join(dir, "bad.json"), []byte(`{"id": "1"}`), 0644); err != nil {
+		t.Fatalf("failed to create seed file: %v", err)
+	}
+	_, err = LoadSeedDir(dir)
+	if err == nil || !contains(err.Error(), "bad.json") {
+		t.Errorf("LoadSeedDir() error = %v, want error naming bad.json", err)
+	}
+}
+
+func TestMergeSeedData(t *testing.T) {
+	dst := map[string][]map[string]interface{}{
+		"users": {{"id": "1"}},
+	}
+	MergeSeedData(dst, map[string][]map[string]interface{}{
+		"users": {{"id": "2"}},
+		"posts": {{"id": "1"}},
+	})
+
+	if len(dst["users"]) != 2 || len(dst["posts"]) != 1 {
+		t.Errorf("unexpected merge result: %v", dst)
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"object", `{"a": 1}`, false},
+		{"trailing whitespace", "{\"a\": 1}\n", false},
+		{"trailing data", `{"a": 1} {"b": 2}`, true},
+		{"invalid", `{a: 1}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v map[string]interface{}
+			err := DecodeJSON([]byte(tt.input), &v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DecodeJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidateSeedData(t *testing.T) {
 	loader := NewLoader()
 	loader.schema = &types.Schema{
@@ -318,6 +919,139 @@ func TestValidateSeedData(t *testing.T) {
 	}
 }
 
+func TestValidateSeedDataRequiresID(t *testing.T) {
+	// id is not declared, but Seed would silently drop a record without one
+	loader := NewLoader()
+	loader.schema = &types.Schema{
+		Entities: map[string]*types.Entity{
+			"tags": {Fields: map[string]*types.Field{"label": {Type: types.FieldTypeString}}},
+		},
+	}
+
+	err := loader.ValidateSeedData(map[string][]map[string]interface{}{
+		"tags": {{"id": "1", "label": "go"}, {"label": "orphan"}},
+	})
+	var recordErr *SeedRecordError
+	if !errors.As(err, &recordErr) {
+		t.Fatalf("ValidateSeedData() = %v, want a *SeedRecordError", err)
+	}
+	if recordErr.Entity != "tags" || recordErr.Index != 1 {
+		t.Errorf("error names %s[%d], want tags[1]", recordErr.Entity, recordErr.Index)
+	}
+	if want := "seed data for tags[1]: required field \"id\" is missing"; err.Error() != want {
+		t.Errorf("ValidateSeedData() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestSeedDataErrors(t *testing.T) {
+	loader := NewLoader()
+	loader.schema = &types.Schema{
+		Entities: map[string]*types.Entity{
+			"users": {
+				Fields: map[string]*types.Field{
+					"id":   {Type: types.FieldTypeString, Required: true},
+					"name": {Type: types.FieldTypeString, Required: true},
+				},
+			},
+		},
+	}
+	seedData := map[string][]map[string]interface{}{
+		"users": {
+			{"id": "1", "name": "Alice"},
+			{"id": "2"},
+			{"id": "3", "name": "Carol"},
+			{"id": "4", "name": 4},
+			{"name": "Eve"},
+			{"id": 6, "name": "Frank"},
+		},
+		"ghosts": {{"id": "1"}},
+	}
+
+	errs := loader.SeedDataErrors(seedData)
+	want := []string{
+		"seed data contains unknown entity: ghosts",
+		"seed data for users[1]: required field \"name\" is missing",
+		"seed data for users[3]:",
+		"seed data for users[4]: required field \"id\" is missing",
+		"seed data for users[5]: field \"id\" must be a string",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(errs), len(want), errs)
+	}
+	for i, prefix := range want {
+		if !contains(errs[i].Error(), prefix) {
+			t.Errorf("error %d = %q, want it to contain %q", i, errs[i].Error(), prefix)
+		}
+	}
+	if err := loader.ValidateSeedData(seedData); err == nil || err.Error() != errs[0].Error() {
+		t.Errorf("ValidateSeedData() = %v, want the first error %v", err, errs[0])
+	}
+
+	valid := WithoutInvalidSeed(seedData, errs)
+	if _, kept := valid["ghosts"]; kept {
+		t.Error("unknown entity was kept")
+	}
+	if users := valid["users"]; len(users) != 2 || users[0]["id"] != "1" || users[1]["id"] != "3" {
+		t.Errorf("valid users = %v, want records 1 and 3", users)
+	}
+	if errs := loader.SeedDataErrors(valid); len(errs) != 0 {
+		t.Errorf("filtered seed data still has errors: %v", errs)
+	}
+}
+
+func TestCheckRequiredSeed(t *testing.T) {
+	loader := NewLoader()
+	loader.schema = &types.Schema{
+		Entities: map[string]*types.Entity{
+			"users": {Fields: map[string]*types.Field{"id": {Type: types.FieldTypeString}}},
+			"posts": {Fields: map[string]*types.Field{"id": {Type: types.FieldTypeString}}},
+		},
+		RequireSeed: map[string]int{"users": 2, "posts": 1},
+	}
+
+	tests := []struct {
+		name        string
+		seedData    map[string][]map[string]interface{}
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name: "requirements met",
+			seedData: map[string][]map[string]interface{}{
+				"users": {{"id": "1"}, {"id": "2"}},
+				"posts": {{"id": "1"}},
+			},
+		},
+		{
+			name: "too few records",
+			seedData: map[string][]map[string]interface{}{
+				"users": {{"id": "1"}},
+				"posts": {{"id": "1"}},
+			},
+			wantErr:     true,
+			errContains: "users has 1, needs at least 2",
+		},
+		{
+			name:        "no seed data",
+			seedData:    map[string][]map[string]interface{}{},
+			wantErr:     true,
+			errContains: "posts has 0, needs at least 1; users has 0, needs at least 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := loader.CheckRequiredSeed(tt.seedData)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckRequiredSeed() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !contains(err.Error(), tt.errContains) {
+				t.Errorf("error = %q, want it to contain %q", err.Error(), tt.errContains)
+			}
+		})
+	}
+}
+
 func TestBuildRouteMap(t *testing.T) {
 	loader := NewLoader()
 	loader.schema = &types.Schema{
@@ -386,6 +1120,15 @@ func TestValidateFieldValue(t *testing.T) {
 		{"object invalid", types.FieldTypeObject, "not an object", true},
 		{"array valid", types.FieldTypeArray, []interface{}{1, 2, 3}, false},
 		{"array invalid", types.FieldTypeArray, "not an array", true},
+		{"binary valid", types.FieldTypeBinary, "aGVsbG8=", false},
+		{"binary malformed", types.FieldTypeBinary, "not base64!", true},
+		{"binary not string", types.FieldTypeBinary, 42.0, true},
+		{"datetime string", types.FieldTypeDatetime, "2024-01-02T15:04:05Z", false},
+		{"datetime not string", types.FieldTypeDatetime, 42.0, true},
+		{"integer valid", types.FieldTypeInteger, 42.0, false},
+		{"integer fractional", types.FieldTypeInteger, 42.5, true},
+		{"json.Number number", types.FieldTypeNumber, json.Number("12.5"), false},
+		{"json.Number integer", types.FieldTypeInteger, json.Number("9007199254740993"), false},
 		{"null allowed", types.FieldTypeString, nil, false},
 	}
 
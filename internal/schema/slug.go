@@ -0,0 +1,54 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+// validateSlugFrom checks that a slug field is a string generated from
+// another string field of the same entity
+func validateSlugFrom(name string, field *types.Field, fields map[string]*types.Field) error {
+	if name == "id" {
+		return fmt.Errorf("the id field cannot use slugFrom")
+	}
+	if field.Type != types.FieldTypeString {
+		return fmt.Errorf("slugFrom is only supported on string fields")
+	}
+	if field.Default != nil || field.Random {
+		return fmt.Errorf("slugFrom cannot be combined with default or random")
+	}
+	if field.SlugFrom == name {
+		return fmt.Errorf("slugFrom must name another field")
+	}
+	source, exists := fields[field.SlugFrom]
+	if !exists {
+		return fmt.Errorf("slugFrom: unknown field %q", field.SlugFrom)
+	}
+	if source.Type != types.FieldTypeString {
+		return fmt.Errorf("slugFrom: field %q must be a string", field.SlugFrom)
+	}
+	return nil
+}
+
+// Slugify lowercases text and joins its words with hyphens, dropping
+// punctuation: "Hello, World!" becomes "hello-world"
+func Slugify(text string) string {
+	var b strings.Builder
+	pendingHyphen := false
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if pendingHyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingHyphen = false
+			b.WriteRune(r)
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			pendingHyphen = true
+		}
+	}
+	return b.String()
+}
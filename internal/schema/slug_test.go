@@ -0,0 +1,28 @@
+package schema
+
+import "testing"
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Hello World", "hello-world"},
+		{"Hello, World!", "hello-world"},
+		{"  Leading and trailing  ", "leading-and-trailing"},
+		{"Don't Panic", "dont-panic"},
+		{"snake_case and-hyphens", "snake-case-and-hyphens"},
+		{"Multiple   spaces -- here", "multiple-spaces-here"},
+		{"Version 2.0", "version-20"},
+		{"Crème Brûlée", "crème-brûlée"},
+		{"!!!", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := Slugify(tt.text); got != tt.want {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,357 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ticktockbent/ape_my/internal/schema"
+	"github.com/ticktockbent/ape_my/internal/storage"
+)
+
+// Reserved paths for built-in endpoints. They are served at the root,
+// independent of the schema's basePath.
+const (
+	routesPath      = "/__routes"
+	exportPath      = "/__export"
+	importPath      = "/__import"
+	echoPath        = "/__echo"
+	maintenancePath = "/__maintenance"
+	schemaPath      = "/__schema"
+	metricsPath     = "/__metrics"
+)
+
+// Import modes selected with ?mode=
+const (
+	importModeMerge   = "merge"   // upsert records by id, keep everything else
+	importModeReplace = "replace" // clear every entity type before loading
+)
+
+// exportFilename is the filename suggested to clients saving an export
+const exportFilename = "ape_my-export.json"
+
+// EchoResponse is the body returned by /__echo
+type EchoResponse struct {
+	Method  string              `json:"method"`
+	Path    string              `json:"path"`
+	Headers map[string][]string `json:"headers"`
+	Query   map[string][]string `json:"query"`
+	Body    interface{}         `json:"body"` // parsed JSON, the raw text if not JSON, or null if empty
+}
+
+// RouteDescription describes one registered route for the introspection endpoint
+type RouteDescription struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Entity string `json:"entity,omitempty"`
+	Source string `json:"source"` // "generated" or "custom"
+}
+
+// MetricsResponse is the body returned by /__metrics
+type MetricsResponse struct {
+	Storage     map[string]storage.OperationStats `json:"storage"`               // keyed by store operation
+	Concurrency *ConcurrencyStats                 `json:"concurrency,omitempty"` // set with --max-concurrent
+}
+
+// registerReservedRoutes registers the built-in endpoints
+func (s *Server) registerReservedRoutes() {
+	s.mux.HandleFunc("GET "+routesPath, s.withReservedMiddleware(s.handleRoutes))
+	s.mux.HandleFunc("GET "+schemaPath, s.withReservedMiddleware(s.requireAuth(s.handleSchema)))
+	if s.options.AllowExport {
+		s.mux.HandleFunc("GET "+exportPath, s.withReservedMiddleware(s.requireAuth(s.handleExport)))
+	}
+	if s.options.AllowReset {
+		s.mux.HandleFunc("POST "+importPath, s.withReservedMiddleware(s.requireAuth(s.handleImport)))
+	}
+	if s.options.Debug {
+		s.mux.HandleFunc("POST "+echoPath, s.withReservedMiddleware(s.handleEcho))
+	}
+	if s.options.AllowMaintenance {
+		s.mux.HandleFunc("POST "+maintenancePath, s.withReservedMiddleware(s.requireAuth(s.handleMaintenance)))
+	}
+	if s.options.Metrics {
+		s.mux.HandleFunc("GET "+metricsPath, s.withReservedMiddleware(s.handleMetrics))
+	}
+}
+
+// withReservedMiddleware wraps built-in endpoints with logging and JSON headers.
+// Reserved endpoints are tooling for the mock itself, so schema-level checks
+// are not applied, and auth only where requireAuth adds it.
+func (s *Server) withReservedMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := newStatusRecorder(w, s.recordedBodyLimit())
+		requestBody := s.captureRequestBody(r)
+		s.logRequest(r)
+
+		rec.Header().Set("Content-Type", "application/json")
+		s.callRecovered(rec, r, func() {
+			next(rec, r)
+		})
+
+		s.logCompletion(r, rec, time.Since(start))
+		s.captureExchange(r, requestBody, rec, start)
+	}
+}
+
+// requireAuth applies the schema's auth to a reserved endpoint that exposes
+// or changes the served data or schema
+func (s *Server) requireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.authStep(w, r, next)
+	}
+}
+
+// handleMetrics handles GET /__metrics - report store operation timings. Only
+// stores wrapped in a storage.TimedStore have any to report.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	response := MetricsResponse{Storage: map[string]storage.OperationStats{}}
+	if reporter, ok := s.store.(storage.StatsReporter); ok {
+		response.Storage = reporter.OperationStats()
+	}
+	if s.limiter != nil {
+		response.Concurrency = s.limiter.stats()
+	}
+	s.respondJSON(w, http.StatusOK, response)
+}
+
+// handleRoutes handles GET /__routes - describe every registered route
+func (s *Server) handleRoutes(w http.ResponseWriter, r *http.Request) {
+	s.respondJSON(w, http.StatusOK, s.describeRoutes())
+}
+
+// describeRoutes builds the list of generated and custom routes, sorted by path
+func (s *Server) describeRoutes() []RouteDescription {
+	var routes []RouteDescription
+
+	collectionMethods := []string{http.MethodGet, http.MethodPost}
+	if s.options.AllowBulkUpdate {
+		collectionMethods = append(collectionMethods, http.MethodPatch)
+	}
+	for _, route := range s.routeMap.GetRoutes() {
+		for _, method := range collectionMethods {
+			routes = append(routes, RouteDescription{
+				Method: method, Path: route.CollectionPath, Entity: route.EntityName, Source: "generated",
+			})
+		}
+		for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			routes = append(routes, RouteDescription{
+				Method: method, Path: route.ItemPath, Entity: route.EntityName, Source: "generated",
+			})
+		}
+	}
+
+	if s.schema != nil {
+		for _, route := range s.schema.Routes {
+			routes = append(routes, RouteDescription{
+				Method: strings.ToUpper(route.Method),
+				Path:   schema.CustomRoutePath(s.schema.BasePath, convertPathParams(route.Path)),
+				Entity: route.Entity,
+				Source: "custom",
+			})
+		}
+	}
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	return routes
+}
+
+// handleExport handles GET /__export - dump the store in the seed-file format.
+// Each entity type is written as it is read so large datasets are streamed
+// rather than buffered into a single document.
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	entityNames := make([]string, 0, len(s.routeMap))
+	for name := range s.routeMap {
+		entityNames = append(entityNames, name)
+	}
+	sort.Strings(entityNames)
+
+	w.Header().Set("Content-Disposition", `attachment; filename="`+exportFilename+`"`)
+	w.WriteHeader(http.StatusOK)
+
+	if err := s.writeExport(w, entityNames); err != nil {
+		// Headers are already sent, so the best we can do is log and stop
+		log.Printf("Export failed: %v", err)
+	}
+}
+
+// writeExport writes {"entityType": [entity, ...], ...} with entities sorted by id
+func (s *Server) writeExport(w http.ResponseWriter, entityNames []string) error {
+	flusher, _ := w.(http.Flusher)
+
+	if _, err := w.Write([]byte("{")); err != nil {
+		return err
+	}
+	for i, name := range entityNames {
+		entities, err := s.store.List(name)
+		if err != nil {
+			return err
+		}
+		sort.Slice(entities, func(a, b int) bool {
+			idA, _ := entities[a]["id"].(string)
+			idB, _ := entities[b]["id"].(string)
+			return idA < idB
+		})
+
+		key, _ := json.Marshal(name)
+		prefix := ""
+		if i > 0 {
+			prefix = ","
+		}
+		if _, err := w.Write([]byte(prefix + "\n  " + string(key) + ": [")); err != nil {
+			return err
+		}
+		for j, entity := range entities {
+			data, err := json.Marshal(entity)
+			if err != nil {
+				return err
+			}
+			sep := "\n    "
+			if j > 0 {
+				sep = ",\n    "
+			}
+			if _, err := w.Write(append([]byte(sep), data...)); err != nil {
+				return err
+			}
+		}
+		closing := "]"
+		if len(entities) > 0 {
+			closing = "\n  ]"
+		}
+		if _, err := w.Write([]byte(closing)); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	_, err := w.Write([]byte("\n}\n"))
+	return err
+}
+
+// ImportSummary reports the result of a POST /__import
+type ImportSummary struct {
+	Mode     string         `json:"mode"`
+	Imported map[string]int `json:"imported"`
+	Skipped  []string       `json:"skipped,omitempty"` // read-only entities in the payload, left as they were
+}
+
+// handleImport handles POST /__import - load seed-format data into the store.
+// The whole payload is validated before anything is written, so a bad record
+// rejects the import without partially applying it.
+func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = importModeMerge
+	}
+	if mode != importModeMerge && mode != importModeReplace {
+		s.respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid import mode %q (must be %s or %s)", mode, importModeMerge, importModeReplace))
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	var seedData map[string][]map[string]interface{}
+	if err := schema.DecodeJSON(body, &seedData); err != nil {
+		s.respondError(w, http.StatusBadRequest, "Invalid JSON: expected seed data format")
+		return
+	}
+
+	if err := s.validator.loader.ValidateSeedData(seedData); err != nil {
+		s.respondError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if mode == importModeReplace {
+		for name := range s.routeMap {
+			if s.readOnly(name) {
+				continue
+			}
+			if err := s.store.Reset(name); err != nil {
+				s.respondError(w, http.StatusInternalServerError, "Failed to reset data")
+				return
+			}
+		}
+	}
+
+	summary := ImportSummary{Mode: mode, Imported: make(map[string]int, len(seedData))}
+	for name, entities := range seedData {
+		if s.readOnly(name) {
+			summary.Skipped = append(summary.Skipped, name)
+			continue
+		}
+		if err := s.store.Seed(name, entities); err != nil {
+			s.respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to import %s", name))
+			return
+		}
+		summary.Imported[name] = len(entities)
+	}
+
+	sort.Strings(summary.Skipped)
+	log.Printf("Imported data (%s): %v", mode, summary.Imported)
+	s.respondJSON(w, http.StatusOK, summary)
+}
+
+// handleOptionsAsterisk handles the server-wide "OPTIONS *" request (RFC 7231
+// section 4.3.7) with 204 and an Allow header listing every method the server
+// routes. The mux cannot match "*", so ServeHTTP dispatches here directly.
+func (s *Server) handleOptionsAsterisk(w http.ResponseWriter, r *http.Request) {
+	w.Header().Del("Content-Type")
+	w.Header().Set("Allow", strings.Join(s.allowedMethods(), ", "))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// allowedMethods returns the sorted set of methods used by any registered route
+func (s *Server) allowedMethods() []string {
+	seen := map[string]bool{http.MethodOptions: true}
+	for _, route := range s.describeRoutes() {
+		seen[route.Method] = true
+	}
+
+	methods := make([]string, 0, len(seen))
+	for method := range seen {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// handleEcho handles POST /__echo - return the request as the server parsed it.
+// Credentials are redacted unless verbose logging is on.
+func (s *Server) handleEcho(w http.ResponseWriter, r *http.Request) {
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	var body interface{}
+	if len(raw) > 0 {
+		if err := schema.DecodeJSON(raw, &body); err != nil {
+			body = string(raw)
+		}
+	}
+
+	s.respondJSON(w, http.StatusOK, EchoResponse{
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Headers: s.visibleHeaders(r.Header),
+		Query:   r.URL.Query(),
+		Body:    body,
+	})
+}
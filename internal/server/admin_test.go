@@ -0,0 +1,668 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ticktockbent/ape_my/internal/schema"
+	"github.com/ticktockbent/ape_my/internal/storage"
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+func TestRoutesIntrospection(t *testing.T) {
+	schemaJSON := `{
+		"basePath": "/api/v1",
+		"auth": {"token": "secret"},
+		"entities": {
+			"users": {
+				"fields": {
+					"id":   {"type": "string", "required": true},
+					"name": {"type": "string", "required": true}
+				}
+			}
+		},
+		"routes": [
+			{"method": "get", "path": "/users/:userId/profile", "entity": "users", "filters": {"userId": "id"}}
+		]
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+
+	// No Authorization header: the endpoint is excluded from auth
+	req := httptest.NewRequest(http.MethodGet, "/__routes", http.NoBody)
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d, body: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var routes []RouteDescription
+	if err := json.NewDecoder(w.Body).Decode(&routes); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	// 2 collection + 4 item routes + 1 custom route
+	if len(routes) != 7 {
+		t.Fatalf("got %d routes, want 7: %+v", len(routes), routes)
+	}
+
+	want := map[string]string{
+		"GET /api/v1/users":                  "generated",
+		"POST /api/v1/users":                 "generated",
+		"DELETE /api/v1/users/{id}":          "generated",
+		"GET /api/v1/users/{userId}/profile": "custom",
+	}
+	found := make(map[string]string)
+	for _, route := range routes {
+		found[route.Method+" "+route.Path] = route.Source
+		if route.Entity != "users" {
+			t.Errorf("route %s %s: entity = %q, want users", route.Method, route.Path, route.Entity)
+		}
+	}
+	for key, source := range want {
+		if found[key] != source {
+			t.Errorf("route %q: source = %q, want %q", key, found[key], source)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	srv := setupTestServerWithOptions(t, Options{AllowExport: true})
+	srv.store.Seed("users", []map[string]interface{}{
+		{"id": "2", "name": "Bob"},
+		{"id": "1", "name": "Alice"},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/__export", http.NoBody)
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d, body: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.Contains(cd, `filename="ape_my-export.json"`) {
+		t.Errorf("Content-Disposition = %q, want suggested filename", cd)
+	}
+
+	// The export must load back as seed data
+	var seedData map[string][]map[string]interface{}
+	if err := schema.DecodeJSON(w.Body.Bytes(), &seedData); err != nil {
+		t.Fatalf("export is not valid seed data: %v\n%s", err, w.Body.String())
+	}
+	users := seedData["users"]
+	if len(users) != 2 || users[0]["id"] != "1" || users[1]["id"] != "2" {
+		t.Errorf("users = %v, want both users sorted by id", users)
+	}
+	if posts, ok := seedData["posts"]; !ok || len(posts) != 0 {
+		t.Errorf("posts = %v, want empty list", posts)
+	}
+}
+
+// setupAuthTestServer creates a test server with runtime options whose schema
+// requires the token "secret"
+func setupAuthTestServer(t *testing.T, opts Options) *Server {
+	t.Helper()
+	srv := setupTestServerWithOptions(t, opts)
+	srv.schema.Auth = &types.AuthConfig{Token: "secret"}
+	return srv
+}
+
+// reservedRequest sends a JSON request to a reserved endpoint, with the token
+// as a Bearer credential unless it is empty
+func reservedRequest(srv *Server, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+	return w
+}
+
+func TestExportAuth(t *testing.T) {
+	srv := setupAuthTestServer(t, Options{AllowExport: true})
+
+	if w := reservedRequest(srv, http.MethodGet, "/__export", "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("without token: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if w := reservedRequest(srv, http.MethodGet, "/__export", "wrong", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong token: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if w := reservedRequest(srv, http.MethodGet, "/__export", "secret", ""); w.Code != http.StatusOK {
+		t.Errorf("with token: status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestImportAuth(t *testing.T) {
+	srv := setupAuthTestServer(t, Options{AllowReset: true})
+	body := `{"users": [{"id": "1", "name": "Alice", "email": "alice@example.com"}]}`
+
+	if w := reservedRequest(srv, http.MethodPost, "/__import", "", body); w.Code != http.StatusUnauthorized {
+		t.Errorf("without token: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if users, _ := srv.store.List("users"); len(users) != 0 {
+		t.Errorf("unauthorized import stored %v", users)
+	}
+	if w := reservedRequest(srv, http.MethodPost, "/__import", "secret", body); w.Code != http.StatusOK {
+		t.Errorf("with token: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+}
+
+func TestMaintenanceAuth(t *testing.T) {
+	srv := setupAuthTestServer(t, Options{AllowMaintenance: true})
+
+	if w := reservedRequest(srv, http.MethodPost, "/__maintenance", "", `{"enabled": true}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("without token: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if w := reservedRequest(srv, http.MethodGet, "/users", "secret", ""); w.Code != http.StatusOK {
+		t.Errorf("after unauthorized enable: status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := reservedRequest(srv, http.MethodPost, "/__maintenance", "secret", `{"enabled": true}`); w.Code != http.StatusOK {
+		t.Errorf("with token: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+}
+
+func TestExportDisabled(t *testing.T) {
+	srv := setupTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/__export", http.NoBody)
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		body         string
+		wantStatus   int
+		wantUserIDs  []string
+		wantPostsLen int
+	}{
+		{
+			name:         "merge keeps existing records",
+			body:         `{"users": [{"id": "2", "name": "Bobby", "email": "bob@example.com"}, {"id": "3", "name": "Cara", "email": "cara@example.com"}]}`,
+			wantStatus:   http.StatusOK,
+			wantUserIDs:  []string{"1", "2", "3"},
+			wantPostsLen: 1,
+		},
+		{
+			name:         "replace clears all entity types",
+			query:        "?mode=replace",
+			body:         `{"users": [{"id": "9", "name": "Zed", "email": "zed@example.com"}]}`,
+			wantStatus:   http.StatusOK,
+			wantUserIDs:  []string{"9"},
+			wantPostsLen: 0,
+		},
+		{
+			name:         "invalid record rejects whole import",
+			query:        "?mode=replace",
+			body:         `{"users": [{"id": "3", "name": "Cara", "email": "cara@example.com"}, {"id": "4", "name": 42, "email": "x@example.com"}]}`,
+			wantStatus:   http.StatusUnprocessableEntity,
+			wantUserIDs:  []string{"1", "2"},
+			wantPostsLen: 1,
+		},
+		{
+			name:         "missing id",
+			body:         `{"users": [{"name": "Cara", "email": "cara@example.com"}]}`,
+			wantStatus:   http.StatusUnprocessableEntity,
+			wantUserIDs:  []string{"1", "2"},
+			wantPostsLen: 1,
+		},
+		{
+			name:         "unknown entity",
+			body:         `{"widgets": [{"id": "1"}]}`,
+			wantStatus:   http.StatusUnprocessableEntity,
+			wantUserIDs:  []string{"1", "2"},
+			wantPostsLen: 1,
+		},
+		{
+			name:         "invalid mode",
+			query:        "?mode=append",
+			body:         `{}`,
+			wantStatus:   http.StatusBadRequest,
+			wantUserIDs:  []string{"1", "2"},
+			wantPostsLen: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := setupTestServerWithOptions(t, Options{AllowReset: true})
+			srv.store.Seed("users", []map[string]interface{}{
+				{"id": "1", "name": "Alice", "email": "alice@example.com"},
+				{"id": "2", "name": "Bob", "email": "bob@example.com"},
+			})
+			srv.store.Seed("posts", []map[string]interface{}{
+				{"id": "1", "title": "Hello"},
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/__import"+tt.query, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+
+			users, _ := srv.store.List("users")
+			gotIDs := make(map[string]bool)
+			for _, user := range users {
+				gotIDs[user["id"].(string)] = true
+			}
+			if len(gotIDs) != len(tt.wantUserIDs) {
+				t.Errorf("users = %v, want ids %v", users, tt.wantUserIDs)
+			}
+			for _, id := range tt.wantUserIDs {
+				if !gotIDs[id] {
+					t.Errorf("user %s missing after import", id)
+				}
+			}
+			posts, _ := srv.store.List("posts")
+			if len(posts) != tt.wantPostsLen {
+				t.Errorf("got %d posts, want %d", len(posts), tt.wantPostsLen)
+			}
+		})
+	}
+}
+
+func TestImportSummary(t *testing.T) {
+	srv := setupTestServerWithOptions(t, Options{AllowReset: true})
+
+	body := `{"users": [{"id": "1", "name": "Alice", "email": "alice@example.com"}], "posts": []}`
+	req := httptest.NewRequest(http.MethodPost, "/__import", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+
+	var summary ImportSummary
+	if err := json.NewDecoder(w.Body).Decode(&summary); err != nil {
+		t.Fatalf("failed to decode summary: %v", err)
+	}
+	if summary.Mode != "merge" || summary.Imported["users"] != 1 || summary.Imported["posts"] != 0 {
+		t.Errorf("summary = %+v, want merge with users=1 posts=0", summary)
+	}
+}
+
+func TestImportDisabled(t *testing.T) {
+	srv := setupTestServer(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/__import", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestOptionsAsterisk(t *testing.T) {
+	schemaJSON := `{
+		"entities": {
+			"users": {
+				"fields": {
+					"id":   {"type": "string", "required": true},
+					"name": {"type": "string", "required": true}
+				}
+			}
+		}
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+
+	req := httptest.NewRequest(http.MethodOptions, "*", http.NoBody)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	want := "DELETE, GET, OPTIONS, PATCH, POST, PUT"
+	if got := w.Header().Get("Allow"); got != want {
+		t.Errorf("Allow = %q, want %q", got, want)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("expected empty body, got %q", w.Body.String())
+	}
+
+	// Regular requests still reach entity routes
+	req = httptest.NewRequest(http.MethodGet, "/users", http.NoBody)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("GET /users status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestEcho(t *testing.T) {
+	tests := []struct {
+		name     string
+		logLevel LogLevel
+		body     string
+		wantAuth string
+		wantBody interface{}
+	}{
+		{name: "json body", logLevel: LogNormal, body: `{"name": "Alice"}`, wantAuth: "[REDACTED]", wantBody: map[string]interface{}{"name": "Alice"}},
+		{name: "text body", logLevel: LogNormal, body: "not json", wantAuth: "[REDACTED]", wantBody: "not json"},
+		{name: "empty body", logLevel: LogNormal, body: "", wantAuth: "[REDACTED]", wantBody: nil},
+		{name: "verbose shows auth", logLevel: LogVerbose, body: "", wantAuth: "Bearer secret", wantBody: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := setupTestServerWithOptions(t, Options{Debug: true, LogLevel: tt.logLevel})
+
+			req := httptest.NewRequest(http.MethodPost, "/__echo?tag=a&tag=b", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer secret")
+			req.Header.Set("Content-Type", "text/plain")
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d, body: %s", w.Code, http.StatusOK, w.Body.String())
+			}
+
+			var echo struct {
+				Method  string              `json:"method"`
+				Headers map[string][]string `json:"headers"`
+				Query   map[string][]string `json:"query"`
+				Body    interface{}         `json:"body"`
+			}
+			json.NewDecoder(w.Body).Decode(&echo)
+
+			if echo.Method != http.MethodPost {
+				t.Errorf("method = %q, want POST", echo.Method)
+			}
+			if got := echo.Headers["Authorization"]; len(got) != 1 || got[0] != tt.wantAuth {
+				t.Errorf("Authorization = %v, want %q", got, tt.wantAuth)
+			}
+			if got := echo.Query["tag"]; len(got) != 2 || got[0] != "a" || got[1] != "b" {
+				t.Errorf("query tag = %v, want [a b]", got)
+			}
+			if !reflect.DeepEqual(echo.Body, tt.wantBody) {
+				t.Errorf("body = %#v, want %#v", echo.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestEchoDisabled(t *testing.T) {
+	srv := setupTestServer(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/__echo", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestMaintenance(t *testing.T) {
+	srv := setupTestServerWithOptions(t, Options{AllowMaintenance: true})
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodPost, "/__maintenance", `{"enabled": true, "retryAfter": 120}`); w.Code != http.StatusOK {
+		t.Fatalf("enable: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	w := do(http.MethodGet, "/users", "")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("API route: status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if got := w.Header().Get("Retry-After"); got != "120" {
+		t.Errorf("Retry-After = %q, want 120", got)
+	}
+	if w := do(http.MethodPost, "/users", `{"name": "Alice", "email": "a@example.com"}`); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("write: status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if w := do(http.MethodGet, "/__routes", ""); w.Code != http.StatusOK {
+		t.Errorf("built-in endpoint: status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	if w := do(http.MethodPost, "/__maintenance", `{"enabled": false}`); w.Code != http.StatusOK {
+		t.Fatalf("disable: status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := do(http.MethodGet, "/users", ""); w.Code != http.StatusOK {
+		t.Errorf("after disabling: status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	for _, body := range []string{`{}`, `{"enabled": true, "retryAfter": 0}`, `nope`} {
+		if w := do(http.MethodPost, "/__maintenance", body); w.Code != http.StatusBadRequest {
+			t.Errorf("body %s: status = %d, want %d", body, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestMaintenanceDisabled(t *testing.T) {
+	srv := setupTestServer(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/__maintenance", strings.NewReader(`{"enabled": true}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestSchemaEndpoint(t *testing.T) {
+	srv := setupTestServerWithOptions(t, Options{AllowSchemaEdit: true})
+	srv.store.Create("users", map[string]interface{}{"name": "Alice", "email": "alice@example.com"})
+	srv.store.Create("posts", map[string]interface{}{"title": "Hello"})
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "/__schema", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET: status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var current map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &current)
+	if entities, _ := current["entities"].(map[string]interface{}); len(entities) != 2 {
+		t.Errorf("GET entities = %v, want users and posts", current["entities"])
+	}
+
+	w = do(http.MethodPut, "/__schema", `{"entities": {"users": {"fields": {"id": {"type": "string"}}}}, "bogus": 1`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("invalid JSON: status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+	w = do(http.MethodPut, "/__schema", `{"entities": {"users": {"fields": {"name": {"type": "string"}}}}}`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("invalid schema: status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+	if w := do(http.MethodGet, "/posts/1", ""); w.Code != http.StatusOK {
+		t.Errorf("old schema should stay active after a rejected PUT, GET /posts/1 = %d", w.Code)
+	}
+
+	w = do(http.MethodPut, "/__schema", `{
+		"entities": {
+			"users": {"fields": {"id": {"type": "string"}, "name": {"type": "string", "required": true}, "email": {"type": "string"}}},
+			"comments": {"fields": {"id": {"type": "string"}, "body": {"type": "string", "required": true}}}
+		}
+	}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	if w := do(http.MethodGet, "/users/1", ""); w.Code != http.StatusOK {
+		t.Errorf("surviving entity data: GET /users/1 = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := do(http.MethodGet, "/posts", ""); w.Code != http.StatusNotFound {
+		t.Errorf("removed entity: GET /posts = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := do(http.MethodPost, "/comments", `{"body": "First"}`); w.Code != http.StatusCreated {
+		t.Errorf("new entity: POST /comments = %d, want %d", w.Code, http.StatusCreated)
+	}
+	if w := do(http.MethodPost, "/comments", `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("new validator: POST /comments without body = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestSchemaEndpointAuth(t *testing.T) {
+	srv := setupTestServerWithSchema(t, `{
+		"auth": {"token": "secret"},
+		"entities": {
+			"users": {"fields": {"id": {"type": "string"}, "name": {"type": "string"}}}
+		}
+	}`)
+	srv.options.AllowSchemaEdit = true
+
+	do := func(method, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/__schema", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodGet, "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("GET without token: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	w := do(http.MethodGet, "secret", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET with token: status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if strings.Contains(w.Body.String(), "secret") {
+		t.Errorf("GET leaked the token: %s", w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), redactedToken) {
+		t.Errorf("GET body = %s, want the token redacted", w.Body.String())
+	}
+
+	replacement := `{"auth": {"token": "secret"}, "entities": {"posts": {"fields": {"id": {"type": "string"}}}}}`
+	if w := do(http.MethodPut, "wrong", replacement); w.Code != http.StatusUnauthorized {
+		t.Errorf("PUT with wrong token: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	w = do(http.MethodPut, "secret", replacement)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT with token: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "secret") {
+		t.Errorf("PUT leaked the token: %s", w.Body.String())
+	}
+}
+
+func TestSchemaReplaceConfiguresStore(t *testing.T) {
+	srv := setupTestServerWithOptions(t, Options{AllowSchemaEdit: true})
+	srv.store.(*storage.InMemoryStore).SetVersioned("posts")
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPut, "/__schema", `{
+		"entities": {
+			"users": {"versioning": true, "startId": 500, "fields": {"id": {"type": "string"}, "name": {"type": "string"}}},
+			"posts": {"fields": {"id": {"type": "string"}, "title": {"type": "string"}}}
+		}
+	}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var user map[string]interface{}
+	json.Unmarshal(do(http.MethodPost, "/users", `{"name": "Alice"}`).Body.Bytes(), &user)
+	if user["id"] != "501" || user[storage.VersionField] != float64(1) {
+		t.Errorf("user = %v, want id 501 at version 1", user)
+	}
+	var post map[string]interface{}
+	json.Unmarshal(do(http.MethodPost, "/posts", `{"title": "Hello"}`).Body.Bytes(), &post)
+	if _, versioned := post[storage.VersionField]; versioned {
+		t.Errorf("post = %v, want no version once the schema drops versioning", post)
+	}
+}
+
+func TestSchemaReplaceJSONSchema(t *testing.T) {
+	srv := setupTestServerWithOptions(t, Options{AllowSchemaEdit: true, JSONSchema: true})
+
+	req := httptest.NewRequest(http.MethodPut, "/__schema", strings.NewReader(`{
+		"definitions": {
+			"comments": {"type": "object", "properties": {"id": {"type": "string"}, "body": {"type": "string"}}}
+		}
+	}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if _, exists := srv.routeMap["comments"]; !exists {
+		t.Errorf("routes = %v, want comments", srv.routeMap)
+	}
+}
+
+func TestSchemaEditDisabled(t *testing.T) {
+	srv := setupTestServer(t)
+	req := httptest.NewRequest(http.MethodPut, "/__schema", strings.NewReader(`{"entities": {}}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	store.Initialize([]string{"users", "posts"})
+	loader := setupTestSchema(t)
+	routeMap, err := loader.BuildRouteMap()
+	if err != nil {
+		t.Fatalf("failed to build route map: %v", err)
+	}
+	srv := NewWithOptions(8080, storage.NewTimedStore(store), routeMap, loader, Options{Metrics: true})
+	srv.RegisterRoutes()
+
+	for _, path := range []string{"/users", "/users", "/users/1"} {
+		req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		srv.mux.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/__metrics", http.NoBody)
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var metrics MetricsResponse
+	if err := json.NewDecoder(w.Body).Decode(&metrics); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if metrics.Storage["listQuery"].Count != 2 {
+		t.Errorf("listQuery count = %d, want 2: %+v", metrics.Storage["listQuery"].Count, metrics.Storage)
+	}
+	if metrics.Storage["get"].Count != 1 {
+		t.Errorf("get count = %d, want 1: %+v", metrics.Storage["get"].Count, metrics.Storage)
+	}
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+// apiVersionParam is the query parameter naming one of the schema's apiVersions
+const apiVersionParam = "apiVersion"
+
+// vendorVersion extracts the version from Accept types like application/vnd.myapi.v2+json
+var vendorVersion = regexp.MustCompile(`application/vnd\.[A-Za-z0-9._-]+\.v([A-Za-z0-9]+)\+json`)
+
+// requestedAPIVersion returns the version named by ?apiVersion or, failing
+// that, a vendor Accept type; "" if the request names none
+func requestedAPIVersion(r *http.Request) string {
+	if version := r.URL.Query().Get(apiVersionParam); version != "" {
+		return version
+	}
+	if match := vendorVersion.FindStringSubmatch(r.Header.Get("Accept")); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// apiVersion resolves the request's version variant. A nil variant means the
+// top-level settings apply; ok is false if the request names an unknown version.
+func (s *Server) apiVersion(r *http.Request) (variant *types.APIVersion, ok bool) {
+	if s.schema == nil || s.schema.APIVersions == nil {
+		return nil, true
+	}
+	config := s.schema.APIVersions
+	version := requestedAPIVersion(r)
+	if version == "" {
+		version = config.Default
+	}
+	if variant, exists := config.Variants[version]; exists {
+		return variant, true
+	}
+	return nil, version == "" || version == config.Default
+}
+
+// respondIfUnsupportedVersion answers 400 for a version the schema doesn't
+// define and reports whether it did
+func (s *Server) respondIfUnsupportedVersion(w http.ResponseWriter, r *http.Request) bool {
+	if _, ok := s.apiVersion(r); ok {
+		return false
+	}
+	s.respondError(w, http.StatusBadRequest, fmt.Sprintf("Unsupported API version %q", requestedAPIVersion(r)))
+	return true
+}
+
+// responseWrapper returns the envelope for the request's version, falling
+// back to the top-level responseWrapper
+func (s *Server) responseWrapper(r *http.Request) *types.ResponseWrapperConfig {
+	if variant, _ := s.apiVersion(r); variant != nil && variant.ResponseWrapper != nil {
+		return variant.ResponseWrapper
+	}
+	if s.schema == nil {
+		return nil
+	}
+	return s.schema.ResponseWrapper
+}
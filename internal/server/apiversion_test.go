@@ -0,0 +1,106 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestAPIVersions(t *testing.T) {
+	srv := setupTestServerWithSchema(t, `{
+		"responseWrapper": {"single": {"data": "$entity"}, "list": {"data": "$entities"}},
+		"apiVersions": {
+			"default": "1",
+			"variants": {
+				"2": {"responseWrapper": {"single": {"user": "$entity"}, "list": {"users": "$entities", "total": "$count"}}},
+				"3": {"responseWrapper": {}, "select": "$[*].name"}
+			}
+		},
+		"entities": {
+			"users": {
+				"fields": {
+					"id":   {"type": "string", "required": true},
+					"name": {"type": "string", "required": true}
+				}
+			}
+		}
+	}`)
+	srv.store.Create("users", map[string]interface{}{"name": "Alice"})
+
+	tests := []struct {
+		name       string
+		path       string
+		accept     string
+		wantStatus int
+		want       interface{}
+	}{
+		{
+			name:       "default version uses top-level wrapper",
+			path:       "/users/1",
+			wantStatus: http.StatusOK,
+			want:       map[string]interface{}{"data": map[string]interface{}{"id": "1", "name": "Alice"}},
+		},
+		{
+			name:       "explicit default version",
+			path:       "/users?apiVersion=1",
+			wantStatus: http.StatusOK,
+			want:       map[string]interface{}{"data": []interface{}{map[string]interface{}{"id": "1", "name": "Alice"}}},
+		},
+		{
+			name:       "query param selects variant wrapper",
+			path:       "/users/1?apiVersion=2",
+			wantStatus: http.StatusOK,
+			want:       map[string]interface{}{"user": map[string]interface{}{"id": "1", "name": "Alice"}},
+		},
+		{
+			name:       "vendor accept type selects variant",
+			path:       "/users",
+			accept:     "application/vnd.myapi.v2+json",
+			wantStatus: http.StatusOK,
+			want:       map[string]interface{}{"users": []interface{}{map[string]interface{}{"id": "1", "name": "Alice"}}, "total": float64(1)},
+		},
+		{
+			name:       "variant projection",
+			path:       "/users?apiVersion=3",
+			wantStatus: http.StatusOK,
+			want:       []interface{}{"Alice"},
+		},
+		{
+			name:       "query param wins over accept",
+			path:       "/users/1?apiVersion=1",
+			accept:     "application/vnd.myapi.v2+json",
+			wantStatus: http.StatusOK,
+			want:       map[string]interface{}{"data": map[string]interface{}{"id": "1", "name": "Alice"}},
+		},
+		{
+			name:       "unknown version",
+			path:       "/users?apiVersion=9",
+			wantStatus: http.StatusBadRequest,
+			want:       map[string]interface{}{"error": `Unsupported API version "9"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, http.NoBody)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			var got interface{}
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("body = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/ticktockbent/ape_my/internal/schema"
+	"github.com/ticktockbent/ape_my/internal/storage"
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+// errElementNotFound is returned from an array removal when no element
+// matches
+var errElementNotFound = errors.New("element not found")
+
+// handleArrayItem handles POST /entities/{id}/{field}, which appends the
+// body's JSON value to an array field, and DELETE
+// /entities/{id}/{field}/{element}, which removes every element equal to
+// element. Both answer with the updated entity.
+func (s *Server) handleArrayItem(entityName, id, subPath string, w http.ResponseWriter, r *http.Request) {
+	fieldName, element, hasElement := strings.Cut(subPath, "/")
+	fieldName = s.internalFieldName(entityName, fieldName)
+	field, declared := s.entityField(entityName, fieldName)
+	if !declared || (r.Method == http.MethodPost && hasElement) || (r.Method == http.MethodDelete && (!hasElement || element == "")) {
+		s.respondError(w, http.StatusNotFound, "Route not found")
+		return
+	}
+	if field.Type != types.FieldTypeArray {
+		s.respondError(w, http.StatusBadRequest, fmt.Sprintf("Field %q is not an array", fieldName))
+		return
+	}
+	if field.WriteOnce {
+		s.respondError(w, http.StatusConflict, fmt.Sprintf("Field %q is write-once and cannot be changed", fieldName))
+		return
+	}
+
+	var update func([]interface{}) ([]interface{}, error)
+	if r.Method == http.MethodPost {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, "Failed to read request body")
+			return
+		}
+		defer r.Body.Close()
+
+		var value interface{}
+		if err := schema.DecodeJSON(body, &value); err != nil {
+			s.respondError(w, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+		update = func(current []interface{}) ([]interface{}, error) {
+			return append(current, value), nil
+		}
+	} else {
+		update = func(current []interface{}) ([]interface{}, error) {
+			kept := make([]interface{}, 0, len(current))
+			for _, item := range current {
+				if !elementMatches(item, element) {
+					kept = append(kept, item)
+				}
+			}
+			if len(kept) == len(current) {
+				return nil, errElementNotFound
+			}
+			return kept, nil
+		}
+	}
+
+	if err := s.store.UpdateArray(entityName, id, fieldName, update); err != nil {
+		if err == storage.ErrNotFound {
+			s.respondError(w, http.StatusNotFound, "Entity not found")
+		} else if err == storage.ErrEntityTypeNotFound {
+			s.respondError(w, http.StatusNotFound, "Entity type not found")
+		} else if err == errElementNotFound {
+			s.respondError(w, http.StatusNotFound, fmt.Sprintf("Element %q not found in %s", element, fieldName))
+		} else if err == storage.ErrNotArray {
+			s.respondError(w, http.StatusConflict, fmt.Sprintf("Stored field %q is not an array", fieldName))
+		} else {
+			log.Printf("Error updating array field: %v", err)
+			s.respondError(w, http.StatusInternalServerError, "Failed to update array field")
+		}
+		return
+	}
+
+	entity, err := s.getWritten(entityName, id)
+	if err != nil {
+		log.Printf("Error retrieving updated entity: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "Entity updated but failed to retrieve")
+		return
+	}
+	s.respondSingle(w, r, entityName, http.StatusOK, entity)
+}
+
+// entityField returns the schema's definition of one of the entity's fields
+func (s *Server) entityField(entityName, fieldName string) (*types.Field, bool) {
+	if s.schema == nil {
+		return nil, false
+	}
+	entity, exists := s.schema.Entities[entityName]
+	if !exists || entity == nil {
+		return nil, false
+	}
+	field, exists := entity.Fields[fieldName]
+	return field, exists && field != nil
+}
+
+// elementMatches reports whether a scalar array element spells element in a
+// URL path; objects and arrays never match
+func elementMatches(item interface{}, element string) bool {
+	switch item.(type) {
+	case map[string]interface{}, []interface{}, nil:
+		return false
+	}
+	return fmt.Sprint(item) == element
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestArrayItemOperations(t *testing.T) {
+	srv := setupTestServerWithSchema(t, `{
+		"entities": {
+			"posts": {
+				"fields": {
+					"id":     {"type": "string", "required": true},
+					"title":  {"type": "string"},
+					"tags":   {"type": "array"},
+					"scores": {"type": "array"},
+					"frozen": {"type": "array", "writeOnce": true}
+				}
+			}
+		}
+	}`)
+	srv.store.Seed("posts", []map[string]interface{}{
+		{"id": "1", "title": "First", "tags": []interface{}{"go"}, "scores": []interface{}{json.Number("5")}},
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantTags   []interface{}
+	}{
+		{"append", http.MethodPost, "/posts/1/tags", `"api"`, http.StatusOK, []interface{}{"go", "api"}},
+		{"append duplicate", http.MethodPost, "/posts/1/tags", `"go"`, http.StatusOK, []interface{}{"go", "api", "go"}},
+		{"remove every match", http.MethodDelete, "/posts/1/tags/go", "", http.StatusOK, []interface{}{"api"}},
+		{"remove missing element", http.MethodDelete, "/posts/1/tags/rust", "", http.StatusNotFound, nil},
+		{"remove number", http.MethodDelete, "/posts/1/scores/5", "", http.StatusOK, []interface{}{"api"}},
+		{"append invalid JSON", http.MethodPost, "/posts/1/tags", `{`, http.StatusBadRequest, nil},
+		{"not an array", http.MethodPost, "/posts/1/title", `"x"`, http.StatusBadRequest, nil},
+		{"undeclared field", http.MethodPost, "/posts/1/labels", `"x"`, http.StatusNotFound, nil},
+		{"write-once field", http.MethodPost, "/posts/1/frozen", `"x"`, http.StatusConflict, nil},
+		{"missing entity", http.MethodPost, "/posts/9/tags", `"x"`, http.StatusNotFound, nil},
+		{"delete without element", http.MethodDelete, "/posts/1/tags", "", http.StatusNotFound, nil},
+		{"other methods", http.MethodPut, "/posts/1/tags", `["x"]`, http.StatusNotFound, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantTags == nil {
+				return
+			}
+			var entity map[string]interface{}
+			if err := json.NewDecoder(w.Body).Decode(&entity); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !reflect.DeepEqual(entity["tags"], tt.wantTags) {
+				t.Errorf("tags = %v, want %v", entity["tags"], tt.wantTags)
+			}
+		})
+	}
+
+	if entity, _ := srv.store.Get("posts", "1"); len(entity["scores"].([]interface{})) != 0 {
+		t.Errorf("scores = %v, want empty", entity["scores"])
+	}
+}
@@ -0,0 +1,23 @@
+package server
+
+import (
+	"log"
+
+	"github.com/ticktockbent/ape_my/internal/storage"
+)
+
+// scheduleAsyncComplete arranges the asyncComplete update for a newly created
+// entity and reports whether the create should be answered with 202 Accepted
+func (s *Server) scheduleAsyncComplete(entityName, id string) bool {
+	config, after := s.validator.loader.AsyncComplete()
+	if config == nil || config.Entity != entityName {
+		return false
+	}
+	scheduler, ok := s.store.(storage.Scheduler)
+	if !ok {
+		log.Printf("asyncComplete: store cannot schedule updates; %s %s will not complete", entityName, id)
+		return false
+	}
+	scheduler.SchedulePatch(entityName, id, after, map[string]interface{}{config.Field: config.Value})
+	return true
+}
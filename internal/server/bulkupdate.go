@@ -0,0 +1,80 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/ticktockbent/ape_my/internal/schema"
+	"github.com/ticktockbent/ape_my/internal/storage"
+)
+
+// BulkUpdateResponse is the body of a successful PATCH on a collection
+type BulkUpdateResponse struct {
+	Updated int `json:"updated"`
+}
+
+// handlePatchWhere handles PATCH /entities?field=value - apply one partial
+// update to every entity matching the equality filters. The body is
+// validated once; at least one filter is required so a bare PATCH cannot
+// rewrite the whole collection.
+func (s *Server) handlePatchWhere(entityName string, w http.ResponseWriter, r *http.Request) {
+	opts := s.buildQueryOpts(entityName, r)
+	if len(opts.Conditions) > 0 {
+		s.respondError(w, http.StatusBadRequest, "Bulk updates only support equality filters")
+		return
+	}
+	if len(opts.Filters) == 0 {
+		s.respondError(w, http.StatusBadRequest, "Bulk updates need at least one filter")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+	defer r.Body.Close()
+
+	var data map[string]interface{}
+	if err := schema.DecodeJSON(body, &data); err != nil {
+		s.respondError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	data, ok := s.unwrapJSONAPI(w, entityName, data)
+	if !ok {
+		return
+	}
+	data = s.coerceFields(entityName, s.inboundFields(entityName, data))
+
+	if err := s.validator.ValidateFieldCount(entityName, data); err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.validator.ValidatePatch(entityName, data); err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if field := s.writeOnceField(entityName, data); field != "" {
+		s.respondError(w, http.StatusConflict, fmt.Sprintf("Field %q is write-once and cannot be changed", field))
+		return
+	}
+
+	updated, err := s.store.PatchWhere(entityName, opts.Filters, data)
+	if err != nil {
+		var conflict *storage.VersionConflictError
+		if err == storage.ErrEntityTypeNotFound {
+			s.respondError(w, http.StatusNotFound, "Entity type not found")
+		} else if errors.As(err, &conflict) {
+			s.respondError(w, http.StatusConflict, fmt.Sprintf("Version conflict: entity is at version %d", conflict.Current))
+		} else {
+			log.Printf("Error bulk patching entities: %v", err)
+			s.respondError(w, http.StatusInternalServerError, "Failed to patch entities")
+		}
+		return
+	}
+
+	s.respondJSON(w, http.StatusOK, BulkUpdateResponse{Updated: updated})
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPatchWhere(t *testing.T) {
+	srv := setupTestServerWithOptions(t, Options{AllowBulkUpdate: true})
+	srv.store.Seed("users", []map[string]interface{}{
+		{"id": "1", "name": "Alice", "email": "alice@example.com", "age": 20},
+		{"id": "2", "name": "Bob", "email": "bob@example.com", "age": 30},
+		{"id": "3", "name": "Carol", "email": "carol@example.com", "age": 20},
+	})
+
+	tests := []struct {
+		name        string
+		query       string
+		body        string
+		wantStatus  int
+		wantUpdated int
+	}{
+		{"matching filter", "?age=20", `{"name": "Twenty"}`, http.StatusOK, 2},
+		{"no matches", "?age=99", `{"name": "Nobody"}`, http.StatusOK, 0},
+		{"no filter", "", `{"name": "Everyone"}`, http.StatusBadRequest, 0},
+		{"operator filter", "?age_gte=20", `{"name": "Everyone"}`, http.StatusBadRequest, 0},
+		{"invalid body", "?age=20", `{"name": 5}`, http.StatusBadRequest, 0},
+		{"malformed body", "?age=20", `{`, http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/users"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp BulkUpdateResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Updated != tt.wantUpdated {
+				t.Errorf("updated = %d, want %d", resp.Updated, tt.wantUpdated)
+			}
+		})
+	}
+
+	for id, want := range map[string]string{"1": "Twenty", "2": "Bob", "3": "Twenty"} {
+		if entity, _ := srv.store.Get("users", id); entity["name"] != want {
+			t.Errorf("user %s name = %v, want %v", id, entity["name"], want)
+		}
+	}
+}
+
+func TestPatchWhereRequiresFlag(t *testing.T) {
+	srv := setupTestServer(t)
+
+	req := httptest.NewRequest(http.MethodPatch, "/users?name=Alice", strings.NewReader(`{"name": "Alicia"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCapturedBody caps how many bytes of each body are written to the capture file
+const maxCapturedBody = 64 * 1024
+
+// captureWriter serializes NDJSON capture records onto a shared writer
+type captureWriter struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// CaptureRecord is one line of the --capture file: a request and the
+// response it got
+type CaptureRecord struct {
+	Time       time.Time       `json:"time"`
+	DurationMs float64         `json:"durationMs"`
+	Request    CapturedRequest `json:"request"`
+	Response   CapturedReply   `json:"response"`
+}
+
+// CapturedRequest is the request half of a CaptureRecord
+type CapturedRequest struct {
+	Method  string              `json:"method"`
+	Path    string              `json:"path"` // includes the query string
+	Headers map[string][]string `json:"headers"`
+	Body    interface{}         `json:"body,omitempty"`
+}
+
+// CapturedReply is the response half of a CaptureRecord
+type CapturedReply struct {
+	Status  int                 `json:"status"`
+	Headers map[string][]string `json:"headers"`
+	Body    interface{}         `json:"body,omitempty"`
+}
+
+// newCaptureWriter returns nil when there is nowhere to capture to
+func newCaptureWriter(out io.Writer) *captureWriter {
+	if out == nil {
+		return nil
+	}
+	return &captureWriter{out: out}
+}
+
+// write appends a record as a single JSON line
+func (c *captureWriter) write(record CaptureRecord) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		log.Printf("Error encoding capture record: %v", err)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.out.Write(append(line, '\n')); err != nil {
+		log.Printf("Error writing capture record: %v", err)
+	}
+}
+
+// captureRequestBody reads up to maxCapturedBody bytes of the request body
+// for the capture file, leaving the full body in place for the handler
+func (s *Server) captureRequestBody(r *http.Request) []byte {
+	if s.capture == nil || r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	head, err := io.ReadAll(io.LimitReader(r.Body, maxCapturedBody+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), r.Body), r.Body}
+	if err != nil {
+		return nil
+	}
+	return head
+}
+
+// captureExchange writes the request and the recorded response to the
+// capture file, if one is configured
+func (s *Server) captureExchange(r *http.Request, requestBody []byte, rec *statusRecorder, start time.Time) {
+	if s.capture == nil {
+		return
+	}
+	s.capture.write(CaptureRecord{
+		Time:       start.UTC(),
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		Request: CapturedRequest{
+			Method:  r.Method,
+			Path:    r.URL.RequestURI(),
+			Headers: s.visibleHeaders(r.Header),
+			Body:    capturedBody(requestBody),
+		},
+		Response: CapturedReply{
+			Status:  rec.status,
+			Headers: s.visibleHeaders(rec.Header()),
+			Body:    capturedBody(rec.body.Bytes()),
+		},
+	})
+}
+
+// capturedBody keeps a JSON body as JSON and anything else (including a
+// body cut at maxCapturedBody) as a string
+func capturedBody(body []byte) interface{} {
+	if len(body) == 0 {
+		return nil
+	}
+	if len(body) > maxCapturedBody {
+		return string(body[:maxCapturedBody]) + "...(truncated)"
+	}
+	if json.Valid(body) {
+		return json.RawMessage(body)
+	}
+	return string(body)
+}
+
+// visibleHeaders copies headers, replacing credentials with [REDACTED]
+// unless verbose logging is on
+func (s *Server) visibleHeaders(header http.Header) map[string][]string {
+	headers := make(map[string][]string, len(header))
+	for name, values := range header {
+		if redactedHeaders[strings.ToLower(name)] && s.options.LogLevel != LogVerbose {
+			values = []string{"[REDACTED]"}
+		}
+		headers[name] = values
+	}
+	return headers
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCapture(t *testing.T) {
+	var out bytes.Buffer
+	srv := setupTestServerWithOptions(t, Options{Capture: &out})
+
+	req := httptest.NewRequest(http.MethodPost, "/users?trace=1", strings.NewReader(`{"name": "Alice", "email": "alice@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST status = %d, want %d, body: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+
+	srv.mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/404", http.NoBody))
+	srv.mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/__routes", http.NoBody))
+
+	var records []CaptureRecord
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var record CaptureRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("capture line is not JSON: %v: %s", err, scanner.Text())
+		}
+		records = append(records, record)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d capture records, want 3", len(records))
+	}
+
+	create := records[0]
+	if create.Request.Method != http.MethodPost || create.Request.Path != "/users?trace=1" {
+		t.Errorf("request = %s %s, want POST /users?trace=1", create.Request.Method, create.Request.Path)
+	}
+	if got := create.Request.Headers["Authorization"]; len(got) != 1 || got[0] != "[REDACTED]" {
+		t.Errorf("Authorization = %v, want redacted", got)
+	}
+	if body, _ := create.Request.Body.(map[string]interface{}); body["name"] != "Alice" {
+		t.Errorf("request body = %v, want the posted JSON", create.Request.Body)
+	}
+	if create.Response.Status != http.StatusCreated {
+		t.Errorf("response status = %d, want %d", create.Response.Status, http.StatusCreated)
+	}
+	if body, _ := create.Response.Body.(map[string]interface{}); body["id"] == nil {
+		t.Errorf("response body = %v, want the created entity", create.Response.Body)
+	}
+
+	if records[1].Response.Status != http.StatusNotFound {
+		t.Errorf("second record status = %d, want %d", records[1].Response.Status, http.StatusNotFound)
+	}
+	if records[2].Request.Path != "/__routes" {
+		t.Errorf("third record path = %q, want /__routes", records[2].Request.Path)
+	}
+}
+
+func TestCaptureKeepsFullRequestBody(t *testing.T) {
+	var out bytes.Buffer
+	srv := setupTestServerWithOptions(t, Options{Capture: &out, Debug: true})
+
+	long := strings.Repeat("x", maxCapturedBody+10)
+	req := httptest.NewRequest(http.MethodPost, "/__echo", strings.NewReader(long))
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+
+	var echo EchoResponse
+	if err := json.NewDecoder(w.Body).Decode(&echo); err != nil {
+		t.Fatalf("decode echo: %v", err)
+	}
+	if got, _ := echo.Body.(string); got != long {
+		t.Errorf("handler saw %d bytes, want the full %d", len(got), len(long))
+	}
+
+	var record CaptureRecord
+	if err := json.NewDecoder(&out).Decode(&record); err != nil {
+		t.Fatalf("decode capture: %v", err)
+	}
+	body, _ := record.Request.Body.(string)
+	if !strings.HasSuffix(body, "...(truncated)") || len(body) != maxCapturedBody+len("...(truncated)") {
+		t.Errorf("captured request body has %d bytes, want it cut at %d", len(body), maxCapturedBody)
+	}
+}
@@ -0,0 +1,28 @@
+package server
+
+import "github.com/ticktockbent/ape_my/pkg/types"
+
+// coerceFields converts string values in a request body to the declared
+// number, integer or boolean type when the schema enables coerce. Values
+// that don't convert are left as strings for validation to reject.
+func (s *Server) coerceFields(entityName string, data map[string]interface{}) map[string]interface{} {
+	if s.schema == nil || !s.schema.Coerce || data == nil {
+		return data
+	}
+	entity, exists := s.schema.Entities[entityName]
+	if !exists {
+		return data
+	}
+	for name, value := range data {
+		raw, isString := value.(string)
+		field := entity.Fields[name]
+		if !isString || field == nil {
+			continue
+		}
+		switch field.Type {
+		case types.FieldTypeNumber, types.FieldTypeInteger, types.FieldTypeBoolean:
+			data[name] = coerceFormValue(field, []string{raw})
+		}
+	}
+	return data
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCoerceFields(t *testing.T) {
+	schemaFor := func(coerce bool) string {
+		flag := "false"
+		if coerce {
+			flag = "true"
+		}
+		return `{
+			"coerce": ` + flag + `,
+			"entities": {
+				"users": {
+					"fields": {
+						"id":     {"type": "string", "required": true},
+						"name":   {"type": "string", "required": true},
+						"age":    {"type": "integer"},
+						"score":  {"type": "number"},
+						"active": {"type": "boolean"}
+					}
+				}
+			}
+		}`
+	}
+
+	tests := []struct {
+		name       string
+		coerce     bool
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "strings coerced",
+			coerce:     true,
+			body:       `{"name": "42", "age": "30", "score": "9.5", "active": "true"}`,
+			wantStatus: http.StatusCreated,
+			wantBody:   `"active":true,"age":30,"id":"1","name":"42","score":9.5`,
+		},
+		{
+			name:       "typed values untouched",
+			coerce:     true,
+			body:       `{"name": "Alice", "age": 30, "active": false}`,
+			wantStatus: http.StatusCreated,
+			wantBody:   `"active":false,"age":30`,
+		},
+		{
+			name:       "unconvertible value rejected",
+			coerce:     true,
+			body:       `{"name": "Alice", "age": "abc"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "strict by default",
+			coerce:     false,
+			body:       `{"name": "Alice", "age": "30"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := setupTestServerWithSchema(t, schemaFor(tt.coerce))
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"github.com/ticktockbent/ape_my/internal/schema"
+	"github.com/ticktockbent/ape_my/internal/storage"
+)
+
+// ConfigureStore applies the schema's store settings: versioning, history,
+// starting ids and indexes for each entity, and the consistency lag. Entity
+// types that are not in the schema are left alone. Stores without a
+// storage.Configurer ignore it.
+func ConfigureStore(store storage.Store, loader *schema.Loader) {
+	configurer, ok := store.(storage.Configurer)
+	if !ok {
+		return
+	}
+	for _, entityName := range loader.GetEntityNames() {
+		entity, _ := loader.GetEntity(entityName)
+		configurer.Configure(entityName, storage.EntitySettings{
+			Versioned:    entity.Versioning,
+			TrackHistory: entity.TrackHistory,
+			StartID:      entity.StartID,
+			Indexes:      entity.Indexes,
+		})
+	}
+	configurer.SetConsistencyLag(loader.ConsistencyLag())
+}
+
+// clearStoreSettings turns off the store settings of an entity type the
+// schema no longer has
+func clearStoreSettings(store storage.Store, entityName string) {
+	if configurer, ok := store.(storage.Configurer); ok {
+		configurer.Configure(entityName, storage.EntitySettings{})
+	}
+}
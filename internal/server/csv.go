@@ -0,0 +1,83 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+)
+
+// CSV output is selected with ?format=csv or Accept: text/csv
+const (
+	formatParam  = "format"
+	csvFormat    = "csv"
+	csvMediaType = "text/csv"
+)
+
+// wantsCSV reports whether the client asked for the list as CSV
+func wantsCSV(r *http.Request) bool {
+	if r.URL.Query().Get(formatParam) == csvFormat {
+		return true
+	}
+	return acceptsMediaType(r, csvMediaType)
+}
+
+// respondCSV writes entities as CSV. The header row is the union of their
+// field names, id first and the rest sorted; objects and arrays are
+// JSON-encoded in their cells and missing or null values are left empty.
+func (s *Server) respondCSV(w http.ResponseWriter, entityName string, items []map[string]interface{}) {
+	shaped := s.shapeEntities(entityName, items)
+	columns := csvColumns(shaped)
+
+	w.Header().Set("Content-Type", csvMediaType+"; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, entityName))
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.Write(columns)
+	row := make([]string, len(columns))
+	for _, item := range shaped {
+		for i, column := range columns {
+			row[i] = csvCell(item[column])
+		}
+		writer.Write(row)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		// Headers are already sent, so the best we can do is log
+		log.Printf("Error writing CSV response: %v", err)
+	}
+}
+
+// csvColumns returns the union of the entities' keys, id first then sorted
+func csvColumns(items []map[string]interface{}) []string {
+	seen := map[string]bool{"id": true}
+	var rest []string
+	for _, item := range items {
+		for key := range item {
+			if !seen[key] {
+				seen[key] = true
+				rest = append(rest, key)
+			}
+		}
+	}
+	sort.Strings(rest)
+	return append([]string{"id"}, rest...)
+}
+
+// csvCell renders one value: strings as-is, null as empty, everything else as JSON
+func csvCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(encoded)
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestListCSV(t *testing.T) {
+	srv := setupTestServer(t)
+	srv.store.Create("users", map[string]interface{}{"name": "Alice", "email": "alice@example.com", "tags": []interface{}{"a", "b"}})
+	srv.store.Create("users", map[string]interface{}{"name": "Bob, Jr.", "email": "bob@example.com", "age": 42})
+	srv.store.Create("users", map[string]interface{}{"name": "Carol", "email": "carol@example.com"})
+
+	tests := []struct {
+		name   string
+		path   string
+		accept string
+	}{
+		{name: "format param", path: "/users?format=csv&name_ne=Carol"},
+		{name: "accept header", path: "/users?name_ne=Carol", accept: "text/csv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, http.NoBody)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+				t.Errorf("Content-Type = %q, want text/csv", ct)
+			}
+			if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="users.csv"` {
+				t.Errorf("Content-Disposition = %q", cd)
+			}
+
+			records, err := csv.NewReader(w.Body).ReadAll()
+			if err != nil {
+				t.Fatalf("invalid CSV: %v", err)
+			}
+			want := [][]string{
+				{"id", "age", "email", "name", "tags"},
+				{"1", "", "alice@example.com", "Alice", `["a","b"]`},
+				{"2", "42", "bob@example.com", "Bob, Jr.", ""},
+			}
+			if !reflect.DeepEqual(records, want) {
+				t.Errorf("CSV = %v, want %v", records, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+// debugIndexField reports, under --debug, where a created entity sits in its
+// collection's unfiltered list order. It is added to the response only.
+const debugIndexField = "_index"
+
+// withDebugIndex returns a copy of the created entity carrying its zero-based
+// position in the default list order, or the entity itself if it is not
+// listed yet (e.g. while consistency lag hides it)
+func (s *Server) withDebugIndex(entityName string, entity map[string]interface{}) map[string]interface{} {
+	result, err := s.store.ListQuery(entityName, types.QueryOpts{})
+	if err != nil {
+		return entity
+	}
+	for i, item := range result.Items {
+		if item["id"] == entity["id"] {
+			indexed := copyEntity(entity)
+			indexed[debugIndexField] = i
+			return indexed
+		}
+	}
+	return entity
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugIndex(t *testing.T) {
+	create := func(srv *Server, id string) map[string]interface{} {
+		body := `{"id": "` + id + `", "name": "User", "email": "user@example.com"}`
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+		}
+		var entity map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&entity)
+		return entity
+	}
+
+	srv := setupTestServerWithOptions(t, Options{Debug: true})
+	for _, tt := range []struct {
+		id        string
+		wantIndex float64
+	}{
+		{"b", 0},
+		{"d", 1},
+		{"a", 0},
+		{"c", 2},
+	} {
+		if entity := create(srv, tt.id); entity[debugIndexField] != tt.wantIndex {
+			t.Errorf("created %s: _index = %v, want %v", tt.id, entity[debugIndexField], tt.wantIndex)
+		}
+	}
+
+	stored, _ := srv.store.Get("users", "c")
+	if _, leaked := stored[debugIndexField]; leaked {
+		t.Errorf("stored entity = %v, want no %s", stored, debugIndexField)
+	}
+
+	plain := setupTestServerWithOptions(t, Options{})
+	if entity := create(plain, "a"); entity[debugIndexField] != nil {
+		t.Errorf("without --debug: _index = %v, want none", entity[debugIndexField])
+	}
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/ticktockbent/ape_my/internal/schema"
+)
+
+// withDeprecation adds the RFC 8594 Deprecation and Sunset headers to every
+// response of a deprecated route. The sunset was validated at load time.
+func withDeprecation(deprecated bool, sunset string, next http.HandlerFunc) http.HandlerFunc {
+	if !deprecated {
+		return next
+	}
+	sunsetHeader := ""
+	if sunset != "" {
+		if t, err := schema.ParseSunset(sunset); err == nil {
+			sunsetHeader = t.UTC().Format(http.TimeFormat)
+		}
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		if sunsetHeader != "" {
+			w.Header().Set("Sunset", sunsetHeader)
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDeprecationHeaders(t *testing.T) {
+	schemaJSON := `{
+		"entities": {
+			"legacy": {
+				"deprecated": true,
+				"sunset": "2025-12-31",
+				"fields": {
+					"id":   {"type": "string", "required": true},
+					"name": {"type": "string", "required": true}
+				}
+			},
+			"users": {
+				"fields": {
+					"id":   {"type": "string", "required": true},
+					"name": {"type": "string", "required": true}
+				}
+			}
+		},
+		"routes": [
+			{"method": "GET", "path": "/old-users/:id", "entity": "users", "filters": {"id": "id"}, "deprecated": true},
+			{"method": "GET", "path": "/member/:id", "entity": "users", "filters": {"id": "id"}}
+		]
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+	srv.store.Seed("legacy", []map[string]interface{}{{"id": "1", "name": "Old"}})
+	srv.store.Seed("users", []map[string]interface{}{{"id": "1", "name": "Alice"}})
+
+	tests := []struct {
+		path            string
+		wantDeprecation string
+		wantSunset      string
+	}{
+		{"/legacy", "true", "Wed, 31 Dec 2025 00:00:00 GMT"},
+		{"/legacy/1", "true", "Wed, 31 Dec 2025 00:00:00 GMT"},
+		{"/legacy/missing", "true", "Wed, 31 Dec 2025 00:00:00 GMT"},
+		{"/old-users/1", "true", ""},
+		{"/member/1", "", ""},
+		{"/users", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, http.NoBody)
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Deprecation"); got != tt.wantDeprecation {
+				t.Errorf("Deprecation = %q, want %q", got, tt.wantDeprecation)
+			}
+			if got := w.Header().Get("Sunset"); got != tt.wantSunset {
+				t.Errorf("Sunset = %q, want %q", got, tt.wantSunset)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/ticktockbent/ape_my/internal/storage"
+)
+
+// dryRunParam is the query parameter that makes a write validate and answer
+// with the would-be entity without storing it
+const dryRunParam = "dryRun"
+
+// dryRun reports whether the request asks for a dry run
+func dryRun(r *http.Request) bool {
+	return r.URL.Query().Get(dryRunParam) == "true"
+}
+
+// previewCreate returns the entity a create would store and the status it
+// would be answered with, or storage.ErrIDTaken for an id already in use. No
+// id is reserved, so the entity has one only if the client sent it.
+func (s *Server) previewCreate(entityName string, data map[string]interface{}) (map[string]interface{}, int, error) {
+	if id, ok := data["id"].(string); ok {
+		if _, err := s.getWritten(entityName, id); err == nil {
+			return nil, 0, storage.ErrIDTaken
+		}
+	}
+	entity := copyEntity(data)
+	if s.versioned(entityName) {
+		entity[storage.VersionField] = 1
+	}
+	if config, _ := s.validator.loader.AsyncComplete(); config != nil && config.Entity == entityName {
+		return entity, http.StatusAccepted, nil
+	}
+	return entity, http.StatusCreated, nil
+}
+
+// previewWrite returns the entity a PUT (replace) or PATCH would leave
+// behind, with the store's not-found and version checks applied
+func (s *Server) previewWrite(entityName, id string, data map[string]interface{}, replace bool) (map[string]interface{}, error) {
+	current, err := s.getWritten(entityName, id)
+	if err != nil {
+		return nil, err
+	}
+
+	version := 0
+	if s.versioned(entityName) {
+		if version, err = storage.NextVersion(current, data); err != nil {
+			return nil, err
+		}
+	}
+
+	entity := current
+	if replace {
+		entity = copyEntity(data)
+	} else {
+		for key, value := range data {
+			if key != "id" {
+				entity[key] = value
+			}
+		}
+	}
+	entity["id"] = id
+	if version > 0 {
+		entity[storage.VersionField] = version
+	}
+	return entity, nil
+}
+
+// versioned reports whether the entity declares versioning
+func (s *Server) versioned(entityName string) bool {
+	entity, exists := s.validator.loader.GetEntity(entityName)
+	return exists && entity.Versioning
+}
+
+// copyEntity returns a shallow copy of data
+func copyEntity(data map[string]interface{}) map[string]interface{} {
+	entity := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		entity[key] = value
+	}
+	return entity
+}
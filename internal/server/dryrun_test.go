@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	srv := setupTestServerWithSchema(t, `{
+		"entities": {
+			"todos": {
+				"fields": {
+					"id":     {"type": "string", "required": true},
+					"task":   {"type": "string", "required": true},
+					"status": {"type": "string", "default": "open"}
+				}
+			}
+		}
+	}`)
+
+	send := func(method, path, body string) (int, map[string]interface{}) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		var entity map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&entity)
+		return w.Code, entity
+	}
+
+	if status, _ := send(http.MethodPost, "/todos", `{"task": "Existing"}`); status != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d", status, http.StatusCreated)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		want       map[string]interface{}
+	}{
+		{"create", http.MethodPost, "/todos?dryRun=true", `{"task": "New"}`, http.StatusCreated, map[string]interface{}{"task": "New", "status": "open"}},
+		{"invalid create", http.MethodPost, "/todos?dryRun=true", `{"status": "done"}`, http.StatusBadRequest, nil},
+		{"put", http.MethodPut, "/todos/1?dryRun=true", `{"task": "Replaced"}`, http.StatusOK, map[string]interface{}{"id": "1", "task": "Replaced"}},
+		{"patch", http.MethodPatch, "/todos/1?dryRun=true", `{"status": "done"}`, http.StatusOK, map[string]interface{}{"id": "1", "task": "Existing", "status": "done"}},
+		{"invalid patch", http.MethodPatch, "/todos/1?dryRun=true", `{"status": 5}`, http.StatusBadRequest, nil},
+		{"patch missing entity", http.MethodPatch, "/todos/9?dryRun=true", `{"status": "done"}`, http.StatusNotFound, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, entity := send(tt.method, tt.path, tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %v", status, tt.wantStatus, entity)
+			}
+			if tt.want == nil {
+				return
+			}
+			if len(entity) != len(tt.want) {
+				t.Errorf("entity = %v, want %v", entity, tt.want)
+			}
+			for key, want := range tt.want {
+				if entity[key] != want {
+					t.Errorf("%s = %v, want %v", key, entity[key], want)
+				}
+			}
+		})
+	}
+
+	// Nothing was written, and the next real create gets the next id
+	status, stored := send(http.MethodGet, "/todos/1", "")
+	if status != http.StatusOK || stored["task"] != "Existing" || stored["status"] != "open" {
+		t.Errorf("stored entity = %v (%d), want it unchanged", stored, status)
+	}
+	if _, created := send(http.MethodPost, "/todos", `{"task": "Second"}`); created["id"] != "2" {
+		t.Errorf("next created id = %v, want 2", created["id"])
+	}
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+
+	"github.com/ticktockbent/ape_my/internal/storage"
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+// collectionETag returns a weak ETag for a list result. Versioned entities
+// are hashed by id and version, others by their full content, in result
+// order and together with the page position, so the tag changes whenever
+// any listed entity, the filter's matches or the page does.
+func (s *Server) collectionETag(entityName string, result *types.QueryResult) string {
+	versioned := false
+	if s.schema != nil {
+		if entity, exists := s.schema.Entities[entityName]; exists {
+			versioned = entity.Versioning
+		}
+	}
+
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%d|%d|%d|%s\n", result.TotalCount, result.Offset, result.Limit, result.NextCursor)
+	for _, item := range result.Items {
+		if versioned && item != nil {
+			fmt.Fprintf(hash, "%v@%v\n", item["id"], item[storage.VersionField])
+			continue
+		}
+		// json.Marshal sorts map keys, so equal entities hash the same
+		raw, _ := json.Marshal(item)
+		hash.Write(raw)
+		hash.Write([]byte{'\n'})
+	}
+	return fmt.Sprintf(`W/"%x"`, hash.Sum64())
+}
+
+// etagMatches reports whether an If-None-Match header names etag, comparing
+// weakly as RFC 9110 requires for If-None-Match
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// respondIfNotModified sets the collection ETag and answers 304 when the
+// request's If-None-Match already has it, reporting whether it did
+func (s *Server) respondIfNotModified(w http.ResponseWriter, r *http.Request, entityName string, result *types.QueryResult) bool {
+	etag := s.collectionETag(entityName, result)
+	w.Header().Set("ETag", etag)
+	if !etagMatches(r.Header.Get("If-None-Match"), etag) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCollectionETag(t *testing.T) {
+	srv := setupTestServerWithSchema(t, `{
+		"pagination": {"style": "offset", "defaultLimit": 1},
+		"entities": {
+			"users": {
+				"fields": {
+					"id":   {"type": "string", "required": true},
+					"name": {"type": "string", "required": true}
+				}
+			}
+		}
+	}`)
+	srv.store.Seed("users", []map[string]interface{}{
+		{"id": "1", "name": "Alice"},
+		{"id": "2", "name": "Bob"},
+	})
+
+	get := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		return w
+	}
+
+	first := get("/users", "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("status = %d, ETag = %q, want 200 with an ETag", first.Code, etag)
+	}
+
+	w := get("/users", etag)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("status with matching If-None-Match = %d, want %d", w.Code, http.StatusNotModified)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("304 body = %q, want empty", w.Body.String())
+	}
+	if w := get("/users", `"other", `+strings.TrimPrefix(etag, "W/")); w.Code != http.StatusNotModified {
+		t.Errorf("status with strong form in a list = %d, want %d", w.Code, http.StatusNotModified)
+	}
+
+	// Each page has its own tag
+	if next := get("/users?offset=1", "").Header().Get("ETag"); next == etag {
+		t.Errorf("second page ETag = first page ETag %q", etag)
+	}
+
+	// Changing a listed entity changes the tag
+	req := httptest.NewRequest(http.MethodPatch, "/users/1", strings.NewReader(`{"name": "Alicia"}`))
+	req.Header.Set("Content-Type", "application/json")
+	srv.mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	w = get("/users", etag)
+	if w.Code != http.StatusOK {
+		t.Errorf("status after update = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w.Header().Get("ETag") == etag {
+		t.Error("ETag did not change after an update")
+	}
+}
@@ -0,0 +1,66 @@
+package server
+
+import "github.com/ticktockbent/ape_my/internal/schema"
+
+// fieldCase returns the schema's fieldCase style, or "" when names are used as declared
+func (s *Server) fieldCase() string {
+	if s.schema == nil {
+		return ""
+	}
+	return s.schema.FieldCase
+}
+
+// internalFieldName maps an external (request) field name to the name declared
+// in the schema. Names that don't match a field are returned unchanged.
+func (s *Server) internalFieldName(entityName, name string) string {
+	style := s.fieldCase()
+	if style == "" {
+		return name
+	}
+	entity, exists := s.schema.Entities[entityName]
+	if !exists {
+		return name
+	}
+	if _, declared := entity.Fields[name]; declared && schema.ConvertFieldCase(style, name) == name {
+		return name
+	}
+	for fieldName := range entity.Fields {
+		if schema.ConvertFieldCase(style, fieldName) == name {
+			return fieldName
+		}
+	}
+	return name
+}
+
+// inboundFields renames the keys of a request body to the schema's field names
+func (s *Server) inboundFields(entityName string, data map[string]interface{}) map[string]interface{} {
+	if s.fieldCase() == "" || data == nil {
+		return data
+	}
+	renamed := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		renamed[s.internalFieldName(entityName, key)] = value
+	}
+	return renamed
+}
+
+// outboundFields renames the schema's field names in a response entity to the
+// configured fieldCase; keys that aren't schema fields pass through
+func (s *Server) outboundFields(entityName string, entity map[string]interface{}) map[string]interface{} {
+	style := s.fieldCase()
+	if style == "" || entity == nil {
+		return entity
+	}
+	def, exists := s.schema.Entities[entityName]
+	if !exists {
+		return entity
+	}
+	renamed := make(map[string]interface{}, len(entity))
+	for key, value := range entity {
+		if _, declared := def.Fields[key]; declared {
+			key = schema.ConvertFieldCase(style, key)
+		}
+		renamed[key] = value
+	}
+	return renamed
+}
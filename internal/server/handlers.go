@@ -2,12 +2,17 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/ticktockbent/ape_my/internal/schema"
 	"github.com/ticktockbent/ape_my/internal/storage"
 	"github.com/ticktockbent/ape_my/pkg/types"
 )
@@ -27,6 +32,12 @@ func (s *Server) handleCollection(entityName, collectionPath string) http.Handle
 			s.handleCreate(entityName, w, r)
 		case http.MethodGet:
 			s.handleList(entityName, w, r)
+		case http.MethodPatch:
+			if !s.options.AllowBulkUpdate {
+				s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+				return
+			}
+			s.handlePatchWhere(entityName, w, r)
 		default:
 			s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		}
@@ -43,15 +54,33 @@ func (s *Server) handleItem(entityName, collectionPath string) http.HandlerFunc
 			return
 		}
 
-		id := strings.TrimPrefix(r.URL.Path, prefix)
-		if id == "" || strings.Contains(id, "/") {
+		// GET /entities/123/address/city reads a nested value by JSON Pointer;
+		// POST and DELETE below an item change array fields
+		id, subPath, nested := strings.Cut(strings.TrimPrefix(r.URL.Path, prefix), "/")
+		if id == "" {
 			s.respondError(w, http.StatusNotFound, "Route not found")
 			return
 		}
+		if nested && r.Method != http.MethodGet {
+			if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+				s.respondError(w, http.StatusNotFound, "Route not found")
+				return
+			}
+			s.handleArrayItem(entityName, id, subPath, w, r)
+			return
+		}
 
 		switch r.Method {
 		case http.MethodGet:
-			s.handleGetOne(entityName, id, w, r)
+			if subPath == schema.HistoryPath && s.tracksHistory(entityName) {
+				s.handleHistory(entityName, id, w, r)
+				return
+			}
+			pointer := r.URL.Query().Get(pointerParam)
+			if nested {
+				pointer = "/" + subPath
+			}
+			s.handleGetOne(entityName, id, pointer, w, r)
 		case http.MethodPut:
 			s.handleUpdate(entityName, id, w, r)
 		case http.MethodPatch:
@@ -66,19 +95,47 @@ func (s *Server) handleItem(entityName, collectionPath string) http.HandlerFunc
 
 // handleCreate handles POST /entities - Create new entity
 func (s *Server) handleCreate(entityName string, w http.ResponseWriter, r *http.Request) {
-	// Parse request body
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		s.respondError(w, http.StatusBadRequest, "Failed to read request body")
-		return
+	// Replay the original entity if this idempotency key was already used
+	idempotencyKey := r.Header.Get(idempotencyHeader)
+	if idempotencyKey != "" {
+		if id, status, seen := s.recordedCreate(entityName, idempotencyKey); seen {
+			s.respondCreated(w, r, entityName, id, status, true)
+			return
+		}
 	}
-	defer r.Body.Close()
 
+	// Parse request body: JSON, or multipart/form-data for file-like fields
 	var data map[string]interface{}
-	if err := json.Unmarshal(body, &data); err != nil {
-		s.respondError(w, http.StatusBadRequest, "Invalid JSON")
+	if isMultipartForm(r) {
+		formData, err := s.parseMultipartEntity(entityName, r)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		data = formData
+	} else {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, "Failed to read request body")
+			return
+		}
+		defer r.Body.Close()
+
+		if err := schema.DecodeJSON(body, &data); err != nil {
+			s.respondError(w, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+		var ok bool
+		if data, ok = s.unwrapJSONAPI(w, entityName, data); !ok {
+			return
+		}
+	}
+	data = s.coerceFields(entityName, s.inboundFields(entityName, data))
+	if err := s.validator.ValidateFieldCount(entityName, data); err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	slugFields := s.applySlugs(entityName, data)
 
 	// Validate against schema
 	if err := s.validator.ValidateCreate(entityName, data); err != nil {
@@ -86,48 +143,298 @@ func (s *Server) handleCreate(entityName string, w http.ResponseWriter, r *http.
 		return
 	}
 
+	// Fill in omitted fields from schema defaults
+	if entity, exists := s.validator.loader.GetEntity(entityName); exists {
+		schema.ApplyDefaults(entity, data, time.Now())
+	}
+
+	if dryRun(r) {
+		entity, status, err := s.previewCreate(entityName, data)
+		if err != nil {
+			s.respondCreateError(w, err, data)
+			return
+		}
+		s.respondSingle(w, r, entityName, status, entity)
+		return
+	}
+
 	// Create entity in storage
-	id, err := s.store.Create(entityName, data)
+	id, status, replayed, err := s.createEntity(entityName, idempotencyKey, data, slugFields)
 	if err != nil {
-		if err == storage.ErrEntityTypeNotFound {
-			s.respondError(w, http.StatusNotFound, "Entity type not found")
-		} else {
-			log.Printf("Error creating entity: %v", err)
-			s.respondError(w, http.StatusInternalServerError, "Failed to create entity")
-		}
+		s.respondCreateError(w, err, data)
 		return
 	}
+	s.respondCreated(w, r, entityName, id, status, replayed)
+}
+
+// createEntity stores a new entity and returns its id and the status to
+// answer with: 202 Accepted for async job entities, which complete later,
+// and 201 Created for the rest. With an idempotency key, requests carrying
+// the same key are serialized from lookup to record, and a key already used
+// returns the entity it created with replayed set. Generated slugFields are
+// chosen again under the entity's slug lock, so two creates cannot both
+// claim the same slug.
+func (s *Server) createEntity(entityName, idempotencyKey string, data map[string]interface{}, slugFields []string) (id string, status int, replayed bool, err error) {
+	if idempotencyKey != "" {
+		unlock := s.idempotency.lock(entityName, idempotencyKey)
+		defer unlock()
+		if id, status, seen := s.recordedCreate(entityName, idempotencyKey); seen {
+			return id, status, true, nil
+		}
+	}
+	if len(slugFields) > 0 {
+		unlock := s.slugLocks.lock(entityName)
+		defer unlock()
+		for _, field := range slugFields {
+			delete(data, field)
+		}
+		s.applySlugs(entityName, data)
+	}
 
-	// Get the created entity to return it
-	entity, err := s.store.Get(entityName, id)
+	id, err = s.store.Create(entityName, data)
+	if err != nil {
+		return "", 0, false, err
+	}
+	status = http.StatusCreated
+	if s.scheduleAsyncComplete(entityName, id) {
+		status = http.StatusAccepted
+	}
+	if idempotencyKey != "" {
+		s.idempotency.Record(entityName, idempotencyKey, id, status)
+	}
+	return id, status, false, nil
+}
+
+// recordedCreate returns the id and status recorded for an idempotency key.
+// A key whose entity has since been deleted is treated as fresh.
+func (s *Server) recordedCreate(entityName, idempotencyKey string) (string, int, bool) {
+	id, status, seen := s.idempotency.Lookup(entityName, idempotencyKey)
+	if !seen {
+		return "", 0, false
+	}
+	if _, err := s.getWritten(entityName, id); err != nil {
+		return "", 0, false
+	}
+	return id, status, true
+}
+
+// respondCreated answers a create with the stored entity
+func (s *Server) respondCreated(w http.ResponseWriter, r *http.Request, entityName, id string, status int, replayed bool) {
+	entity, err := s.getWritten(entityName, id)
 	if err != nil {
 		log.Printf("Error retrieving created entity: %v", err)
 		s.respondError(w, http.StatusInternalServerError, "Entity created but failed to retrieve")
 		return
 	}
+	if replayed {
+		w.Header().Set(replayedHeader, "true")
+	} else if s.options.Debug {
+		entity = s.withDebugIndex(entityName, entity)
+	}
+	s.respondSingle(w, r, entityName, status, entity)
+}
+
+// respondCreateError answers a create the store refused
+func (s *Server) respondCreateError(w http.ResponseWriter, err error, data map[string]interface{}) {
+	switch err {
+	case storage.ErrEntityTypeNotFound:
+		s.respondError(w, http.StatusNotFound, "Entity type not found")
+	case storage.ErrInvalidID:
+		s.respondError(w, http.StatusBadRequest, err.Error())
+	case storage.ErrIDTaken:
+		s.respondError(w, http.StatusConflict, fmt.Sprintf("Entity with id %q already exists", data["id"]))
+	default:
+		log.Printf("Error creating entity: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "Failed to create entity")
+	}
+}
 
-	// Return 201 Created with the entity
-	s.respondSingle(w, http.StatusCreated, entity)
+// getWritten reads back an entity the handler just wrote. Stores simulating
+// replication lag would otherwise hide it from the writer itself.
+func (s *Server) getWritten(entityName, id string) (map[string]interface{}, error) {
+	if latest, ok := s.store.(storage.LatestGetter); ok {
+		return latest.GetLatest(entityName, id)
+	}
+	return s.store.Get(entityName, id)
 }
 
 // handleList handles GET /entities - List all entities with optional filtering and pagination
 func (s *Server) handleList(entityName string, w http.ResponseWriter, r *http.Request) {
+	// Batch fetch by id replaces filtering and pagination
+	if idList := r.URL.Query().Get(idsParam); idList != "" {
+		s.handleListByIDs(entityName, idList, w, r)
+		return
+	}
+
+	// Accept can switch the list to NDJSON or CSV
+	addVary(w, "Accept")
+
 	// Build query options from request query parameters
 	opts := s.buildQueryOpts(entityName, r)
 
+	// A Range header replaces query-param pagination
+	itemRange, hasRange, err := parseItemsRange(r.Header.Get("Range"))
+	if err != nil {
+		s.respondError(w, http.StatusRequestedRangeNotSatisfiable, "Invalid Range header: "+err.Error())
+		return
+	}
+	if hasRange {
+		opts.Cursor = ""
+		opts.Offset = itemRange.start
+		opts.Limit = itemRange.limit()
+	}
+
+	// Stream one entity per line when the client asks for NDJSON, straight
+	// from the store when it can iterate
+	if wantsNDJSON(r) && !hasRange {
+		if iterator, ok := s.store.(storage.Iterator); ok {
+			s.streamNDJSON(w, entityName, opts, iterator)
+			return
+		}
+	}
+
 	result, err := s.store.ListQuery(entityName, opts)
+	if err != nil {
+		s.respondListError(w, err)
+		return
+	}
+
+	if hasRange && result.TotalCount > 0 {
+		s.respondRange(w, r, entityName, itemRange, result)
+		return
+	}
+
+	// Serve the schema's example payload while the store is empty
+	if result.TotalCount == 0 {
+		if example := s.emptyExample(entityName); example != nil {
+			w.Header().Set(exampleHeader, "true")
+			result = &types.QueryResult{Items: example, TotalCount: len(example)}
+		}
+	}
+
+	// Stream one entity per line when the client asks for NDJSON
+	if wantsNDJSON(r) {
+		s.respondNDJSON(w, entityName, result.Items)
+		return
+	}
+
+	if wantsCSV(r) {
+		s.respondCSV(w, entityName, result.Items)
+		return
+	}
+
+	// Build response using wrapper if configured, or return raw list
+	s.respondList(w, r, entityName, result)
+}
+
+// respondListError answers a list the store could not run
+func (s *Server) respondListError(w http.ResponseWriter, err error) {
+	if err == storage.ErrEntityTypeNotFound {
+		s.respondError(w, http.StatusNotFound, "Entity type not found")
+		return
+	}
+	log.Printf("Error listing entities: %v", err)
+	s.respondError(w, http.StatusInternalServerError, "Failed to list entities")
+}
+
+// exampleHeader marks list responses served from exampleWhenEmpty
+const exampleHeader = "X-Ape-Example"
+
+// Query parameters for batch fetches (?ids=1,2,3&includeMissing=true)
+const (
+	idsParam            = "ids"
+	includeMissingParam = "includeMissing"
+)
+
+// handleListByIDs returns the entities named in a comma-separated id list, in
+// the order given. Unknown ids are omitted, or returned as null when
+// includeMissing=true.
+func (s *Server) handleListByIDs(entityName, idList string, w http.ResponseWriter, r *http.Request) {
+	var ids []string
+	for _, id := range strings.Split(idList, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	entities, err := s.store.GetMany(entityName, ids)
 	if err != nil {
 		if err == storage.ErrEntityTypeNotFound {
 			s.respondError(w, http.StatusNotFound, "Entity type not found")
 		} else {
-			log.Printf("Error listing entities: %v", err)
-			s.respondError(w, http.StatusInternalServerError, "Failed to list entities")
+			log.Printf("Error fetching entities: %v", err)
+			s.respondError(w, http.StatusInternalServerError, "Failed to fetch entities")
 		}
 		return
 	}
 
-	// Build response using wrapper if configured, or return raw list
-	s.respondList(w, entityName, result)
+	includeMissing, _ := strconv.ParseBool(r.URL.Query().Get(includeMissingParam))
+	items := make([]map[string]interface{}, 0, len(entities))
+	for _, entity := range entities {
+		if entity != nil || includeMissing {
+			items = append(items, entity)
+		}
+	}
+
+	s.respondList(w, r, entityName, &types.QueryResult{Items: items, TotalCount: len(items)})
+}
+
+// emptyExample returns the entity's exampleWhenEmpty payload if one is
+// configured and the store holds no entities of that type
+func (s *Server) emptyExample(entityName string) []map[string]interface{} {
+	if s.schema == nil {
+		return nil
+	}
+	entity, exists := s.schema.Entities[entityName]
+	if !exists || len(entity.ExampleWhenEmpty) == 0 {
+		return nil
+	}
+	// A filtered query can be empty while the store is not
+	if all, err := s.store.List(entityName); err != nil || len(all) > 0 {
+		return nil
+	}
+	return entity.ExampleWhenEmpty
+}
+
+// pageStyle is the page-number pagination style (?page=2&per_page=20)
+const pageStyle = "page"
+
+// reservedQueryParams are query parameters that never act as filters
+var reservedQueryParams = map[string]bool{
+	apiVersionParam:     true,
+	idsParam:            true,
+	includeMissingParam: true,
+	"limit":             true,
+	"offset":            true,
+	"cursor":            true,
+	"page":              true,
+	"per_page":          true,
+	formatParam:         true,
+}
+
+// filterOperators lists the supported ?field_op= suffixes
+var filterOperators = map[string]bool{
+	types.FilterOpLike: true,
+	types.FilterOpNe:   true,
+	types.FilterOpGt:   true,
+	types.FilterOpGte:  true,
+	types.FilterOpLt:   true,
+	types.FilterOpLte:  true,
+	types.FilterOpIn:   true,
+}
+
+// splitFilterOperator splits a query key such as "age_gte" into its field and
+// operator. Field names may contain underscores; the operator is the last part.
+func splitFilterOperator(key string) (string, string, bool) {
+	idx := strings.LastIndex(key, "_")
+	if idx <= 0 {
+		return "", "", false
+	}
+	field, op := key[:idx], key[idx+1:]
+	if !filterOperators[op] {
+		return "", "", false
+	}
+	return field, op, true
 }
 
 // buildQueryOpts extracts filtering and pagination parameters from the request
@@ -139,10 +446,19 @@ func (s *Server) buildQueryOpts(entityName string, r *http.Request) types.QueryO
 	// Get valid field names for this entity to filter query params
 	validFields := s.getEntityFieldNames(entityName)
 
-	// Extract filter params — only use params that match entity field names
+	// Extract filter params — only use params that match entity field names,
+	// either bare (equality) or with an operator suffix such as age_gte
 	for key, values := range r.URL.Query() {
-		if validFields[key] && key != "limit" && key != "offset" && key != "cursor" {
-			opts.Filters[key] = values[0]
+		if reservedQueryParams[key] {
+			continue
+		}
+		if field := s.internalFieldName(entityName, key); validFields[field] {
+			opts.Filters[field] = values[0]
+			continue
+		}
+		if field, op, ok := splitFilterOperator(key); ok && validFields[s.internalFieldName(entityName, field)] {
+			field = s.internalFieldName(entityName, field)
+			opts.Conditions = append(opts.Conditions, types.FilterCondition{Field: field, Op: op, Value: values[0]})
 		}
 	}
 
@@ -156,8 +472,12 @@ func (s *Server) buildQueryOpts(entityName string, r *http.Request) types.QueryO
 			opts.Limit = 20 // fallback default
 		}
 
-		// Parse limit from query
-		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		// Parse limit from query (per_page for page-number pagination)
+		limitParam := "limit"
+		if pagConfig.Style == pageStyle {
+			limitParam = "per_page"
+		}
+		if limitStr := r.URL.Query().Get(limitParam); limitStr != "" {
 			if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
 				opts.Limit = limit
 			}
@@ -171,6 +491,14 @@ func (s *Server) buildQueryOpts(entityName string, r *http.Request) types.QueryO
 		// Parse style-specific params
 		if pagConfig.Style == "cursor" {
 			opts.Cursor = r.URL.Query().Get("cursor")
+		} else if pagConfig.Style == pageStyle {
+			page := 1
+			if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+				if n, err := strconv.Atoi(pageStr); err == nil && n > 0 {
+					page = n
+				}
+			}
+			opts.Offset = (page - 1) * opts.Limit
 		} else {
 			if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
 				if offset, err := strconv.Atoi(offsetStr); err == nil && offset >= 0 {
@@ -199,8 +527,9 @@ func (s *Server) getEntityFieldNames(entityName string) map[string]bool {
 	return fields
 }
 
-// handleGetOne handles GET /entities/{id} - Get single entity
-func (s *Server) handleGetOne(entityName, id string, w http.ResponseWriter, r *http.Request) {
+// handleGetOne handles GET /entities/{id} - Get single entity, or the value at
+// pointer within it when one is given
+func (s *Server) handleGetOne(entityName, id, pointer string, w http.ResponseWriter, r *http.Request) {
 	entity, err := s.store.Get(entityName, id)
 	if err != nil {
 		if err == storage.ErrNotFound {
@@ -214,8 +543,13 @@ func (s *Server) handleGetOne(entityName, id string, w http.ResponseWriter, r *h
 		return
 	}
 
+	if pointer != "" {
+		s.respondPointer(w, entityName, entity, pointer)
+		return
+	}
+
 	// Return 200 OK with the entity
-	s.respondSingle(w, http.StatusOK, entity)
+	s.respondSingle(w, r, entityName, http.StatusOK, entity)
 }
 
 // handleUpdate handles PUT /entities/{id} - Replace entire entity
@@ -229,10 +563,24 @@ func (s *Server) handleUpdate(entityName string, id string, w http.ResponseWrite
 	defer r.Body.Close()
 
 	var data map[string]interface{}
-	if err := json.Unmarshal(body, &data); err != nil {
+	if err := schema.DecodeJSON(body, &data); err != nil {
 		s.respondError(w, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
+	data, ok := s.unwrapJSONAPI(w, entityName, data)
+	if !ok {
+		return
+	}
+	data = s.coerceFields(entityName, s.inboundFields(entityName, data))
+	if err := s.validator.ValidateFieldCount(entityName, data); err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if field, ok := s.checkWriteOnce(entityName, id, data, true); !ok {
+		s.respondError(w, http.StatusConflict, fmt.Sprintf("Field %q is write-once and cannot be changed", field))
+		return
+	}
 
 	// Validate against schema
 	if err := s.validator.ValidateUpdate(entityName, data); err != nil {
@@ -240,13 +588,21 @@ func (s *Server) handleUpdate(entityName string, id string, w http.ResponseWrite
 		return
 	}
 
-	// Update entity in storage
-	err = s.store.Update(entityName, id, data)
+	// Update entity in storage, or only work out the result on a dry run
+	var preview map[string]interface{}
+	if dryRun(r) {
+		preview, err = s.previewWrite(entityName, id, data, true)
+	} else {
+		err = s.store.Update(entityName, id, data)
+	}
 	if err != nil {
+		var conflict *storage.VersionConflictError
 		if err == storage.ErrNotFound {
 			s.respondError(w, http.StatusNotFound, "Entity not found")
 		} else if err == storage.ErrEntityTypeNotFound {
 			s.respondError(w, http.StatusNotFound, "Entity type not found")
+		} else if errors.As(err, &conflict) {
+			s.respondError(w, http.StatusConflict, fmt.Sprintf("Version conflict: entity is at version %d", conflict.Current))
 		} else {
 			log.Printf("Error updating entity: %v", err)
 			s.respondError(w, http.StatusInternalServerError, "Failed to update entity")
@@ -254,8 +610,17 @@ func (s *Server) handleUpdate(entityName string, id string, w http.ResponseWrite
 		return
 	}
 
+	if s.schema != nil && s.schema.PutReturnsNoContent {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if preview != nil {
+		s.respondSingle(w, r, entityName, http.StatusOK, preview)
+		return
+	}
+
 	// Get the updated entity to return it
-	entity, err := s.store.Get(entityName, id)
+	entity, err := s.getWritten(entityName, id)
 	if err != nil {
 		log.Printf("Error retrieving updated entity: %v", err)
 		s.respondError(w, http.StatusInternalServerError, "Entity updated but failed to retrieve")
@@ -263,7 +628,7 @@ func (s *Server) handleUpdate(entityName string, id string, w http.ResponseWrite
 	}
 
 	// Return 200 OK with the updated entity
-	s.respondSingle(w, http.StatusOK, entity)
+	s.respondSingle(w, r, entityName, http.StatusOK, entity)
 }
 
 // handlePatch handles PATCH /entities/{id} - Partially update entity
@@ -277,10 +642,24 @@ func (s *Server) handlePatch(entityName string, id string, w http.ResponseWriter
 	defer r.Body.Close()
 
 	var data map[string]interface{}
-	if err := json.Unmarshal(body, &data); err != nil {
+	if err := schema.DecodeJSON(body, &data); err != nil {
 		s.respondError(w, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
+	data, ok := s.unwrapJSONAPI(w, entityName, data)
+	if !ok {
+		return
+	}
+	data = s.coerceFields(entityName, s.inboundFields(entityName, data))
+	if err := s.validator.ValidateFieldCount(entityName, data); err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if field, ok := s.checkWriteOnce(entityName, id, data, false); !ok {
+		s.respondError(w, http.StatusConflict, fmt.Sprintf("Field %q is write-once and cannot be changed", field))
+		return
+	}
 
 	// Validate against schema (PATCH doesn't require all required fields)
 	if err := s.validator.ValidatePatch(entityName, data); err != nil {
@@ -288,13 +667,21 @@ func (s *Server) handlePatch(entityName string, id string, w http.ResponseWriter
 		return
 	}
 
-	// Patch entity in storage
-	err = s.store.Patch(entityName, id, data)
+	// Patch entity in storage, or only work out the result on a dry run
+	var preview map[string]interface{}
+	if dryRun(r) {
+		preview, err = s.previewWrite(entityName, id, data, false)
+	} else {
+		err = s.store.Patch(entityName, id, data)
+	}
 	if err != nil {
+		var conflict *storage.VersionConflictError
 		if err == storage.ErrNotFound {
 			s.respondError(w, http.StatusNotFound, "Entity not found")
 		} else if err == storage.ErrEntityTypeNotFound {
 			s.respondError(w, http.StatusNotFound, "Entity type not found")
+		} else if errors.As(err, &conflict) {
+			s.respondError(w, http.StatusConflict, fmt.Sprintf("Version conflict: entity is at version %d", conflict.Current))
 		} else {
 			log.Printf("Error patching entity: %v", err)
 			s.respondError(w, http.StatusInternalServerError, "Failed to patch entity")
@@ -302,8 +689,17 @@ func (s *Server) handlePatch(entityName string, id string, w http.ResponseWriter
 		return
 	}
 
+	if s.schema != nil && s.schema.PatchReturnsNoContent {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if preview != nil {
+		s.respondSingle(w, r, entityName, http.StatusOK, preview)
+		return
+	}
+
 	// Get the patched entity to return it
-	entity, err := s.store.Get(entityName, id)
+	entity, err := s.getWritten(entityName, id)
 	if err != nil {
 		log.Printf("Error retrieving patched entity: %v", err)
 		s.respondError(w, http.StatusInternalServerError, "Entity patched but failed to retrieve")
@@ -311,12 +707,12 @@ func (s *Server) handlePatch(entityName string, id string, w http.ResponseWriter
 	}
 
 	// Return 200 OK with the patched entity
-	s.respondSingle(w, http.StatusOK, entity)
+	s.respondSingle(w, r, entityName, http.StatusOK, entity)
 }
 
 // handleDelete handles DELETE /entities/{id} - Delete entity
 func (s *Server) handleDelete(entityName, id string, w http.ResponseWriter, r *http.Request) {
-	err := s.store.Delete(entityName, id)
+	deleted, err := s.store.Remove(entityName, id)
 	if err != nil {
 		if err == storage.ErrNotFound {
 			s.respondError(w, http.StatusNotFound, "Entity not found")
@@ -329,6 +725,12 @@ func (s *Server) handleDelete(entityName, id string, w http.ResponseWriter, r *h
 		return
 	}
 
+	// Echo the deleted record for APIs whose clients rely on it
+	if s.schema != nil && s.schema.DeleteReturnsEntity {
+		s.respondSingle(w, r, entityName, http.StatusOK, deleted)
+		return
+	}
+
 	// Return 204 No Content (successful deletion)
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -336,7 +738,7 @@ func (s *Server) handleDelete(entityName, id string, w http.ResponseWriter, r *h
 // handleCustomRoute handles custom route patterns with path parameter extraction
 func (s *Server) handleCustomRoute(route *types.CustomRoute) http.HandlerFunc {
 	// Extract parameter names from the original :param path pattern
-	paramNames := extractParamNames(route.Path)
+	paramNames := schema.PathParams(route.Path)
 	paramSet := make(map[string]bool, len(paramNames))
 	for _, name := range paramNames {
 		paramSet[name] = true
@@ -382,14 +784,81 @@ func (s *Server) handleCustomRoute(route *types.CustomRoute) http.HandlerFunc {
 			return
 		}
 
+		// Routes marked single always answer with one object
+		if route.Single {
+			switch len(result.Items) {
+			case 0:
+				s.respondError(w, http.StatusNotFound, "Entity not found")
+			case 1:
+				s.respondSingle(w, r, route.Entity, http.StatusOK, result.Items[0])
+			default:
+				s.respondError(w, http.StatusConflict, fmt.Sprintf("Route matches %d entities, expected exactly one", len(result.Items)))
+			}
+			return
+		}
+
 		// If filters would match a single entity, return single response
 		if len(result.Items) == 1 && hasIDFilter(filters) {
-			s.respondSingle(w, http.StatusOK, result.Items[0])
+			s.respondSingle(w, r, route.Entity, http.StatusOK, result.Items[0])
 			return
 		}
 
-		s.respondList(w, route.Entity, result)
+		s.respondList(w, r, route.Entity, result)
+	}
+}
+
+// handleFixedResponse answers a route declared with a literal response,
+// writing the payload exactly as it appears in the schema. When the route
+// lists conditional responses, the first whose match fits the request body
+// wins; the route's own response is the fallback.
+func handleFixedResponse(route *types.CustomRoute) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, status := route.Response, route.Status
+		if len(route.Responses) > 0 {
+			if candidate := matchRouteResponse(route.Responses, r); candidate != nil {
+				body, status = candidate.Response, candidate.Status
+			}
+		}
+		if status == 0 {
+			status = http.StatusOK
+		}
+		w.WriteHeader(status)
+		if status != http.StatusNoContent && status != http.StatusNotModified {
+			w.Write(body)
+		}
+	}
+}
+
+// matchRouteResponse returns the first response whose match fields all equal
+// the request body's top-level fields, or nil. A body that is not a JSON
+// object matches nothing.
+func matchRouteResponse(responses []types.RouteResponse, r *http.Request) *types.RouteResponse {
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil
+	}
+	for i := range responses {
+		if bodyMatches(body, responses[i].Match) {
+			return &responses[i]
+		}
+	}
+	return nil
+}
+
+// bodyMatches reports whether every match field is present in body with an
+// equal value
+func bodyMatches(body, match map[string]interface{}) bool {
+	for field, want := range match {
+		got, exists := body[field]
+		if !exists || !reflect.DeepEqual(got, want) {
+			return false
+		}
 	}
+	return true
 }
 
 // hasIDFilter checks if the filter set targets a specific entity by ID
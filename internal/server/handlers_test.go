@@ -2,12 +2,14 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/ticktockbent/ape_my/internal/storage"
 )
@@ -588,3 +590,688 @@ func TestConcurrentHTTPRequests(t *testing.T) {
 	t.Logf("Concurrent test stats: Creates=%d, Reads=%d, Updates=%d",
 		successfulCreates.Load(), successfulReads.Load(), successfulUpdates.Load())
 }
+
+func TestLargeIntegerRoundTrip(t *testing.T) {
+	server := setupTestServer(t)
+
+	// 2^53 + 1 cannot be represented exactly as float64
+	body := `{"name": "Big", "age": 9007199254740993}`
+	req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d, body: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	if !bytes.Contains(w.Body.Bytes(), []byte(`"age":9007199254740993`)) {
+		t.Errorf("create response lost precision: %s", w.Body.String())
+	}
+
+	// Filtering by the exact value matches the stored number
+	req = httptest.NewRequest(http.MethodGet, "/users?age=9007199254740993", http.NoBody)
+	w = httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+
+	var users []map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&users)
+	if len(users) != 1 {
+		t.Errorf("filter by large integer: got %d results, want 1", len(users))
+	}
+}
+
+func TestExampleWhenEmpty(t *testing.T) {
+	schemaJSON := `{
+		"entities": {
+			"users": {
+				"fields": {
+					"id":   {"type": "string", "required": true},
+					"name": {"type": "string", "required": true}
+				},
+				"exampleWhenEmpty": [
+					{"id": "example-1", "name": "Example User"}
+				]
+			},
+			"posts": {
+				"fields": {
+					"id":    {"type": "string", "required": true},
+					"title": {"type": "string", "required": true}
+				}
+			}
+		}
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+
+	get := func(path string) (*httptest.ResponseRecorder, []map[string]interface{}) {
+		req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		var items []map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &items)
+		return w, items
+	}
+
+	// Empty store serves the example, marked by header
+	w, items := get("/users")
+	if len(items) != 1 || items[0]["id"] != "example-1" {
+		t.Errorf("expected example payload, got %v", items)
+	}
+	if w.Header().Get("X-Ape-Example") != "true" {
+		t.Error("expected X-Ape-Example header on example response")
+	}
+
+	// Entities without an example keep returning []
+	w, items = get("/posts")
+	if len(items) != 0 || w.Header().Get("X-Ape-Example") != "" {
+		t.Errorf("expected plain empty list for posts, got %v", items)
+	}
+
+	// Real data replaces the example; empty filtered results stay empty
+	srv.store.Seed("users", []map[string]interface{}{{"id": "1", "name": "Alice"}})
+	w, items = get("/users")
+	if len(items) != 1 || items[0]["id"] != "1" || w.Header().Get("X-Ape-Example") != "" {
+		t.Errorf("expected real data, got %v", items)
+	}
+	_, items = get("/users?name=Nobody")
+	if len(items) != 0 {
+		t.Errorf("expected empty filtered result, got %v", items)
+	}
+}
+
+func TestConsistencyLagHidesRecentWrites(t *testing.T) {
+	server := setupTestServer(t)
+	server.store.(*storage.InMemoryStore).SetConsistencyLag(time.Hour)
+
+	body := `{"name": "Lagged", "email": "lag@example.com"}`
+	req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+
+	// The writer still gets the created entity back
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d, body: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	var created map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&created)
+	id, _ := created["id"].(string)
+	if id == "" {
+		t.Fatalf("expected created entity in response, got %v", created)
+	}
+
+	// Readers don't see it yet
+	req = httptest.NewRequest(http.MethodGet, "/users/"+id, http.NoBody)
+	w = httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("GET during lag: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestCreateAppliesDefaults(t *testing.T) {
+	schemaJSON := `{
+		"entities": {
+			"tickets": {
+				"fields": {
+					"id":        {"type": "string", "required": true},
+					"title":     {"type": "string", "required": true},
+					"status":    {"type": "string", "required": true, "default": "new"},
+					"createdAt": {"type": "datetime", "default": "{{now}}"}
+				}
+			}
+		}
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+
+	create := func(body string) map[string]interface{} {
+		req := httptest.NewRequest(http.MethodPost, "/tickets", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("status = %d, want %d, body: %s", w.Code, http.StatusCreated, w.Body.String())
+		}
+		var created map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&created)
+		return created
+	}
+
+	created := create(`{"title": "Broken login"}`)
+	if created["status"] != "new" {
+		t.Errorf("status = %v, want default %q", created["status"], "new")
+	}
+	createdAt, _ := created["createdAt"].(string)
+	if _, err := time.Parse(time.RFC3339, createdAt); err != nil {
+		t.Errorf("createdAt = %q, want an RFC3339 timestamp", createdAt)
+	}
+
+	// Supplied values win over defaults
+	created = create(`{"title": "Typo", "status": "triaged", "createdAt": "2020-01-01T00:00:00Z"}`)
+	if created["status"] != "triaged" || created["createdAt"] != "2020-01-01T00:00:00Z" {
+		t.Errorf("expected supplied values kept, got %v", created)
+	}
+}
+
+func TestListFormat(t *testing.T) {
+	schemaJSON := `{
+		"listFormat": "map",
+		"pagination": {"style": "offset"},
+		"entities": {
+			"users": {
+				"fields": {
+					"id":   {"type": "string", "required": true},
+					"role": {"type": "string"}
+				}
+			},
+			"posts": {
+				"listFormat": "array",
+				"fields": {
+					"id": {"type": "string", "required": true}
+				}
+			}
+		}
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+	srv.store.Seed("users", []map[string]interface{}{
+		{"id": "1", "role": "admin"},
+		{"id": "2", "role": "member"},
+		{"id": "3", "role": "member"},
+	})
+	srv.store.Seed("posts", []map[string]interface{}{{"id": "1"}})
+
+	get := func(path string) []byte {
+		req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: status = %d, want %d", path, w.Code, http.StatusOK)
+		}
+		return w.Body.Bytes()
+	}
+
+	// Map format keys items by id, after filtering
+	var users map[string]map[string]interface{}
+	if err := json.Unmarshal(get("/users?role=member"), &users); err != nil {
+		t.Fatalf("expected an object response: %v", err)
+	}
+	if len(users) != 2 || users["2"]["role"] != "member" || users["3"] == nil {
+		t.Errorf("unexpected map response: %v", users)
+	}
+
+	// Pagination still applies inside the envelope
+	var page struct {
+		Data map[string]map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(get("/users?limit=1&offset=1"), &page); err != nil {
+		t.Fatalf("expected an envelope with object data: %v", err)
+	}
+	if len(page.Data) != 1 || page.Data["2"] == nil {
+		t.Errorf("unexpected paginated map data: %v", page.Data)
+	}
+
+	// Entity-level setting overrides the schema default
+	var posts []map[string]interface{}
+	if err := json.Unmarshal(get("/posts"), &posts); err != nil || len(posts) != 1 {
+		t.Errorf("expected posts as an array, got %s", get("/posts"))
+	}
+}
+
+func TestWriteReturnsNoContent(t *testing.T) {
+	schemaJSON := `{
+		"putReturnsNoContent": true,
+		"patchReturnsNoContent": true,
+		"entities": {
+			"users": {
+				"fields": {
+					"id":   {"type": "string", "required": true},
+					"name": {"type": "string", "required": true}
+				}
+			}
+		}
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+	srv.store.Seed("users", []map[string]interface{}{{"id": "1", "name": "Alice"}})
+
+	tests := []struct {
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantName   string
+	}{
+		{method: http.MethodPut, path: "/users/1", body: `{"name": "Bob"}`, wantStatus: http.StatusNoContent, wantName: "Bob"},
+		{method: http.MethodPatch, path: "/users/1", body: `{"name": "Carol"}`, wantStatus: http.StatusNoContent, wantName: "Carol"},
+		{method: http.MethodPatch, path: "/users/99", body: `{"name": "Dan"}`, wantStatus: http.StatusNotFound, wantName: "Carol"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusNoContent && w.Body.Len() != 0 {
+				t.Errorf("expected empty body, got %s", w.Body.String())
+			}
+
+			// The write still happened
+			stored, _ := srv.store.Get("users", "1")
+			if stored["name"] != tt.wantName {
+				t.Errorf("stored name = %v, want %q", stored["name"], tt.wantName)
+			}
+		})
+	}
+}
+
+func TestDeleteReturnsEntity(t *testing.T) {
+	schemaJSON := `{
+		"deleteReturnsEntity": true,
+		"entities": {
+			"users": {
+				"fields": {
+					"id":    {"type": "string", "required": true},
+					"name":  {"type": "string", "required": true},
+					"email": {"type": "string", "mask": "email"}
+				}
+			}
+		}
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+	srv.store.Seed("users", []map[string]interface{}{{"id": "1", "name": "Alice", "email": "alice@example.com"}})
+
+	req := httptest.NewRequest(http.MethodDelete, "/users/1", http.NoBody)
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d, body: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var deleted map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&deleted)
+	if deleted["id"] != "1" || deleted["name"] != "Alice" {
+		t.Errorf("expected the deleted entity, got %v", deleted)
+	}
+	if deleted["email"] != "a***@example.com" {
+		t.Errorf("email = %v, want masked output", deleted["email"])
+	}
+	if _, err := srv.store.Get("users", "1"); err == nil {
+		t.Error("entity should be gone after DELETE")
+	}
+
+	// A second delete is still a 404
+	w = httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users/1", http.NoBody))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("second DELETE: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestCreateArrayDefaultsAreNotShared(t *testing.T) {
+	schemaJSON := `{
+		"entities": {
+			"lists": {
+				"fields": {
+					"id":    {"type": "string", "required": true},
+					"items": {"type": "array", "default": []},
+					"meta":  {"type": "object", "default": {"tags": []}}
+				}
+			}
+		}
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+
+	var ids []string
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/lists", bytes.NewBufferString(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("status = %d, want %d, body: %s", w.Code, http.StatusCreated, w.Body.String())
+		}
+		var created map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&created)
+		if items, ok := created["items"].([]interface{}); !ok || len(items) != 0 {
+			t.Fatalf("items = %v, want []", created["items"])
+		}
+		ids = append(ids, created["id"].(string))
+	}
+
+	// Appending to the first entity's defaults must not show up in the second
+	req := httptest.NewRequest(http.MethodPatch, "/lists/"+ids[0], bytes.NewBufferString(`{"items": ["x"], "meta": {"tags": ["y"]}}`))
+	req.Header.Set("Content-Type", "application/json")
+	srv.mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	first, _ := srv.store.Get("lists", ids[0])
+	first["items"] = append(first["items"].([]interface{}), "z")
+	second, _ := srv.store.Get("lists", ids[1])
+	if items := second["items"].([]interface{}); len(items) != 0 {
+		t.Errorf("second entity items = %v, want []", items)
+	}
+	if tags := second["meta"].(map[string]interface{})["tags"].([]interface{}); len(tags) != 0 {
+		t.Errorf("second entity meta.tags = %v, want []", tags)
+	}
+}
+
+func TestVersioningConflict(t *testing.T) {
+	schemaJSON := `{
+		"entities": {
+			"docs": {
+				"versioning": true,
+				"fields": {
+					"id":    {"type": "string", "required": true},
+					"title": {"type": "string", "required": true}
+				}
+			}
+		}
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+	srv.store.(*storage.InMemoryStore).SetVersioned("docs")
+
+	send := func(method, path, body string) (int, map[string]interface{}) {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		var resp map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, resp
+	}
+
+	status, created := send(http.MethodPost, "/docs", `{"title": "Draft"}`)
+	if status != http.StatusCreated || created["version"] != float64(1) {
+		t.Fatalf("create: status %d, body %v, want version 1", status, created)
+	}
+	path := "/docs/" + created["id"].(string)
+
+	status, patched := send(http.MethodPatch, path, `{"title": "Edited", "version": 1}`)
+	if status != http.StatusOK || patched["version"] != float64(2) {
+		t.Fatalf("patch: status %d, body %v, want version 2", status, patched)
+	}
+
+	// A client still holding version 1 loses
+	status, resp := send(http.MethodPut, path, `{"title": "Stale", "version": 1}`)
+	if status != http.StatusConflict {
+		t.Fatalf("stale put: status = %d, want %d", status, http.StatusConflict)
+	}
+	if resp["error"] != "Version conflict: entity is at version 2" {
+		t.Errorf("error = %v", resp["error"])
+	}
+}
+
+func TestListByIDs(t *testing.T) {
+	srv := setupTestServer(t)
+	srv.store.Seed("users", []map[string]interface{}{
+		{"id": "1", "name": "Alice", "email": "alice@example.com"},
+		{"id": "2", "name": "Bob", "email": "bob@example.com"},
+		{"id": "3", "name": "Carol", "email": "carol@example.com"},
+	})
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{name: "requested order", query: "/users?ids=3,1", want: `["Carol","Alice"]`},
+		{name: "missing omitted", query: "/users?ids=2,%209,", want: `["Bob"]`},
+		{name: "missing as null", query: "/users?ids=2,9&includeMissing=true", want: `["Bob",null]`},
+		{name: "other filters ignored", query: "/users?ids=1,2&name=Bob", want: `["Alice","Bob"]`},
+		{name: "composes with select", query: "/users?ids=1&select=$[*].name", want: `["Alice"]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.query, http.NoBody)
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d, body: %s", w.Code, http.StatusOK, w.Body.String())
+			}
+
+			var items []interface{}
+			json.NewDecoder(w.Body).Decode(&items)
+			names := make([]interface{}, len(items))
+			for i, item := range items {
+				if entity, ok := item.(map[string]interface{}); ok {
+					names[i] = entity["name"]
+				} else {
+					names[i] = item
+				}
+			}
+			got, _ := json.Marshal(names)
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldCase(t *testing.T) {
+	schemaJSON := `{
+		"fieldCase": "camel",
+		"entities": {
+			"users": {
+				"fields": {
+					"id":         {"type": "string", "required": true},
+					"first_name": {"type": "string", "required": true},
+					"is_active":  {"type": "boolean"}
+				}
+			}
+		}
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+
+	req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(`{"firstName": "Alice", "isActive": true}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+
+	var created map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if created["firstName"] != "Alice" || created["isActive"] != true {
+		t.Errorf("response = %v, want camelCase keys", created)
+	}
+	if _, exists := created["first_name"]; exists {
+		t.Errorf("response leaked the stored field name: %v", created)
+	}
+
+	// Storage keeps the names declared in the schema
+	stored, err := srv.store.Get("users", created["id"].(string))
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if stored["first_name"] != "Alice" {
+		t.Errorf("stored = %v, want first_name", stored)
+	}
+
+	// Filters accept the external names, with and without operators
+	for _, path := range []string{"/users?firstName=Alice", "/users?firstName_like=ali"} {
+		req = httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		w = httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		var list []map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+			t.Fatalf("GET %s: failed to decode response: %v", path, err)
+		}
+		if len(list) != 1 || list[0]["firstName"] != "Alice" {
+			t.Errorf("GET %s = %v, want the one camelCase user", path, list)
+		}
+	}
+}
+
+func TestAsyncComplete(t *testing.T) {
+	schemaJSON := `{
+		"asyncComplete": {"entity": "jobs", "after": "20ms", "field": "status", "value": "complete"},
+		"entities": {
+			"jobs": {
+				"fields": {
+					"id":     {"type": "string", "required": true},
+					"status": {"type": "string", "default": "pending"}
+				}
+			},
+			"users": {
+				"fields": {
+					"id": {"type": "string", "required": true}
+				}
+			}
+		}
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+
+	create := func(path string) (int, map[string]interface{}) {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		var body map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body
+	}
+
+	code, job := create("/jobs")
+	if code != http.StatusAccepted {
+		t.Fatalf("create job status = %d, want %d", code, http.StatusAccepted)
+	}
+	if job["status"] != "pending" {
+		t.Errorf("status = %v, want pending", job["status"])
+	}
+	if code, _ := create("/users"); code != http.StatusCreated {
+		t.Errorf("create user status = %d, want %d", code, http.StatusCreated)
+	}
+
+	status := func() interface{} {
+		req := httptest.NewRequest(http.MethodGet, "/jobs/"+job["id"].(string), http.NoBody)
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		var body map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return body["status"]
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for status() != "complete" {
+		if time.Now().After(deadline) {
+			t.Fatal("job never completed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Shutdown cancels jobs still waiting
+	_, pending := create("/jobs")
+	srv.Shutdown(context.Background())
+	time.Sleep(50 * time.Millisecond)
+	stored, _ := srv.store.Get("jobs", pending["id"].(string))
+	if stored["status"] != "pending" {
+		t.Errorf("status = %v after shutdown, want pending", stored["status"])
+	}
+}
+
+func TestRandomFields(t *testing.T) {
+	schemaJSON := `{
+		"entities": {
+			"users": {
+				"fields": {
+					"id":       {"type": "string", "required": true},
+					"nickname": {"type": "string", "random": true},
+					"score":    {"type": "integer", "random": true, "min": 1, "max": 5}
+				}
+			}
+		}
+	}`
+	get := func(srv *Server, path string) map[string]interface{} {
+		req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		var body map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("GET %s: failed to decode response: %v", path, err)
+		}
+		return body
+	}
+
+	first := setupTestServerWithSchema(t, schemaJSON)
+	first.store.Seed("users", []map[string]interface{}{{"id": "1"}, {"id": "2", "nickname": "bob"}})
+	second := setupTestServerWithSchema(t, schemaJSON)
+	second.store.Seed("users", []map[string]interface{}{{"id": "1"}})
+
+	user := get(first, "/users/1")
+	nickname, ok := user["nickname"].(string)
+	if !ok || nickname == "" {
+		t.Fatalf("nickname = %v, want a generated string", user["nickname"])
+	}
+	if score, ok := user["score"].(float64); !ok || score < 1 || score > 5 {
+		t.Errorf("score = %v, want an integer from 1 to 5", user["score"])
+	}
+
+	// A separate server generates the same values for the same id
+	if again := get(second, "/users/1"); again["nickname"] != nickname || again["score"] != user["score"] {
+		t.Errorf("second server returned %v, want %v", again, user)
+	}
+
+	// Stored values win over generated ones
+	if other := get(first, "/users/2"); other["nickname"] != "bob" {
+		t.Errorf("nickname = %v, want the stored bob", other["nickname"])
+	}
+
+	// Generated values are not written to storage
+	stored, _ := first.store.Get("users", "1")
+	if _, exists := stored["nickname"]; exists {
+		t.Errorf("stored = %v, want nickname left unset", stored)
+	}
+}
+
+func TestCreateRejectsTakenID(t *testing.T) {
+	srv := setupTestServer(t)
+	srv.store.Create("users", map[string]interface{}{"id": "alice", "name": "Alice", "email": "alice@example.com"})
+
+	for _, path := range []string{"/users", "/users?dryRun=true"} {
+		body := `{"id": "alice", "name": "Mallory", "email": "mallory@example.com"}`
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+
+		if w.Code != http.StatusConflict {
+			t.Errorf("POST %s: status = %d, want %d", path, w.Code, http.StatusConflict)
+		}
+	}
+
+	if user, _ := srv.store.Get("users", "alice"); user["name"] != "Alice" {
+		t.Errorf("user = %v, want the original kept", user)
+	}
+}
+
+func TestCreateRejectsNonStringID(t *testing.T) {
+	srv := setupTestServer(t)
+
+	for _, id := range []string{`123`, `true`, `{"n": 1}`} {
+		body := `{"id": ` + id + `, "name": "Alice", "email": "alice@example.com"}`
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("id %s: status = %d, want %d", id, w.Code, http.StatusBadRequest)
+		}
+		var resp ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.Error != "id must be a string" {
+			t.Errorf("id %s: error = %q, want %q", id, resp.Error, "id must be a string")
+		}
+	}
+
+	users, _ := srv.store.List("users")
+	if len(users) != 0 {
+		t.Errorf("stored %d users, want none", len(users))
+	}
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/ticktockbent/ape_my/internal/storage"
+)
+
+// tracksHistory reports whether the entity sets trackHistory
+func (s *Server) tracksHistory(entityName string) bool {
+	if s.schema == nil {
+		return false
+	}
+	entity, exists := s.schema.Entities[entityName]
+	return exists && entity != nil && entity.TrackHistory
+}
+
+// handleHistory handles GET /entities/123/history - the entity's past states,
+// oldest first. History outlives a deleted entity.
+func (s *Server) handleHistory(entityName, id string, w http.ResponseWriter, r *http.Request) {
+	reader, ok := s.store.(storage.HistoryReader)
+	if !ok {
+		s.respondError(w, http.StatusNotFound, "Entity not found")
+		return
+	}
+	entries, err := reader.History(entityName, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrEntityTypeNotFound) {
+			s.respondError(w, http.StatusNotFound, "Entity not found")
+		} else {
+			log.Printf("Error reading history: %v", err)
+			s.respondError(w, http.StatusInternalServerError, "Failed to read history")
+		}
+		return
+	}
+
+	for i := range entries {
+		entries[i].State = s.shapeEntity(entityName, entries[i].State)
+	}
+	s.respondData(w, r, http.StatusOK, entries)
+}
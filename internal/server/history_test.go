@@ -0,0 +1,81 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ticktockbent/ape_my/internal/storage"
+)
+
+func TestEntityHistory(t *testing.T) {
+	srv := setupTestServerWithSchema(t, `{
+		"entities": {
+			"users": {
+				"trackHistory": true,
+				"fields": {
+					"id":       {"type": "string", "required": true},
+					"name":     {"type": "string", "required": true},
+					"password": {"type": "string", "redact": true}
+				}
+			},
+			"posts": {
+				"fields": {
+					"id":    {"type": "string", "required": true},
+					"title": {"type": "string"}
+				}
+			}
+		}
+	}`)
+	srv.store.(*storage.InMemoryStore).SetTrackHistory("users")
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		return w
+	}
+
+	do(http.MethodPost, "/users", `{"id": "1", "name": "Alice", "password": "hunter2"}`)
+	do(http.MethodPut, "/users/1", `{"name": "Alicia", "password": "hunter2"}`)
+	do(http.MethodPatch, "/users/1", `{"name": "Ally"}`)
+	do(http.MethodDelete, "/users/1", "")
+
+	w := do(http.MethodGet, "/users/1/history", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d, body: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var entries []storage.HistoryEntry
+	if err := json.NewDecoder(w.Body).Decode(&entries); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	wantNames := []string{"Alice", "Alicia", "Ally"}
+	wantActions := []string{storage.HistoryUpdate, storage.HistoryPatch, storage.HistoryDelete}
+	if len(entries) != len(wantNames) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(wantNames))
+	}
+	for i, entry := range entries {
+		if entry.Action != wantActions[i] || entry.State["name"] != wantNames[i] {
+			t.Errorf("entry %d = %s %v, want %s %s", i, entry.Action, entry.State, wantActions[i], wantNames[i])
+		}
+		if _, leaked := entry.State["password"]; leaked {
+			t.Errorf("entry %d includes a redacted field", i)
+		}
+	}
+
+	if w := do(http.MethodGet, "/users/2/history", ""); w.Code != http.StatusNotFound {
+		t.Errorf("unknown id status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := do(http.MethodPost, "/users/1/history", ""); w.Code != http.StatusNotFound {
+		t.Errorf("POST history status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	// Entities without trackHistory have no history route
+	do(http.MethodPost, "/posts", `{"id": "1", "title": "Hello"}`)
+	if w := do(http.MethodGet, "/posts/1/history", ""); w.Code != http.StatusNotFound {
+		t.Errorf("untracked entity status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
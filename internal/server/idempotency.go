@@ -0,0 +1,92 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// idempotencyHeader is the request header carrying a client-supplied idempotency key
+const idempotencyHeader = "Idempotency-Key"
+
+// replayedHeader marks responses that were replayed from an earlier request
+const replayedHeader = "Idempotent-Replayed"
+
+// DefaultIdempotencyTTL is how long an idempotency key is remembered
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// idempotencyEntry records the entity created for a key and the status the
+// create answered with, so a replay answers the same way
+type idempotencyEntry struct {
+	id      string
+	status  int
+	expires time.Time
+}
+
+// idempotencyCache maps (entity, key) to the ID and status of the first
+// request carrying that key. Entries live in memory only and are evicted once their
+// TTL has passed: lazily on lookup, and in a sweep whenever a key is recorded.
+type idempotencyCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]idempotencyEntry
+	now     func() time.Time
+
+	// locks serializes keyed creates per key, so two concurrent requests
+	// with the same key cannot both miss the cache and create duplicates
+	locks keyedLocks
+}
+
+// newIdempotencyCache creates a cache with the given TTL (DefaultIdempotencyTTL if zero)
+func newIdempotencyCache(ttl time.Duration) *idempotencyCache {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	return &idempotencyCache{
+		ttl:     ttl,
+		entries: make(map[string]idempotencyEntry),
+		now:     time.Now,
+	}
+}
+
+// lock waits until no other request holds key and returns the function that
+// releases it. Requests with other keys are not held up.
+func (c *idempotencyCache) lock(entityName, key string) func() {
+	return c.locks.lock(c.cacheKey(entityName, key))
+}
+
+// cacheKey scopes a key to an entity type
+func (c *idempotencyCache) cacheKey(entityName, key string) string {
+	return entityName + "\x00" + key
+}
+
+// Lookup returns the ID and status recorded for a key, if it has not expired
+func (c *idempotencyCache) Lookup(entityName, key string) (string, int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	k := c.cacheKey(entityName, key)
+	entry, exists := c.entries[k]
+	if !exists {
+		return "", 0, false
+	}
+	if !c.now().Before(entry.expires) {
+		delete(c.entries, k)
+		return "", 0, false
+	}
+	return entry.id, entry.status, true
+}
+
+// Record remembers the ID created for a key and the status returned, and
+// evicts expired entries
+func (c *idempotencyCache) Record(entityName, key, id string, status int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for k, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[c.cacheKey(entityName, key)] = idempotencyEntry{id: id, status: status, expires: now.Add(c.ttl)}
+}
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ticktockbent/ape_my/internal/storage"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	srv := setupTestServer(t)
+
+	create := func(path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		return w
+	}
+	idOf := func(w *httptest.ResponseRecorder) string {
+		var entity map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&entity)
+		id, _ := entity["id"].(string)
+		return id
+	}
+
+	userBody := `{"name": "Alice", "email": "alice@example.com"}`
+
+	first := create("/users", "key-1", userBody)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first create: status = %d, body: %s", first.Code, first.Body.String())
+	}
+	if first.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("first create should not be marked as replayed")
+	}
+	firstID := idOf(first)
+
+	// Same key replays the original entity
+	replay := create("/users", "key-1", userBody)
+	if replay.Code != http.StatusCreated {
+		t.Errorf("replay: status = %d, want %d", replay.Code, http.StatusCreated)
+	}
+	if replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("replay should set Idempotent-Replayed header")
+	}
+	if id := idOf(replay); id != firstID {
+		t.Errorf("replay returned id %q, want %q", id, firstID)
+	}
+
+	// Keys are scoped per entity
+	post := create("/posts", "key-1", `{"title": "Hello"}`)
+	if post.Code != http.StatusCreated || post.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("same key on another entity should create: status = %d", post.Code)
+	}
+
+	// Without the header, creates are not deduplicated
+	create("/users", "", userBody)
+	create("/users", "", userBody)
+
+	users, _ := srv.store.List("users")
+	if len(users) != 3 {
+		t.Errorf("got %d users, want 3", len(users))
+	}
+
+	// A deleted original frees the key
+	srv.store.Delete("users", firstID)
+	recreated := create("/users", "key-1", userBody)
+	if recreated.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("key for a deleted entity should not replay")
+	}
+	if id := idOf(recreated); id == firstID || id == "" {
+		t.Errorf("recreated id = %q, want a new id", id)
+	}
+}
+
+func TestIdempotencyReplayKeepsStatus(t *testing.T) {
+	srv := setupTestServerWithSchema(t, `{
+		"asyncComplete": {"entity": "jobs", "after": "1h", "field": "status", "value": "complete"},
+		"entities": {
+			"jobs": {
+				"fields": {
+					"id":     {"type": "string", "required": true},
+					"status": {"type": "string", "default": "pending"}
+				}
+			}
+		}
+	}`)
+	t.Cleanup(srv.store.(storage.Scheduler).CancelScheduled)
+
+	for _, want := range []string{"", "true"} {
+		req := httptest.NewRequest(http.MethodPost, "/jobs", bytes.NewBufferString(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "job-1")
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		if w.Code != http.StatusAccepted {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusAccepted)
+		}
+		if got := w.Header().Get("Idempotent-Replayed"); got != want {
+			t.Errorf("Idempotent-Replayed = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestIdempotencyCacheExpiry(t *testing.T) {
+	cache := newIdempotencyCache(time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	cache.Record("users", "key", "1", http.StatusCreated)
+	if id, status, ok := cache.Lookup("users", "key"); !ok || id != "1" || status != http.StatusCreated {
+		t.Fatalf("Lookup() = %q, %d, %v, want 1, 201, true", id, status, ok)
+	}
+	if _, _, ok := cache.Lookup("posts", "key"); ok {
+		t.Error("key should be scoped to its entity")
+	}
+
+	now = now.Add(time.Minute)
+	if _, _, ok := cache.Lookup("users", "key"); ok {
+		t.Error("expired key should not be found")
+	}
+
+	// Recording sweeps expired entries
+	cache.Record("users", "old", "2", http.StatusCreated)
+	now = now.Add(2 * time.Minute)
+	cache.Record("users", "new", "3", http.StatusCreated)
+	if len(cache.entries) != 1 {
+		t.Errorf("expected expired entries to be swept, got %d entries", len(cache.entries))
+	}
+}
+
+func TestIdempotencyConcurrentSameKey(t *testing.T) {
+	srv := setupTestServer(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(`{"name": "Alice", "email": "alice@example.com"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Idempotency-Key", "key-1")
+			srv.mux.ServeHTTP(httptest.NewRecorder(), req)
+		}()
+	}
+	wg.Wait()
+
+	if users, _ := srv.store.List("users"); len(users) != 1 {
+		t.Errorf("got %d users, want 1", len(users))
+	}
+	if len(srv.idempotency.locks.locks) != 0 {
+		t.Errorf("%d key locks left behind, want none", len(srv.idempotency.locks.locks))
+	}
+}
+
+func TestIdempotencyLockIsPerKey(t *testing.T) {
+	cache := newIdempotencyCache(time.Minute)
+	unlock := cache.lock("users", "a")
+
+	done := make(chan struct{})
+	go func() {
+		cache.lock("users", "b")()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a different key waited for the held one")
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		cache.lock("users", "a")()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("the same key was acquired while held")
+	case <-time.After(20 * time.Millisecond):
+	}
+	unlock()
+	<-acquired
+}
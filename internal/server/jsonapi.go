@@ -0,0 +1,100 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+// jsonAPIMediaType is the Content-Type of JSON:API documents
+const jsonAPIMediaType = "application/vnd.api+json"
+
+// jsonAPI reports whether the schema asks for JSON:API documents
+func (s *Server) jsonAPI() bool {
+	return s.schema != nil && s.schema.Format == types.FormatJSONAPI
+}
+
+// responseMediaType is the Content-Type of API responses
+func (s *Server) responseMediaType() string {
+	if s.jsonAPI() {
+		return jsonAPIMediaType
+	}
+	return "application/json"
+}
+
+// jsonAPIResource turns a shaped entity into a resource object, lifting id
+// out of the attributes
+func jsonAPIResource(entityName string, entity map[string]interface{}) map[string]interface{} {
+	attributes := make(map[string]interface{}, len(entity))
+	for key, value := range entity {
+		if key != "id" {
+			attributes[key] = value
+		}
+	}
+	resource := map[string]interface{}{
+		"type":       entityName,
+		"attributes": attributes,
+	}
+	if id, exists := entity["id"]; exists && id != nil {
+		resource["id"] = fmt.Sprintf("%v", id)
+	}
+	return resource
+}
+
+// jsonAPIResources turns shaped entities into resource objects
+func jsonAPIResources(entityName string, entities []map[string]interface{}) []map[string]interface{} {
+	resources := make([]map[string]interface{}, len(entities))
+	for i, entity := range entities {
+		resources[i] = jsonAPIResource(entityName, entity)
+	}
+	return resources
+}
+
+// jsonAPIErrors builds a JSON:API error document
+func jsonAPIErrors(status int, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"errors": []map[string]interface{}{{
+			"status": strconv.Itoa(status),
+			"title":  http.StatusText(status),
+			"detail": message,
+		}},
+	}
+}
+
+// unwrapJSONAPI extracts the entity from a {"data": {"type", "id",
+// "attributes"}} request document when the schema's format is jsonapi.
+// Otherwise body is returned as is. A body that isn't a resource document is
+// answered 400, and a type other than the collection's 409, reporting false.
+func (s *Server) unwrapJSONAPI(w http.ResponseWriter, entityName string, body map[string]interface{}) (map[string]interface{}, bool) {
+	if !s.jsonAPI() {
+		return body, true
+	}
+
+	resource, ok := body["data"].(map[string]interface{})
+	if !ok {
+		s.respondError(w, http.StatusBadRequest, "Request body must be a JSON:API document with a data object")
+		return nil, false
+	}
+	if resourceType, exists := resource["type"]; exists && resourceType != entityName {
+		s.respondError(w, http.StatusConflict, fmt.Sprintf("Resource type %v does not match collection %q", resourceType, entityName))
+		return nil, false
+	}
+
+	data := map[string]interface{}{}
+	if attributes, exists := resource["attributes"]; exists {
+		fields, ok := attributes.(map[string]interface{})
+		if !ok {
+			s.respondError(w, http.StatusBadRequest, "data.attributes must be an object")
+			return nil, false
+		}
+		for key, value := range fields {
+			data[key] = value
+		}
+	}
+	if id, exists := resource["id"]; exists {
+		data["id"] = id
+	}
+	return data, true
+}
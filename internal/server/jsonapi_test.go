@@ -0,0 +1,154 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const jsonAPISchema = `{
+	"format": "jsonapi",
+	"entities": {
+		"users": {
+			"fields": {
+				"id":   {"type": "string", "required": true},
+				"name": {"type": "string", "required": true}
+			}
+		}
+	}
+}`
+
+func TestJSONAPIResponses(t *testing.T) {
+	srv := setupTestServerWithSchema(t, jsonAPISchema)
+	srv.store.Seed("users", []map[string]interface{}{{"id": "1", "name": "Alice"}})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", http.NoBody)
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+	if got := w.Header().Get("Content-Type"); got != jsonAPIMediaType {
+		t.Errorf("Content-Type = %q, want %q", got, jsonAPIMediaType)
+	}
+	var single struct {
+		Data struct {
+			Type       string                 `json:"type"`
+			ID         string                 `json:"id"`
+			Attributes map[string]interface{} `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&single); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if single.Data.Type != "users" || single.Data.ID != "1" || single.Data.Attributes["name"] != "Alice" {
+		t.Errorf("data = %+v, want users/1 with name Alice", single.Data)
+	}
+	if _, exists := single.Data.Attributes["id"]; exists {
+		t.Error("id should be lifted out of attributes")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/users", http.NoBody)
+	w = httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+	var list struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list.Data) != 1 || list.Data[0]["type"] != "users" || list.Data[0]["id"] != "1" {
+		t.Errorf("list data = %v, want one users resource", list.Data)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/users/missing", http.NoBody)
+	w = httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+	var errs struct {
+		Errors []map[string]string `json:"errors"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&errs); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if w.Code != http.StatusNotFound || len(errs.Errors) != 1 || errs.Errors[0]["status"] != "404" {
+		t.Errorf("status %d, errors = %v, want one 404 error object", w.Code, errs.Errors)
+	}
+}
+
+func TestJSONAPIRequests(t *testing.T) {
+	srv := setupTestServerWithSchema(t, jsonAPISchema)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantName   string
+	}{
+		{
+			name:       "create from resource document",
+			method:     http.MethodPost,
+			path:       "/users",
+			body:       `{"data": {"type": "users", "attributes": {"name": "Alice"}}}`,
+			wantStatus: http.StatusCreated,
+			wantName:   "Alice",
+		},
+		{
+			name:       "patch from resource document",
+			method:     http.MethodPatch,
+			path:       "/users/1",
+			body:       `{"data": {"type": "users", "id": "1", "attributes": {"name": "Alicia"}}}`,
+			wantStatus: http.StatusOK,
+			wantName:   "Alicia",
+		},
+		{
+			name:       "bare entity rejected",
+			method:     http.MethodPost,
+			path:       "/users",
+			body:       `{"name": "Bob"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "wrong resource type",
+			method:     http.MethodPost,
+			path:       "/users",
+			body:       `{"data": {"type": "posts", "attributes": {"name": "Bob"}}}`,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "attributes not an object",
+			method:     http.MethodPost,
+			path:       "/users",
+			body:       `{"data": {"type": "users", "attributes": "Bob"}}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", jsonAPIMediaType)
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantName == "" {
+				return
+			}
+			var document struct {
+				Data struct {
+					ID         string                 `json:"id"`
+					Attributes map[string]interface{} `json:"attributes"`
+				} `json:"data"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&document); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if document.Data.ID != "1" || document.Data.Attributes["name"] != tt.wantName {
+				t.Errorf("data = %+v, want id 1 with name %q", document.Data, tt.wantName)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// segmentKind identifies the type of a single JSONPath step
+type segmentKind int
+
+const (
+	segmentChild    segmentKind = iota // .name or ['name']
+	segmentIndex                       // [n]
+	segmentWildcard                    // .* or [*]
+)
+
+// pathSegment is one step of a parsed JSONPath expression
+type pathSegment struct {
+	kind      segmentKind
+	name      string
+	index     int
+	recursive bool // preceded by ".." (descendant search)
+}
+
+// jsonPath is a parsed JSONPath expression supporting the common subset:
+// $, .name, ['name'], [n], [*], .* and ..name
+type jsonPath struct {
+	segments []pathSegment
+}
+
+// parseJSONPath parses a JSONPath expression such as $.items[*].name
+func parseJSONPath(expr string) (*jsonPath, error) {
+	expr = strings.TrimSpace(expr)
+	if !strings.HasPrefix(expr, "$") {
+		return nil, fmt.Errorf("expression must start with '$'")
+	}
+
+	path := &jsonPath{}
+	i := 1
+	for i < len(expr) {
+		switch expr[i] {
+		case '.':
+			recursive := false
+			i++
+			if i < len(expr) && expr[i] == '.' {
+				recursive = true
+				i++
+			}
+			if i >= len(expr) {
+				return nil, fmt.Errorf("unexpected end of expression after '.'")
+			}
+			if expr[i] == '[' {
+				if !recursive {
+					return nil, fmt.Errorf("unexpected '[' after '.' at position %d", i)
+				}
+				continue
+			}
+			if expr[i] == '*' {
+				path.segments = append(path.segments, pathSegment{kind: segmentWildcard, recursive: recursive})
+				i++
+				continue
+			}
+			start := i
+			for i < len(expr) && expr[i] != '.' && expr[i] != '[' {
+				i++
+			}
+			name := expr[start:i]
+			if name == "" {
+				return nil, fmt.Errorf("empty member name at position %d", start)
+			}
+			path.segments = append(path.segments, pathSegment{kind: segmentChild, name: name, recursive: recursive})
+
+		case '[':
+			end := strings.IndexByte(expr[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated '[' at position %d", i)
+			}
+			segment, err := parseBracket(expr[i+1 : i+end])
+			if err != nil {
+				return nil, err
+			}
+			// A bracket directly after ".." inherits the descendant search
+			if i >= 2 && expr[i-2:i] == ".." {
+				segment.recursive = true
+			}
+			path.segments = append(path.segments, segment)
+			i += end + 1
+
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", expr[i], i)
+		}
+	}
+
+	return path, nil
+}
+
+// parseBracket parses the contents of a [...] selector
+func parseBracket(content string) (pathSegment, error) {
+	content = strings.TrimSpace(content)
+	if content == "*" {
+		return pathSegment{kind: segmentWildcard}, nil
+	}
+	if len(content) >= 2 && (content[0] == '\'' || content[0] == '"') && content[len(content)-1] == content[0] {
+		return pathSegment{kind: segmentChild, name: content[1 : len(content)-1]}, nil
+	}
+	index, err := strconv.Atoi(content)
+	if err != nil {
+		return pathSegment{}, fmt.Errorf("invalid selector [%s]", content)
+	}
+	return pathSegment{kind: segmentIndex, index: index}, nil
+}
+
+// definite reports whether the path can match at most one value
+func (p *jsonPath) definite() bool {
+	for _, segment := range p.segments {
+		if segment.kind == segmentWildcard || segment.recursive {
+			return false
+		}
+	}
+	return true
+}
+
+// Evaluate applies the path to a decoded JSON document. Definite paths return
+// the matched value (or nil), all others return the list of matches.
+func (p *jsonPath) Evaluate(doc interface{}) interface{} {
+	nodes := []interface{}{doc}
+	for _, segment := range p.segments {
+		var next []interface{}
+		for _, node := range nodes {
+			if segment.recursive {
+				for _, descendant := range descendants(node) {
+					next = append(next, applySegment(segment, descendant)...)
+				}
+			} else {
+				next = append(next, applySegment(segment, node)...)
+			}
+		}
+		nodes = next
+	}
+
+	if p.definite() {
+		if len(nodes) == 0 {
+			return nil
+		}
+		return nodes[0]
+	}
+	if nodes == nil {
+		return []interface{}{}
+	}
+	return nodes
+}
+
+// applySegment returns the values selected by a single segment from a node
+func applySegment(segment pathSegment, node interface{}) []interface{} {
+	switch segment.kind {
+	case segmentChild:
+		if obj, ok := node.(map[string]interface{}); ok {
+			if value, exists := obj[segment.name]; exists {
+				return []interface{}{value}
+			}
+		}
+	case segmentIndex:
+		if arr, ok := node.([]interface{}); ok {
+			index := segment.index
+			if index < 0 {
+				index += len(arr)
+			}
+			if index >= 0 && index < len(arr) {
+				return []interface{}{arr[index]}
+			}
+		}
+	case segmentWildcard:
+		switch typed := node.(type) {
+		case map[string]interface{}:
+			values := make([]interface{}, 0, len(typed))
+			for _, key := range sortedKeys(typed) {
+				values = append(values, typed[key])
+			}
+			return values
+		case []interface{}:
+			return append([]interface{}{}, typed...)
+		}
+	}
+	return nil
+}
+
+// descendants returns the node itself followed by all nested values, depth-first
+func descendants(node interface{}) []interface{} {
+	result := []interface{}{node}
+	switch typed := node.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(typed) {
+			result = append(result, descendants(typed[key])...)
+		}
+	case []interface{}:
+		for _, value := range typed {
+			result = append(result, descendants(value)...)
+		}
+	}
+	return result
+}
+
+// sortedKeys returns the keys of a map in sorted order for deterministic output
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		wantErr bool
+	}{
+		{"root only", "$", false},
+		{"dot child", "$.items", false},
+		{"wildcard index", "$.items[*].name", false},
+		{"numeric index", "$.items[0]", false},
+		{"negative index", "$.items[-1]", false},
+		{"quoted child", "$['items']", false},
+		{"recursive descent", "$..name", false},
+		{"missing root", "items", true},
+		{"unterminated bracket", "$.items[0", true},
+		{"bad selector", "$.items[abc]", true},
+		{"trailing dot", "$.items.", true},
+		{"empty member", "$..", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseJSONPath(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseJSONPath(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJSONPathEvaluate(t *testing.T) {
+	doc := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"name": "Alice", "tags": []interface{}{"a", "b"}},
+			map[string]interface{}{"name": "Bob"},
+		},
+		"meta": map[string]interface{}{"count": float64(2)},
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		want interface{}
+	}{
+		{"root", "$", doc},
+		{"definite child", "$.meta.count", float64(2)},
+		{"quoted child", "$['meta']['count']", float64(2)},
+		{"index", "$.items[1].name", "Bob"},
+		{"negative index", "$.items[-1].name", "Bob"},
+		{"missing definite", "$.missing", nil},
+		{"out of range", "$.items[5]", nil},
+		{"wildcard projection", "$.items[*].name", []interface{}{"Alice", "Bob"}},
+		{"recursive descent", "$..name", []interface{}{"Alice", "Bob"}},
+		{"recursive index", "$..tags[0]", []interface{}{"a"}},
+		{"wildcard no match", "$.items[*].missing", []interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := parseJSONPath(tt.expr)
+			if err != nil {
+				t.Fatalf("parseJSONPath(%q) error = %v", tt.expr, err)
+			}
+			got := path.Evaluate(doc)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate(%q) = %#v, want %#v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// pointerParam is the query parameter carrying a JSON Pointer into a single entity
+const pointerParam = "pointer"
+
+// respondPointer writes the value at pointer within the entity as clients see
+// it, or 404 if the pointer doesn't resolve
+func (s *Server) respondPointer(w http.ResponseWriter, entityName string, entity map[string]interface{}, pointer string) {
+	if !strings.HasPrefix(pointer, "/") {
+		s.respondError(w, http.StatusBadRequest, "Invalid pointer: must start with '/'")
+		return
+	}
+	doc, err := toGenericJSON(s.shapeEntity(entityName, entity))
+	if err != nil {
+		log.Printf("Error preparing entity for pointer: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "Failed to resolve pointer")
+		return
+	}
+	value, ok := resolveJSONPointer(doc, pointer)
+	if !ok {
+		s.respondError(w, http.StatusNotFound, "Pointer does not resolve")
+		return
+	}
+	s.respondJSON(w, http.StatusOK, value)
+}
+
+// resolveJSONPointer evaluates an RFC 6901 JSON Pointer against a decoded
+// JSON value. The empty pointer refers to the whole document.
+func resolveJSONPointer(doc interface{}, pointer string) (interface{}, bool) {
+	if pointer == "" {
+		return doc, true
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+
+	current := doc
+	for _, token := range strings.Split(pointer[1:], "/") {
+		// ~1 must be decoded before ~0 so "~01" means "~1", not "/"
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, exists := node[token]
+			if !exists {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(node) || (len(token) > 1 && token[0] == '0') {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
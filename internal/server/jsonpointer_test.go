@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestResolveJSONPointer(t *testing.T) {
+	doc := map[string]interface{}{
+		"address": map[string]interface{}{"city": "Paris"},
+		"tags":    []interface{}{"a", "b"},
+		"a/b":     "slash",
+		"m~n":     "tilde",
+	}
+
+	tests := []struct {
+		pointer string
+		want    interface{}
+		wantOK  bool
+	}{
+		{"", doc, true},
+		{"/address", map[string]interface{}{"city": "Paris"}, true},
+		{"/address/city", "Paris", true},
+		{"/tags/1", "b", true},
+		{"/a~1b", "slash", true},
+		{"/m~0n", "tilde", true},
+		{"/tags/2", nil, false},
+		{"/tags/01", nil, false},
+		{"/tags/-", nil, false},
+		{"/address/zip", nil, false},
+		{"/address/city/name", nil, false},
+		{"address", nil, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := resolveJSONPointer(doc, tt.pointer)
+		if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("resolveJSONPointer(%q) = %v, %v; want %v, %v", tt.pointer, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestGetByPointer(t *testing.T) {
+	srv := setupTestServer(t)
+	srv.store.Create("users", map[string]interface{}{
+		"name":    "Alice",
+		"email":   "alice@example.com",
+		"address": map[string]interface{}{"city": "Paris", "zip": "75001"},
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		want       interface{}
+	}{
+		{name: "path leaf", path: "/users/1/address/city", wantStatus: http.StatusOK, want: "Paris"},
+		{name: "path sub-object", path: "/users/1/address", wantStatus: http.StatusOK, want: map[string]interface{}{"city": "Paris", "zip": "75001"}},
+		{name: "query param", path: "/users/1?pointer=/address/zip", wantStatus: http.StatusOK, want: "75001"},
+		{name: "unresolved", path: "/users/1/address/country", wantStatus: http.StatusNotFound},
+		{name: "missing entity", path: "/users/9/address", wantStatus: http.StatusNotFound},
+		{name: "relative pointer", path: "/users/1?pointer=address", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, http.NoBody)
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.want == nil {
+				return
+			}
+			var got interface{}
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("body = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("nested path only for GET", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/users/1/address", http.NoBody)
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("DELETE status = %d, want %d", w.Code, http.StatusNotFound)
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package server
+
+import "sync"
+
+// keyedLocks hands out one mutex per key, so work on different keys is not
+// serialized. Mutexes are dropped once no request holds or waits for them.
+// The zero value is ready to use.
+type keyedLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+// keyLock is one key's mutex; refs counts the requests holding or waiting for it
+type keyLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lock waits until no other request holds key and returns the function that
+// releases it
+func (k *keyedLocks) lock(key string) func() {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*keyLock)
+	}
+	held, exists := k.locks[key]
+	if !exists {
+		held = &keyLock{}
+		k.locks[key] = held
+	}
+	held.refs++
+	k.mu.Unlock()
+
+	held.mu.Lock()
+	return func() {
+		held.mu.Unlock()
+		k.mu.Lock()
+		held.refs--
+		if held.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// lazyInitState tracks which entities have been initialized, so only the
+// first request to each pays the lazyInit delay
+type lazyInitState struct {
+	mu    sync.Mutex
+	ready map[string]chan struct{} // closed once the entity's delay has passed
+}
+
+// wait holds the request until entityName is initialized: the first request
+// starts a delay-long initialization, and requests arriving before it is
+// done wait for it too. It returns false if the request's context ended
+// first.
+func (l *lazyInitState) wait(r *http.Request, entityName string, delay time.Duration) bool {
+	l.mu.Lock()
+	if l.ready == nil {
+		l.ready = make(map[string]chan struct{})
+	}
+	ready, started := l.ready[entityName]
+	if !started {
+		ready = make(chan struct{})
+		l.ready[entityName] = ready
+		time.AfterFunc(delay, func() { close(ready) })
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-ready:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+// reset makes every entity cold again
+func (l *lazyInitState) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ready = nil
+}
+
+// withLazyInit delays the first request to an entity's routes by the
+// schema's lazyInit delay. A request whose client went away or timed out
+// during the delay is not handled at all.
+func (s *Server) withLazyInit(entityName string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if delay := s.validator.loader.LazyInitDelay(); delay > 0 && !s.lazyInit.wait(r, entityName, delay) {
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+func TestLazyInit(t *testing.T) {
+	srv := setupTestServerWithSchema(t, `{
+		"lazyInit": {"delay": "150ms"},
+		"entities": {
+			"users": {"fields": {"id": {"type": "string", "required": true}}},
+			"posts": {"fields": {"id": {"type": "string", "required": true}}}
+		}
+	}`)
+
+	elapsed := func(path string) time.Duration {
+		start := time.Now()
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, http.NoBody))
+		if w.Code != http.StatusOK && w.Code != http.StatusNotFound {
+			t.Fatalf("GET %s status = %d", path, w.Code)
+		}
+		return time.Since(start)
+	}
+
+	steps := []struct {
+		path     string
+		wantSlow bool
+	}{
+		{"/users", true},
+		{"/users", false},
+		{"/users/1", false},
+		{"/posts", true},
+		{"/posts", false},
+	}
+	for _, step := range steps {
+		took := elapsed(step.path)
+		if slow := took >= 100*time.Millisecond; slow != step.wantSlow {
+			t.Errorf("GET %s took %v, want slow = %v", step.path, took, step.wantSlow)
+		}
+	}
+
+	// A schema reload makes every entity cold again
+	srv.lazyInit.reset()
+	if took := elapsed("/users"); took < 100*time.Millisecond {
+		t.Errorf("GET /users after reset took %v, want the lazyInit delay", took)
+	}
+}
+
+func TestLazyInitRespectsRequestTimeout(t *testing.T) {
+	srv := setupTestServerWithOptions(t, Options{RequestTimeout: 20 * time.Millisecond})
+	srv.validator.loader.GetSchema().LazyInit = &types.LazyInitConfig{Delay: "200ms"}
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name": "Alice", "email": "alice@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	// Give a create that wrongly ran after the delay time to land
+	time.Sleep(250 * time.Millisecond)
+	if users, _ := srv.store.List("users"); len(users) != 0 {
+		t.Errorf("timed out create stored %v", users)
+	}
+}
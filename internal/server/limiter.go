@@ -0,0 +1,100 @@
+package server
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// concurrencyLimiter bounds how many API requests are processed at once.
+// Requests over the limit wait up to queueTimeout for a slot (zero means
+// reject straight away) and are then answered 503.
+type concurrencyLimiter struct {
+	slots        chan struct{}
+	queueTimeout time.Duration
+
+	inFlight atomic.Int64
+	queued   atomic.Int64
+	rejected atomic.Int64
+}
+
+// ConcurrencyStats is the limiter's state as reported on /__metrics
+type ConcurrencyStats struct {
+	Limit    int   `json:"limit"`
+	InFlight int64 `json:"inFlight"`
+	Queued   int64 `json:"queued"`
+	Rejected int64 `json:"rejected"` // requests answered 503 since startup
+}
+
+// newConcurrencyLimiter returns nil when max is not positive (unlimited)
+func newConcurrencyLimiter(max int, queueTimeout time.Duration) *concurrencyLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &concurrencyLimiter{
+		slots:        make(chan struct{}, max),
+		queueTimeout: queueTimeout,
+	}
+}
+
+// acquire takes a slot, queueing up to queueTimeout or until the client goes
+// away, and reports whether it got one
+func (l *concurrencyLimiter) acquire(r *http.Request) bool {
+	select {
+	case l.slots <- struct{}{}:
+		l.inFlight.Add(1)
+		return true
+	default:
+	}
+	if l.queueTimeout <= 0 {
+		l.rejected.Add(1)
+		return false
+	}
+
+	l.queued.Add(1)
+	defer l.queued.Add(-1)
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		l.inFlight.Add(1)
+		return true
+	case <-timer.C:
+	case <-r.Context().Done():
+	}
+	l.rejected.Add(1)
+	return false
+}
+
+// release frees a slot taken by acquire
+func (l *concurrencyLimiter) release() {
+	l.inFlight.Add(-1)
+	<-l.slots
+}
+
+// stats snapshots the limiter's counters
+func (l *concurrencyLimiter) stats() *ConcurrencyStats {
+	return &ConcurrencyStats{
+		Limit:    cap(l.slots),
+		InFlight: l.inFlight.Load(),
+		Queued:   l.queued.Load(),
+		Rejected: l.rejected.Load(),
+	}
+}
+
+// withConcurrencyLimit runs fn holding a limiter slot, or answers 503 when
+// none frees up in time. The slot is released by a deferred call, so it is
+// returned even if fn panics.
+func (s *Server) withConcurrencyLimit(w http.ResponseWriter, r *http.Request, fn func()) {
+	if s.limiter == nil {
+		fn()
+		return
+	}
+	if !s.limiter.acquire(r) {
+		w.Header().Set("Retry-After", "1")
+		s.respondError(w, http.StatusServiceUnavailable, "Server is at its concurrent request limit")
+		return
+	}
+	defer s.limiter.release()
+	fn()
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// blockingHandler signals started and then waits for release
+func blockingHandler(started chan<- struct{}, release <-chan struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func TestConcurrencyLimitRejects(t *testing.T) {
+	srv := setupTestServerWithOptions(t, Options{MaxConcurrent: 1, Metrics: true})
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := srv.withChain(nil, blockingHandler(started, release))
+
+	done := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, "/slow", http.NoBody))
+		done <- w.Code
+	}()
+	<-started
+
+	w := httptest.NewRecorder()
+	srv.withChain(nil, func(w http.ResponseWriter, r *http.Request) {})(w, httptest.NewRequest(http.MethodGet, "/fast", http.NoBody))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status over limit = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected a Retry-After header")
+	}
+
+	// Reserved endpoints are not limited
+	req := httptest.NewRequest(http.MethodGet, "/__metrics", http.NoBody)
+	w = httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+	var metrics MetricsResponse
+	if err := json.NewDecoder(w.Body).Decode(&metrics); err != nil {
+		t.Fatalf("decode metrics: %v", err)
+	}
+	if metrics.Concurrency == nil {
+		t.Fatal("expected concurrency metrics")
+	}
+	if got := *metrics.Concurrency; got.Limit != 1 || got.InFlight != 1 || got.Rejected != 1 {
+		t.Errorf("concurrency = %+v, want limit 1, inFlight 1, rejected 1", got)
+	}
+
+	close(release)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("held request status = %d, want %d", code, http.StatusOK)
+	}
+	w = httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", http.NoBody))
+	if w.Code != http.StatusOK {
+		t.Errorf("status after release = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestConcurrencyLimitQueues(t *testing.T) {
+	srv := setupTestServerWithOptions(t, Options{MaxConcurrent: 1, QueueTimeout: 5 * time.Second})
+	started, release := make(chan struct{}, 2), make(chan struct{})
+	handler := srv.withChain(nil, blockingHandler(started, release))
+
+	codes := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(http.MethodGet, "/slow", http.NoBody))
+			codes <- w.Code
+		}()
+	}
+	<-started
+
+	deadline := time.Now().Add(2 * time.Second)
+	for srv.limiter.stats().Queued != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("queued = %d, want 1", srv.limiter.stats().Queued)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if code := <-codes; code != http.StatusOK {
+			t.Errorf("status = %d, want %d", code, http.StatusOK)
+		}
+	}
+	if stats := srv.limiter.stats(); stats.InFlight != 0 || stats.Queued != 0 || stats.Rejected != 0 {
+		t.Errorf("stats after drain = %+v, want all zero", stats)
+	}
+}
+
+func TestConcurrencyLimitQueueTimeout(t *testing.T) {
+	srv := setupTestServerWithOptions(t, Options{MaxConcurrent: 1, QueueTimeout: 10 * time.Millisecond})
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	go srv.withChain(nil, blockingHandler(started, release))(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", http.NoBody))
+	<-started
+
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", http.NoBody))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestConcurrencyLimitReleasedOnPanic(t *testing.T) {
+	srv := setupTestServerWithOptions(t, Options{MaxConcurrent: 1})
+	panicking := srv.withChain(nil, func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	panicking(w, httptest.NewRequest(http.MethodGet, "/boom", http.NoBody))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if inFlight := srv.limiter.stats().InFlight; inFlight != 0 {
+		t.Errorf("inFlight after panic = %d, want 0", inFlight)
+	}
+
+	w = httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", http.NoBody))
+	if w.Code != http.StatusOK {
+		t.Errorf("status after panic = %d, want %d", w.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LogLevel controls how much the request logging middleware prints
+type LogLevel int
+
+const (
+	// LogNormal logs one line per request plus its completion (default)
+	LogNormal LogLevel = iota
+
+	// LogQuiet logs only requests that ended in an error status
+	LogQuiet
+
+	// LogVerbose additionally logs headers and truncated bodies
+	LogVerbose
+)
+
+// maxLoggedBody caps how many bytes of a body are printed in verbose mode
+const maxLoggedBody = 1024
+
+// redactedHeaders are never printed in full, even in verbose mode
+var redactedHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"set-cookie":          true,
+	"x-api-key":           true,
+}
+
+// statusRecorder wraps a ResponseWriter to remember the status code and,
+// optionally, the first bodyLimit bytes of the response body
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	bodyLimit   int
+	body        bytes.Buffer
+}
+
+// newStatusRecorder creates a recorder defaulting to 200 OK that keeps up to
+// bodyLimit bytes of the body (none when zero)
+func newStatusRecorder(w http.ResponseWriter, bodyLimit int) *statusRecorder {
+	return &statusRecorder{ResponseWriter: w, status: http.StatusOK, bodyLimit: bodyLimit}
+}
+
+// recordedBodyLimit is how much of each response body the logger and the
+// capture file need; the extra byte lets a capture tell it was cut
+func (s *Server) recordedBodyLimit() int {
+	switch {
+	case s.capture != nil:
+		return maxCapturedBody + 1
+	case s.options.LogLevel == LogVerbose:
+		return maxLoggedBody
+	default:
+		return 0
+	}
+}
+
+// WriteHeader records the status code before delegating
+func (rec *statusRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Write keeps body bytes (up to the limit) before delegating
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	if rec.body.Len() < rec.bodyLimit {
+		remaining := rec.bodyLimit - rec.body.Len()
+		if len(b) < remaining {
+			remaining = len(b)
+		}
+		rec.body.Write(b[:remaining])
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+// Flush forwards to the underlying writer when it supports streaming
+func (rec *statusRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// logRequest logs the incoming request according to the configured level
+func (s *Server) logRequest(r *http.Request) {
+	switch s.options.LogLevel {
+	case LogQuiet:
+		return
+	case LogVerbose:
+		log.Printf("%s %s", r.Method, r.URL.RequestURI())
+		log.Printf("  headers: %s", formatHeaders(r.Header))
+		if r.Body != nil && r.Body != http.NoBody {
+			body, err := io.ReadAll(r.Body)
+			r.Body.Close()
+			if err == nil {
+				r.Body = io.NopCloser(bytes.NewReader(body))
+				if len(body) > 0 {
+					log.Printf("  body: %s", truncateBody(body))
+				}
+			}
+		}
+	default:
+		log.Printf("%s %s", r.Method, r.URL.Path)
+	}
+}
+
+// logCompletion logs the outcome of a request according to the configured level
+func (s *Server) logCompletion(r *http.Request, rec *statusRecorder, duration time.Duration) {
+	switch s.options.LogLevel {
+	case LogQuiet:
+		if rec.status >= http.StatusBadRequest {
+			log.Printf("%s %s -> %d in %v", r.Method, r.URL.Path, rec.status, duration)
+		}
+	case LogVerbose:
+		log.Printf("%s %s completed with %d in %v", r.Method, r.URL.Path, rec.status, duration)
+		log.Printf("  response headers: %s", formatHeaders(rec.Header()))
+		if rec.body.Len() > 0 {
+			log.Printf("  response body: %s", truncateBody(rec.body.Bytes()))
+		}
+	default:
+		log.Printf("%s %s completed in %v", r.Method, r.URL.Path, duration)
+	}
+}
+
+// formatHeaders renders headers on one line in sorted order, redacting secrets
+func formatHeaders(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		value := strings.Join(header[name], ", ")
+		if redactedHeaders[strings.ToLower(name)] {
+			value = "[REDACTED]"
+		}
+		parts = append(parts, name+": "+value)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// truncateBody shortens a body for logging, marking when it was cut
+func truncateBody(body []byte) string {
+	if len(body) <= maxLoggedBody {
+		return string(body)
+	}
+	return string(body[:maxLoggedBody]) + "...(truncated)"
+}
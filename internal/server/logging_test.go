@@ -0,0 +1,99 @@
+package server
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// captureLog redirects the standard logger for the duration of fn
+func captureLog(t *testing.T, fn func()) string {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	fn()
+	return buf.String()
+}
+
+func TestLogLevels(t *testing.T) {
+	tests := []struct {
+		name        string
+		level       LogLevel
+		path        string
+		wantContain []string
+		wantAbsent  []string
+	}{
+		{
+			name:        "normal logs request line",
+			level:       LogNormal,
+			path:        "/users",
+			wantContain: []string{"GET /users", "completed in"},
+			wantAbsent:  []string{"headers:"},
+		},
+		{
+			name:       "quiet skips successful requests",
+			level:      LogQuiet,
+			path:       "/users",
+			wantAbsent: []string{"GET /users"},
+		},
+		{
+			name:        "quiet logs errors",
+			level:       LogQuiet,
+			path:        "/users/missing",
+			wantContain: []string{"GET /users/missing -> 404"},
+		},
+		{
+			name:        "verbose logs headers with auth redacted",
+			level:       LogVerbose,
+			path:        "/users",
+			wantContain: []string{"headers:", "Authorization: [REDACTED]", "response body:"},
+			wantAbsent:  []string{"secret-token"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := setupTestServerWithOptions(t, Options{LogLevel: tt.level})
+			output := captureLog(t, func() {
+				req := httptest.NewRequest(http.MethodGet, tt.path, http.NoBody)
+				req.Header.Set("Authorization", "Bearer secret-token")
+				srv.mux.ServeHTTP(httptest.NewRecorder(), req)
+			})
+
+			for _, want := range tt.wantContain {
+				if !strings.Contains(output, want) {
+					t.Errorf("log output missing %q:\n%s", want, output)
+				}
+			}
+			for _, absent := range tt.wantAbsent {
+				if strings.Contains(output, absent) {
+					t.Errorf("log output should not contain %q:\n%s", absent, output)
+				}
+			}
+		})
+	}
+}
+
+func TestVerboseLoggingPreservesRequestBody(t *testing.T) {
+	srv := setupTestServerWithOptions(t, Options{LogLevel: LogVerbose})
+
+	var w *httptest.ResponseRecorder
+	output := captureLog(t, func() {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name": "Alice"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w = httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+	})
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d, body: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	if !strings.Contains(output, `body: {"name": "Alice"}`) {
+		t.Errorf("verbose log should include request body:\n%s", output)
+	}
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+// defaultRetryAfter is the Retry-After value, in seconds, used when
+// maintenance is switched on without one
+const defaultRetryAfter = 60
+
+// maintenanceState is toggled by POST /__maintenance and checked on every API request
+type maintenanceState struct {
+	enabled    atomic.Bool
+	retryAfter atomic.Int64 // seconds
+}
+
+// MaintenanceStatus is the body accepted and returned by /__maintenance
+type MaintenanceStatus struct {
+	Enabled    bool `json:"enabled"`
+	RetryAfter int  `json:"retryAfter,omitempty"` // seconds, sent as the Retry-After header
+}
+
+// respondIfMaintenance answers 503 with Retry-After while maintenance is on
+// and reports whether it did
+func (s *Server) respondIfMaintenance(w http.ResponseWriter) bool {
+	if !s.maintenance.enabled.Load() {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.FormatInt(s.maintenance.retryAfter.Load(), 10))
+	s.respondError(w, http.StatusServiceUnavailable, "Service unavailable for maintenance")
+	return true
+}
+
+// handleMaintenance handles POST /__maintenance - switch maintenance mode on or off
+func (s *Server) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	var request struct {
+		Enabled    *bool `json:"enabled"`
+		RetryAfter *int  `json:"retryAfter"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		s.respondError(w, http.StatusBadRequest, "Invalid JSON: expected {\"enabled\": true|false}")
+		return
+	}
+	if request.Enabled == nil {
+		s.respondError(w, http.StatusBadRequest, "enabled is required")
+		return
+	}
+
+	retryAfter := defaultRetryAfter
+	if request.RetryAfter != nil {
+		if *request.RetryAfter < 1 {
+			s.respondError(w, http.StatusBadRequest, "retryAfter must be a positive number of seconds")
+			return
+		}
+		retryAfter = *request.RetryAfter
+	}
+
+	s.maintenance.retryAfter.Store(int64(retryAfter))
+	s.maintenance.enabled.Store(*request.Enabled)
+
+	status := MaintenanceStatus{Enabled: *request.Enabled}
+	if status.Enabled {
+		status.RetryAfter = retryAfter
+	}
+	s.respondJSON(w, http.StatusOK, status)
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// methodOverrideHeader carries the real method of a tunneled POST
+const methodOverrideHeader = "X-HTTP-Method-Override"
+
+// overridableMethods are the methods a POST may be turned into
+var overridableMethods = map[string]bool{
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// applyMethodOverride returns the request to dispatch: for a POST with
+// X-HTTP-Method-Override, a copy carrying the named method; otherwise r
+// itself. It answers 400 and returns false when the header names a method
+// that can't be tunneled.
+func (s *Server) applyMethodOverride(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	override := r.Header.Get(methodOverrideHeader)
+	if override == "" || r.Method != http.MethodPost {
+		return r, true
+	}
+	method := strings.ToUpper(strings.TrimSpace(override))
+	if !overridableMethods[method] {
+		s.withReservedMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			s.respondError(w, http.StatusBadRequest, fmt.Sprintf("Unsupported %s %q (must be PUT, PATCH or DELETE)", methodOverrideHeader, override))
+		})(w, r)
+		return nil, false
+	}
+	overridden := r.Clone(r.Context())
+	overridden.Method = method
+	overridden.Header.Del(methodOverrideHeader)
+	return overridden, true
+}
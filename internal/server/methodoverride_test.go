@@ -0,0 +1,74 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMethodOverride(t *testing.T) {
+	tests := []struct {
+		name       string
+		allow      bool
+		override   string
+		body       string
+		wantStatus int
+		wantName   interface{} // users/1 name afterwards; nil when deleted
+	}{
+		{"override to DELETE", true, "DELETE", "", http.StatusNoContent, nil},
+		{"override to PATCH", true, "PATCH", `{"name": "Alicia"}`, http.StatusOK, "Alicia"},
+		{"override to PUT", true, "put", `{"name": "Ally", "email": "ally@example.com"}`, http.StatusOK, "Ally"},
+		{"unrecognized value", true, "GET", "", http.StatusBadRequest, "Alice"},
+		{"unknown method", true, "PURGE", "", http.StatusBadRequest, "Alice"},
+		{"ignored without the flag", false, "DELETE", "", http.StatusMethodNotAllowed, "Alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := setupTestServerWithOptions(t, Options{AllowMethodOverride: tt.allow})
+			srv.store.Seed("users", []map[string]interface{}{
+				{"id": "1", "name": "Alice", "email": "alice@example.com"},
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(methodOverrideHeader, tt.override)
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			entity, err := srv.store.Get("users", "1")
+			if tt.wantName == nil {
+				if err == nil {
+					t.Errorf("users/1 still exists: %v", entity)
+				}
+				return
+			}
+			if err != nil || entity["name"] != tt.wantName {
+				t.Errorf("users/1 = %v (%v), want name %v", entity, err, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestMethodOverrideOnlyAppliesToPost(t *testing.T) {
+	srv := setupTestServerWithOptions(t, Options{AllowMethodOverride: true})
+	srv.store.Seed("users", []map[string]interface{}{
+		{"id": "1", "name": "Alice", "email": "alice@example.com"},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", http.NoBody)
+	req.Header.Set(methodOverrideHeader, "DELETE")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if _, err := srv.store.Get("users", "1"); err != nil {
+		t.Errorf("GET with an override header deleted the entity: %v", err)
+	}
+}
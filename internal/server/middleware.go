@@ -0,0 +1,132 @@
+package server
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+// middlewareStep is one stage of the request pipeline. It either answers the
+// request itself or calls next to continue.
+type middlewareStep func(s *Server, w http.ResponseWriter, r *http.Request, next http.HandlerFunc)
+
+// middlewareSteps maps the names usable in a "middleware" list to their steps
+var middlewareSteps = map[string]middlewareStep{
+	types.MiddlewareAuth:        (*Server).authStep,
+	types.MiddlewareSelect:      (*Server).selectStep,
+	types.MiddlewareContentType: (*Server).contentTypeStep,
+	types.MiddlewareHeaders:     (*Server).headersStep,
+	types.MiddlewareTimeout:     (*Server).timeoutStep,
+}
+
+// middlewareNames resolves the ordered steps for an entity: its own list, the
+// schema's, or DefaultMiddleware. An empty entityName asks for the schema's.
+func (s *Server) middlewareNames(entityName string) []string {
+	if s.schema == nil {
+		return types.DefaultMiddleware
+	}
+	if entity, exists := s.schema.Entities[entityName]; exists && entity.Middleware != nil {
+		return entity.Middleware
+	}
+	if s.schema.Middleware != nil {
+		return s.schema.Middleware
+	}
+	return types.DefaultMiddleware
+}
+
+// buildChain composes the named steps around handler, the first name outermost
+func (s *Server) buildChain(names []string, handler http.HandlerFunc) http.HandlerFunc {
+	chain := handler
+	for i := len(names) - 1; i >= 0; i-- {
+		step, next := middlewareSteps[names[i]], chain
+		chain = func(w http.ResponseWriter, r *http.Request) {
+			step(s, w, r, next)
+		}
+	}
+	return chain
+}
+
+// authStep validates the Bearer token if auth is configured
+func (s *Server) authStep(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !s.authorized(r) {
+		s.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	next(w, r)
+}
+
+// authorized reports whether the request carries the configured Bearer
+// token. Without auth in the schema every request is authorized.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.schema == nil || s.schema.Auth == nil {
+		return true
+	}
+	return r.Header.Get("Authorization") == "Bearer "+s.schema.Auth.Token
+}
+
+// selectStep rejects malformed select expressions before the handler has side effects
+func (s *Server) selectStep(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if expr := r.URL.Query().Get(selectParam); expr != "" {
+		if _, err := parseJSONPath(expr); err != nil {
+			s.respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid select expression: %v", err))
+			return
+		}
+	}
+	next(w, r)
+}
+
+// contentTypeStep validates the Content-Type for POST, PUT, PATCH: JSON or any
+// structured +json type such as application/vnd.api+json. POST also
+// accepts multipart/form-data for creates with file-like fields. With
+// LenientContentType a missing Content-Type is taken to mean JSON.
+func (s *Server) contentTypeStep(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodPatch {
+		contentType := r.Header.Get("Content-Type")
+		multipartCreate := r.Method == http.MethodPost && isMultipartForm(r)
+		lenientMissing := contentType == "" && s.options.LenientContentType
+		if !multipartCreate && !lenientMissing && !isJSONMediaType(contentType) {
+			message := "Content-Type must be application/json"
+			if r.Method == http.MethodPost {
+				message = "Content-Type must be application/json or multipart/form-data"
+			}
+			s.respondError(w, http.StatusUnsupportedMediaType, message)
+			return
+		}
+	}
+	next(w, r)
+}
+
+// isJSONMediaType reports whether contentType is application/json or a
+// structured syntax suffixed +json type, ignoring parameters like charset
+func isJSONMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// headersStep sets the schema-level custom response headers
+func (s *Server) headersStep(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if s.schema != nil && s.schema.ResponseHeaders != nil {
+		for key, value := range s.schema.ResponseHeaders {
+			if !protectedHeaders[strings.ToLower(key)] {
+				w.Header().Set(key, value)
+			}
+		}
+	}
+	next(w, r)
+}
+
+// timeoutStep bounds the rest of the chain by the request timeout if configured.
+// http.TimeoutHandler cancels the request context and answers 503 on expiry.
+func (s *Server) timeoutStep(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if s.options.RequestTimeout > 0 {
+		http.TimeoutHandler(next, s.options.RequestTimeout, timeoutBody).ServeHTTP(w, r)
+		return
+	}
+	next(w, r)
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/ticktockbent/ape_my/internal/schema"
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+// multipartMaxMemory is how much of a multipart body is held in memory before
+// file parts spill to temporary files
+const multipartMaxMemory = 32 << 20
+
+// isMultipartForm reports whether the request carries multipart/form-data
+func isMultipartForm(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+// parseMultipartEntity assembles entity data from a multipart/form-data body.
+// Text parts are coerced to the schema type of the matching field where
+// possible; values that don't convert are kept as strings so validation
+// reports the mismatch. File parts are stored as base64 strings, or as
+// metadata for object fields.
+func (s *Server) parseMultipartEntity(entityName string, r *http.Request) (map[string]interface{}, error) {
+	if err := r.ParseMultipartForm(multipartMaxMemory); err != nil {
+		return nil, fmt.Errorf("invalid multipart form: %w", err)
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	var fields map[string]*types.Field
+	if s.schema != nil {
+		if entity, exists := s.schema.Entities[entityName]; exists {
+			fields = entity.Fields
+		}
+	}
+
+	data := make(map[string]interface{})
+	for name, values := range r.MultipartForm.Value {
+		if len(values) == 0 {
+			continue
+		}
+		data[name] = coerceFormValue(fields[name], values)
+	}
+
+	for name, headers := range r.MultipartForm.File {
+		if len(headers) == 0 {
+			continue
+		}
+		value, err := fileFieldValue(fields[name], headers[0])
+		if err != nil {
+			return nil, fmt.Errorf("file %q: %w", name, err)
+		}
+		data[name] = value
+	}
+
+	return data, nil
+}
+
+// coerceFormValue converts form text values to the field's schema type
+func coerceFormValue(field *types.Field, values []string) interface{} {
+	if field == nil {
+		return values[0]
+	}
+
+	raw := values[0]
+	switch field.Type {
+	case types.FieldTypeNumber, types.FieldTypeInteger:
+		// Only JSON number text: ParseFloat alone also accepts NaN, Inf
+		// and hex floats, which would be stored as invalid JSON
+		var decoded interface{}
+		if schema.DecodeJSON([]byte(raw), &decoded) == nil {
+			if num, ok := decoded.(json.Number); ok {
+				if _, err := strconv.ParseFloat(string(num), 64); err == nil {
+					return num
+				}
+			}
+		}
+	case types.FieldTypeBoolean:
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	case types.FieldTypeObject:
+		var obj map[string]interface{}
+		if err := schema.DecodeJSON([]byte(raw), &obj); err == nil {
+			return obj
+		}
+	case types.FieldTypeArray:
+		// Either a single JSON array or repeated form values
+		var arr []interface{}
+		if len(values) == 1 && schema.DecodeJSON([]byte(raw), &arr) == nil {
+			return arr
+		}
+		arr = make([]interface{}, len(values))
+		for i, v := range values {
+			arr[i] = v
+		}
+		return arr
+	}
+	return raw
+}
+
+// fileFieldValue reads an uploaded file into a field value
+func fileFieldValue(field *types.Field, header *multipart.FileHeader) (interface{}, error) {
+	if field != nil && field.Type == types.FieldTypeObject {
+		return map[string]interface{}{
+			"filename":    header.Filename,
+			"contentType": header.Header.Get("Content-Type"),
+			"size":        header.Size,
+		}, nil
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.EncodeToString(content), nil
+}
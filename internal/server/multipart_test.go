@@ -0,0 +1,138 @@
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// multipartRequest builds a multipart/form-data POST with text fields and one optional file
+func multipartRequest(t *testing.T, path string, fields map[string]string, fileField, fileName string, fileContent []byte) *http.Request {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for name, value := range fields {
+		if err := mw.WriteField(name, value); err != nil {
+			t.Fatalf("failed to write field: %v", err)
+		}
+	}
+	if fileField != "" {
+		part, err := mw.CreateFormFile(fileField, fileName)
+		if err != nil {
+			t.Fatalf("failed to create file part: %v", err)
+		}
+		part.Write(fileContent)
+	}
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, path, &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestMultipartCreate(t *testing.T) {
+	schemaJSON := `{
+		"entities": {
+			"documents": {
+				"fields": {
+					"id":         {"type": "string", "required": true},
+					"title":      {"type": "string", "required": true},
+					"pages":      {"type": "integer"},
+					"public":     {"type": "boolean"},
+					"tags":       {"type": "array"},
+					"content":    {"type": "binary"},
+					"attachment": {"type": "object"}
+				}
+			}
+		}
+	}`
+
+	t.Run("text and file parts", func(t *testing.T) {
+		srv := setupTestServerWithSchema(t, schemaJSON)
+		req := multipartRequest(t, "/documents", map[string]string{
+			"title":  "Report",
+			"pages":  "12",
+			"public": "true",
+			"tags":   `["a", "b"]`,
+		}, "content", "report.txt", []byte("hello"))
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("status = %d, want %d, body: %s", w.Code, http.StatusCreated, w.Body.String())
+		}
+
+		var doc map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&doc)
+		if doc["title"] != "Report" || doc["pages"] != float64(12) || doc["public"] != true {
+			t.Errorf("text fields not coerced: %v", doc)
+		}
+		if tags, ok := doc["tags"].([]interface{}); !ok || len(tags) != 2 {
+			t.Errorf("tags = %v, want 2-element array", doc["tags"])
+		}
+		if doc["content"] != base64.StdEncoding.EncodeToString([]byte("hello")) {
+			t.Errorf("content = %v, want base64 of file", doc["content"])
+		}
+	})
+
+	t.Run("file metadata for object field", func(t *testing.T) {
+		srv := setupTestServerWithSchema(t, schemaJSON)
+		req := multipartRequest(t, "/documents", map[string]string{"title": "Scan"}, "attachment", "scan.pdf", []byte("%PDF"))
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("status = %d, want %d, body: %s", w.Code, http.StatusCreated, w.Body.String())
+		}
+		var doc map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&doc)
+		meta, ok := doc["attachment"].(map[string]interface{})
+		if !ok || meta["filename"] != "scan.pdf" || meta["size"] != float64(4) {
+			t.Errorf("attachment = %v, want file metadata", doc["attachment"])
+		}
+	})
+
+	t.Run("validation runs on assembled fields", func(t *testing.T) {
+		srv := setupTestServerWithSchema(t, schemaJSON)
+		req := multipartRequest(t, "/documents", map[string]string{"title": "Bad", "pages": "many"}, "", "", nil)
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("number text must be a JSON number", func(t *testing.T) {
+		srv := setupTestServerWithSchema(t, schemaJSON)
+		for _, pages := range []string{"NaN", "Inf", "-Infinity", "0x1p3", "1e400"} {
+			req := multipartRequest(t, "/documents", map[string]string{"title": "Bad", "pages": pages}, "", "", nil)
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("pages=%s: status = %d, want %d", pages, w.Code, http.StatusBadRequest)
+			}
+		}
+		if docs, _ := srv.store.List("documents"); len(docs) != 0 {
+			t.Errorf("stored %v, want nothing", docs)
+		}
+	})
+
+	t.Run("multipart not accepted for updates", func(t *testing.T) {
+		srv := setupTestServerWithSchema(t, schemaJSON)
+		srv.store.Seed("documents", []map[string]interface{}{{"id": "1", "title": "Old"}})
+		req := multipartRequest(t, "/documents/1", map[string]string{"title": "New"}, "", "", nil)
+		req.Method = http.MethodPut
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusUnsupportedMediaType)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/ticktockbent/ape_my/internal/storage"
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+// ndjsonMediaType is the Accept value that streams lists as newline-delimited JSON
+const ndjsonMediaType = "application/x-ndjson"
+
+// wantsNDJSON reports whether the Accept header asks for newline-delimited JSON
+func wantsNDJSON(r *http.Request) bool {
+	return acceptsMediaType(r, ndjsonMediaType)
+}
+
+// acceptsMediaType reports whether the Accept header lists mediaType
+func acceptsMediaType(r *http.Request, mediaType string) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		accepted, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err == nil && accepted == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// respondNDJSON streams entities one JSON object per line, flushing after each
+// so clients can process results as they arrive. Wrappers, pagination
+// envelopes and listFormat don't apply; each line is a shaped entity. Behind
+// the timeout middleware step the writer is http.TimeoutHandler's, which
+// buffers the response and cannot flush, so the lines arrive together once
+// the handler returns.
+func (s *Server) respondNDJSON(w http.ResponseWriter, entityName string, items []map[string]interface{}) {
+	w.Header().Set("Content-Type", ndjsonMediaType)
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	for _, item := range items {
+		if err := encoder.Encode(s.shapeEntity(entityName, item)); err != nil {
+			// Headers are already sent, so the best we can do is log and stop
+			log.Printf("Error streaming NDJSON response: %v", err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// streamNDJSON streams a list query's matches like respondNDJSON, reading
+// each entity from the store only as it is written out, so a large
+// collection is never copied whole. The header goes out with the first line,
+// which leaves an empty result free to fall back to exampleWhenEmpty.
+func (s *Server) streamNDJSON(w http.ResponseWriter, entityName string, opts types.QueryOpts, iterator storage.Iterator) {
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	streamed := 0
+	err := iterator.Each(entityName, opts, func(item map[string]interface{}) bool {
+		if streamed == 0 {
+			w.Header().Set("Content-Type", ndjsonMediaType)
+			w.WriteHeader(http.StatusOK)
+		}
+		streamed++
+		if err := encoder.Encode(s.shapeEntity(entityName, item)); err != nil {
+			log.Printf("Error streaming NDJSON response: %v", err)
+			return false
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return true
+	})
+	if streamed > 0 {
+		return
+	}
+	if err != nil {
+		s.respondListError(w, err)
+		return
+	}
+
+	items := []map[string]interface{}{}
+	if example := s.emptyExample(entityName); example != nil {
+		w.Header().Set(exampleHeader, "true")
+		items = example
+	}
+	s.respondNDJSON(w, entityName, items)
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWantsNDJSON(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"application/json", false},
+		{"application/x-ndjson", true},
+		{"application/json, application/x-ndjson;q=0.5", true},
+		{"application/x-ndjsonx", false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/users", http.NoBody)
+		req.Header.Set("Accept", tt.accept)
+		if got := wantsNDJSON(req); got != tt.want {
+			t.Errorf("wantsNDJSON(%q) = %v, want %v", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestListNDJSON(t *testing.T) {
+	srv := setupTestServer(t)
+	for i := 1; i <= 3; i++ {
+		srv.store.Create("users", map[string]interface{}{"name": fmt.Sprintf("User %d", i), "email": "u@example.com"})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/users?name_ne=User%202", http.NoBody)
+	req.Header.Set("Accept", "application/x-ndjson")
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != ndjsonMediaType {
+		t.Errorf("Content-Type = %q, want %q", ct, ndjsonMediaType)
+	}
+	if !w.Flushed {
+		t.Error("response was not flushed while streaming")
+	}
+
+	var names []string
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var user map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &user); err != nil {
+			t.Fatalf("line %q is not a JSON object: %v", scanner.Text(), err)
+		}
+		names = append(names, user["name"].(string))
+	}
+	if len(names) != 2 || names[0] != "User 1" || names[1] != "User 3" {
+		t.Errorf("streamed names = %v, want [User 1 User 3]", names)
+	}
+}
+
+func TestListNDJSONPagesAndEmpty(t *testing.T) {
+	srv := setupTestServerWithSchema(t, `{
+		"pagination": {"defaultLimit": 10},
+		"entities": {
+			"users": {"fields": {"id": {"type": "string"}, "name": {"type": "string"}}}
+		}
+	}`)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		req.Header.Set("Accept", "application/x-ndjson")
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/users")
+	if w.Code != http.StatusOK || w.Body.Len() != 0 {
+		t.Errorf("empty list: status = %d, body = %q, want 200 and no lines", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != ndjsonMediaType {
+		t.Errorf("empty list: Content-Type = %q, want %q", ct, ndjsonMediaType)
+	}
+
+	for i := 1; i <= 5; i++ {
+		srv.store.Create("users", map[string]interface{}{"name": fmt.Sprintf("User %d", i)})
+	}
+	w = get("/users?offset=1&limit=2")
+	var ids []string
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var user map[string]interface{}
+		json.Unmarshal(scanner.Bytes(), &user)
+		ids = append(ids, user["id"].(string))
+	}
+	if len(ids) != 2 || ids[0] != "2" || ids[1] != "3" {
+		t.Errorf("paged ids = %v, want [2 3]", ids)
+	}
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// Request headers that force a response when --allow-mock-override is set
+const (
+	mockResponseHeader = "X-Mock-Response" // status code to answer with
+	mockBodyHeader     = "X-Mock-Body"     // optional body to send instead of the default
+)
+
+// respondIfMockOverride answers the request with the status (and body) named
+// in its X-Mock-Response / X-Mock-Body headers and reports whether it did.
+// Without an X-Mock-Body, error statuses get the usual {"error": ...} body
+// and other statuses an empty one.
+func (s *Server) respondIfMockOverride(w http.ResponseWriter, r *http.Request) bool {
+	if !s.options.AllowMockOverride {
+		return false
+	}
+	value := r.Header.Get(mockResponseHeader)
+	if value == "" {
+		return false
+	}
+
+	status, err := strconv.Atoi(value)
+	if err != nil || status < 100 || status > 599 {
+		s.respondError(w, http.StatusBadRequest, "Invalid "+mockResponseHeader+" header: must be a status code from 100 to 599")
+		return true
+	}
+
+	body := r.Header.Get(mockBodyHeader)
+	switch {
+	case body != "":
+		if !json.Valid([]byte(body)) {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	case status >= http.StatusBadRequest:
+		s.respondError(w, status, http.StatusText(status))
+	default:
+		w.WriteHeader(status)
+	}
+	return true
+}
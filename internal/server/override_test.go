@@ -0,0 +1,64 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMockOverride(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          string
+		body            string
+		wantStatus      int
+		wantBody        string
+		wantContentType string
+	}{
+		{name: "error status", status: "500", wantStatus: http.StatusInternalServerError, wantBody: `{"error":"Internal Server Error"}` + "\n", wantContentType: "application/json"},
+		{name: "json body", status: "422", body: `{"errors": []}`, wantStatus: http.StatusUnprocessableEntity, wantBody: `{"errors": []}`, wantContentType: "application/json"},
+		{name: "text body", status: "502", body: "bad gateway", wantStatus: http.StatusBadGateway, wantBody: "bad gateway", wantContentType: "text/plain; charset=utf-8"},
+		{name: "success without body", status: "204", wantStatus: http.StatusNoContent, wantBody: ""},
+		{name: "invalid status", status: "99", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := setupTestServerWithOptions(t, Options{AllowMockOverride: true})
+
+			req := httptest.NewRequest(http.MethodDelete, "/users/1", http.NoBody)
+			req.Header.Set(mockResponseHeader, tt.status)
+			if tt.body != "" {
+				req.Header.Set(mockBodyHeader, tt.body)
+			}
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" || tt.wantStatus < http.StatusBadRequest {
+				if w.Body.String() != tt.wantBody {
+					t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+				}
+			}
+			if tt.wantContentType != "" && w.Header().Get("Content-Type") != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", w.Header().Get("Content-Type"), tt.wantContentType)
+			}
+		})
+	}
+}
+
+func TestMockOverrideDisabled(t *testing.T) {
+	srv := setupTestServer(t)
+	srv.store.Create("users", map[string]interface{}{"name": "Alice", "email": "a@example.com"})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", http.NoBody)
+	req.Header.Set(mockResponseHeader, "500")
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
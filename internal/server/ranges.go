@@ -0,0 +1,73 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+// rangeUnit is the Range unit for list endpoints (Range: items=0-24)
+const rangeUnit = "items"
+
+// itemsRange is a parsed Range: items=start-end header; end is -1 when open
+type itemsRange struct {
+	start int
+	end   int
+}
+
+// limit returns the number of items the range asks for (0 = to the end)
+func (ir itemsRange) limit() int {
+	if ir.end < 0 {
+		return 0
+	}
+	return ir.end - ir.start + 1
+}
+
+// parseItemsRange parses a Range header of the form items=start-end or
+// items=start-. It reports false for an empty header or another unit, which
+// are ignored, and an error for a malformed items range.
+func parseItemsRange(header string) (itemsRange, bool, error) {
+	if header == "" {
+		return itemsRange{}, false, nil
+	}
+	unit, spec, found := strings.Cut(header, "=")
+	if !found || strings.TrimSpace(unit) != rangeUnit {
+		return itemsRange{}, false, nil
+	}
+
+	invalid := fmt.Errorf("expected %s=start-end, got %q", rangeUnit, header)
+	startStr, endStr, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return itemsRange{}, false, invalid
+	}
+	start, err := strconv.Atoi(startStr)
+	if err != nil || start < 0 {
+		return itemsRange{}, false, invalid
+	}
+	if endStr == "" {
+		return itemsRange{start: start, end: -1}, true, nil
+	}
+	end, err := strconv.Atoi(endStr)
+	if err != nil || end < start {
+		return itemsRange{}, false, invalid
+	}
+	return itemsRange{start: start, end: end}, true, nil
+}
+
+// respondRange writes a 206 Partial Content list for a Range request, or 416
+// when the range starts past the end of the collection
+func (s *Server) respondRange(w http.ResponseWriter, r *http.Request, entityName string, ir itemsRange, result *types.QueryResult) {
+	if ir.start >= result.TotalCount {
+		w.Header().Set("Content-Range", fmt.Sprintf("%s */%d", rangeUnit, result.TotalCount))
+		s.respondError(w, http.StatusRequestedRangeNotSatisfiable, fmt.Sprintf("Range start %d is beyond the %d available items", ir.start, result.TotalCount))
+		return
+	}
+
+	last := ir.start + len(result.Items) - 1
+	w.Header().Set("Content-Range", fmt.Sprintf("%s %d-%d/%d", rangeUnit, ir.start, last, result.TotalCount))
+	items := s.listCollection(entityName, s.shapeEntities(entityName, result.Items))
+	s.respondData(w, r, http.StatusPartialContent, items)
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseItemsRange(t *testing.T) {
+	tests := []struct {
+		header    string
+		want      itemsRange
+		wantRange bool
+		wantErr   bool
+	}{
+		{header: "", wantRange: false},
+		{header: "bytes=0-99", wantRange: false},
+		{header: "items=0-24", want: itemsRange{start: 0, end: 24}, wantRange: true},
+		{header: "items=10-", want: itemsRange{start: 10, end: -1}, wantRange: true},
+		{header: "items=5-2", wantErr: true},
+		{header: "items=-5", wantErr: true},
+		{header: "items=a-b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			got, hasRange, err := parseItemsRange(tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseItemsRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if hasRange != tt.wantRange || got != tt.want {
+				t.Errorf("parseItemsRange() = %+v, %v, want %+v, %v", got, hasRange, tt.want, tt.wantRange)
+			}
+		})
+	}
+}
+
+func TestListRange(t *testing.T) {
+	srv := setupTestServer(t)
+	for i := 1; i <= 5; i++ {
+		srv.store.Create("users", map[string]interface{}{"name": fmt.Sprintf("User %d", i), "email": "u@example.com"})
+	}
+
+	tests := []struct {
+		name             string
+		rangeHeader      string
+		wantStatus       int
+		wantContentRange string
+		wantCount        int
+	}{
+		{name: "no range", wantStatus: http.StatusOK, wantCount: 5},
+		{name: "first page", rangeHeader: "items=0-1", wantStatus: http.StatusPartialContent, wantContentRange: "items 0-1/5", wantCount: 2},
+		{name: "end past total", rangeHeader: "items=3-24", wantStatus: http.StatusPartialContent, wantContentRange: "items 3-4/5", wantCount: 2},
+		{name: "open ended", rangeHeader: "items=4-", wantStatus: http.StatusPartialContent, wantContentRange: "items 4-4/5", wantCount: 1},
+		{name: "start past total", rangeHeader: "items=5-9", wantStatus: http.StatusRequestedRangeNotSatisfiable, wantContentRange: "items */5"},
+		{name: "malformed", rangeHeader: "items=9-1", wantStatus: http.StatusRequestedRangeNotSatisfiable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users", http.NoBody)
+			if tt.rangeHeader != "" {
+				req.Header.Set("Range", tt.rangeHeader)
+			}
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if got := w.Header().Get("Content-Range"); got != tt.wantContentRange {
+				t.Errorf("Content-Range = %q, want %q", got, tt.wantContentRange)
+			}
+			if w.Code >= 300 {
+				return
+			}
+			var items []map[string]interface{}
+			json.NewDecoder(w.Body).Decode(&items)
+			if len(items) != tt.wantCount {
+				t.Errorf("got %d items, want %d", len(items), tt.wantCount)
+			}
+		})
+	}
+}
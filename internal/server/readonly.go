@@ -0,0 +1,34 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// readOnlyAllow lists the methods a read-only entity still answers
+const readOnlyAllow = "GET, HEAD, OPTIONS"
+
+// withReadOnly rejects every write to a read-only entity with 405
+func (s *Server) withReadOnly(entityName string, next http.HandlerFunc) http.HandlerFunc {
+	if !s.readOnly(entityName) {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next(w, r)
+		default:
+			w.Header().Set("Allow", readOnlyAllow)
+			s.respondError(w, http.StatusMethodNotAllowed, fmt.Sprintf("Entity %s is read-only", entityName))
+		}
+	}
+}
+
+// readOnly reports whether the entity is declared readOnly
+func (s *Server) readOnly(entityName string) bool {
+	if s.schema == nil {
+		return false
+	}
+	entity, exists := s.schema.Entities[entityName]
+	return exists && entity != nil && entity.ReadOnly
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const readOnlySchema = `{
+	"entities": {
+		"countries": {
+			"readOnly": true,
+			"fields": {
+				"id":   {"type": "string", "required": true},
+				"name": {"type": "string", "required": true}
+			}
+		},
+		"users": {
+			"fields": {
+				"id":   {"type": "string", "required": true},
+				"name": {"type": "string", "required": true}
+			}
+		}
+	}
+}`
+
+func TestReadOnlyEntity(t *testing.T) {
+	srv := setupTestServerWithSchema(t, readOnlySchema)
+	srv.store.Seed("countries", []map[string]interface{}{{"id": "nz", "name": "New Zealand"}})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{"list", http.MethodGet, "/countries", "", http.StatusOK},
+		{"get", http.MethodGet, "/countries/nz", "", http.StatusOK},
+		{"create", http.MethodPost, "/countries", `{"name": "Chile"}`, http.StatusMethodNotAllowed},
+		{"replace", http.MethodPut, "/countries/nz", `{"name": "Aotearoa"}`, http.StatusMethodNotAllowed},
+		{"patch", http.MethodPatch, "/countries/nz", `{"name": "Aotearoa"}`, http.StatusMethodNotAllowed},
+		{"delete", http.MethodDelete, "/countries/nz", "", http.StatusMethodNotAllowed},
+		{"other entities stay writable", http.MethodPost, "/users", `{"name": "Alice"}`, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusMethodNotAllowed {
+				if got := w.Header().Get("Allow"); got != readOnlyAllow {
+					t.Errorf("Allow = %q, want %q", got, readOnlyAllow)
+				}
+			}
+		})
+	}
+
+	entity, err := srv.store.Get("countries", "nz")
+	if err != nil || entity["name"] != "New Zealand" {
+		t.Errorf("countries/nz = %v (%v), want it unchanged", entity, err)
+	}
+}
+
+func TestReadOnlyEntityImport(t *testing.T) {
+	base := setupTestServerWithSchema(t, readOnlySchema)
+	srv := NewWithOptions(8080, base.store, base.routeMap, base.validator.loader, Options{AllowReset: true})
+	srv.RegisterRoutes()
+	srv.store.Seed("countries", []map[string]interface{}{{"id": "nz", "name": "New Zealand"}})
+
+	body := `{"countries": [{"id": "cl", "name": "Chile"}], "users": [{"id": "1", "name": "Alice"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/__import?mode=replace", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d, body: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var summary ImportSummary
+	if err := json.NewDecoder(w.Body).Decode(&summary); err != nil {
+		t.Fatalf("failed to decode summary: %v", err)
+	}
+	if len(summary.Skipped) != 1 || summary.Skipped[0] != "countries" || summary.Imported["users"] != 1 {
+		t.Errorf("summary = %+v, want users imported and countries skipped", summary)
+	}
+
+	if _, err := srv.store.Get("countries", "nz"); err != nil {
+		t.Errorf("replace import removed read-only record: %v", err)
+	}
+	if _, err := srv.store.Get("countries", "cl"); err == nil {
+		t.Error("import wrote to a read-only entity")
+	}
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// callRecovered runs fn, turning a panic into a logged stack trace and a 500
+// JSON error so one bad request can't take down the connection. If the
+// response has already started, the panic is only logged.
+func (s *Server) callRecovered(rec *statusRecorder, r *http.Request, fn func()) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		// net/http uses ErrAbortHandler to abort a response on purpose
+		if recovered == http.ErrAbortHandler {
+			panic(recovered)
+		}
+
+		log.Printf("Panic serving %s %s: %v\n%s", r.Method, r.URL.Path, recovered, debug.Stack())
+		if rec.wroteHeader {
+			return
+		}
+		rec.Header().Set("Content-Type", s.responseMediaType())
+		s.respondError(rec, http.StatusInternalServerError, "Internal server error")
+	}()
+	fn()
+}
@@ -7,9 +7,13 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/ticktockbent/ape_my/internal/schema"
 	"github.com/ticktockbent/ape_my/pkg/types"
 )
 
+// selectParam is the query parameter carrying a JSONPath response projection
+const selectParam = "select"
+
 // ErrorResponse represents a JSON error response
 type ErrorResponse struct {
 	Error string `json:"error"`
@@ -27,28 +31,107 @@ func (s *Server) respondJSON(w http.ResponseWriter, status int, data interface{}
 	}
 }
 
-// respondError writes a JSON error response
+// respondData writes a success payload, applying the ?select JSONPath projection
+// (or the API version's default one) to the final (possibly wrapped) body
+func (s *Server) respondData(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	// A vendor Accept type can pick the API version that shapes the body
+	if s.schema != nil && s.schema.APIVersions != nil {
+		addVary(w, "Accept")
+	}
+
+	expr := r.URL.Query().Get(selectParam)
+	if expr == "" {
+		if variant, _ := s.apiVersion(r); variant != nil {
+			expr = variant.Select
+		}
+	}
+	if expr != "" {
+		path, err := parseJSONPath(expr)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid select expression: %v", err))
+			return
+		}
+		generic, err := toGenericJSON(data)
+		if err != nil {
+			log.Printf("Error preparing response for select: %v", err)
+			s.respondError(w, http.StatusInternalServerError, "Failed to apply select expression")
+			return
+		}
+		data = path.Evaluate(generic)
+	}
+	s.respondJSON(w, status, data)
+}
+
+// toGenericJSON round-trips a value through JSON so it can be walked as
+// map[string]interface{} / []interface{}
+func toGenericJSON(data interface{}) (interface{}, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := schema.DecodeJSON(raw, &generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
+
+// respondError writes a JSON error response, using the error wrapper template if configured
 func (s *Server) respondError(w http.ResponseWriter, status int, message string) {
+	if s.jsonAPI() {
+		s.respondJSON(w, status, jsonAPIErrors(status, message))
+		return
+	}
+	if s.schema != nil && s.schema.ResponseWrapper != nil && s.schema.ResponseWrapper.Error != nil {
+		s.respondJSON(w, status, applyTemplate(s.schema.ResponseWrapper.Error, map[string]interface{}{
+			"$error":  message,
+			"$status": status,
+		}))
+		return
+	}
 	s.respondJSON(w, status, ErrorResponse{Error: message})
 }
 
-// respondSingle writes a single-entity response, applying wrapper if configured
-func (s *Server) respondSingle(w http.ResponseWriter, status int, entity map[string]interface{}) {
-	if s.schema != nil && s.schema.ResponseWrapper != nil && s.schema.ResponseWrapper.Single != nil {
-		wrapped := applyTemplate(s.schema.ResponseWrapper.Single, map[string]interface{}{
+// respondSingle writes a single-entity response, applying field transformations and wrapper if configured
+func (s *Server) respondSingle(w http.ResponseWriter, r *http.Request, entityName string, status int, entity map[string]interface{}) {
+	entity = s.shapeEntity(entityName, entity)
+
+	if s.jsonAPI() {
+		s.respondData(w, r, status, map[string]interface{}{"data": jsonAPIResource(entityName, entity)})
+		return
+	}
+
+	if wrapper := s.responseWrapper(r); wrapper != nil && wrapper.Single != nil {
+		wrapped := applyTemplate(wrapper.Single, map[string]interface{}{
 			"$entity": entity,
 		})
-		s.respondJSON(w, status, wrapped)
+		s.respondData(w, r, status, wrapped)
 		return
 	}
-	s.respondJSON(w, status, entity)
+	s.respondData(w, r, status, entity)
 }
 
-// respondList writes a list response with optional wrapping and pagination metadata
-func (s *Server) respondList(w http.ResponseWriter, entityName string, result *types.QueryResult) {
+// respondList writes a list response with optional wrapping and pagination
+// metadata, or 304 when the client's If-None-Match matches the collection ETag
+func (s *Server) respondList(w http.ResponseWriter, r *http.Request, entityName string, result *types.QueryResult) {
+	if s.respondIfNotModified(w, r, entityName, result) {
+		return
+	}
+
+	if s.jsonAPI() {
+		document := map[string]interface{}{"data": jsonAPIResources(entityName, s.shapeEntities(entityName, result.Items))}
+		if result.NextCursor != "" {
+			document["meta"] = map[string]interface{}{defaultNextTokenKey: result.NextCursor}
+		}
+		s.respondData(w, r, http.StatusOK, document)
+		return
+	}
+
+	items := s.listCollection(entityName, s.shapeEntities(entityName, result.Items))
+
 	// Build metadata map for template substitution
 	metadata := map[string]interface{}{
-		"$entities":     result.Items,
+		"$entities":     items,
 		"$count":        len(result.Items),
 		"$result_count": len(result.Items),
 	}
@@ -56,33 +139,138 @@ func (s *Server) respondList(w http.ResponseWriter, entityName string, result *t
 		metadata["$next_token"] = result.NextCursor
 	}
 
-	if s.schema != nil && s.schema.ResponseWrapper != nil && s.schema.ResponseWrapper.List != nil {
-		wrapped := applyTemplate(s.schema.ResponseWrapper.List, metadata)
-		s.respondJSON(w, http.StatusOK, wrapped)
+	wrapper := s.responseWrapper(r)
+	if wrapper != nil && wrapper.List != nil {
+		wrapped := applyTemplate(wrapper.List, metadata)
+		s.respondData(w, r, http.StatusOK, wrapped)
+		return
+	}
+	if wrapper != nil && wrapper.ListKey != "" {
+		s.respondData(w, r, http.StatusOK, paginationEnvelope(s.listKeyPagination(wrapper.ListKey), items, result))
 		return
 	}
 
 	// No wrapper configured — check if pagination metadata should be included
 	if s.schema != nil && s.schema.Pagination != nil {
-		meta := map[string]interface{}{
-			"result_count": len(result.Items),
+		// Only include meta wrapper if there's meaningful pagination info
+		// Page-number meta is always included so clients can render pagers,
+		// and so is a capped count's hasMore
+		if result.NextCursor != "" || result.TotalCount > len(result.Items) || s.schema.Pagination.Style == pageStyle || s.schema.Pagination.MaxReportedCount > 0 {
+			s.respondData(w, r, http.StatusOK, paginationEnvelope(s.schema.Pagination, items, result))
+			return
 		}
-		if s.schema.Pagination.Style == "cursor" && result.NextCursor != "" {
-			meta["next_token"] = result.NextCursor
+	}
+
+	s.respondData(w, r, http.StatusOK, items)
+}
+
+// listKeyPagination returns the pagination config with its data key replaced
+// by listKey, so the envelope carries the usual meta under the shorthand key
+func (s *Server) listKeyPagination(listKey string) *types.PaginationConfig {
+	config := types.PaginationConfig{}
+	if s.schema.Pagination != nil {
+		config = *s.schema.Pagination
+	}
+	keys := types.PaginationKeys{}
+	if config.Keys != nil {
+		keys = *config.Keys
+	}
+	keys.Data = listKey
+	config.Keys = &keys
+	return &config
+}
+
+// listCollection returns items in the entity's listFormat: the array itself,
+// or an object keyed by id for "map"
+func (s *Server) listCollection(entityName string, items []map[string]interface{}) interface{} {
+	if s.listFormat(entityName) != types.ListFormatMap {
+		return items
+	}
+	byID := make(map[string]interface{}, len(items))
+	for _, item := range items {
+		if item != nil {
+			byID[fmt.Sprintf("%v", item["id"])] = item
 		}
+	}
+	return byID
+}
 
-		// Only include meta wrapper if there's meaningful pagination info
-		if result.NextCursor != "" || result.TotalCount > len(result.Items) {
-			response := map[string]interface{}{
-				"data": result.Items,
-				"meta": meta,
-			}
-			s.respondJSON(w, http.StatusOK, response)
-			return
+// listFormat resolves the entity's listFormat, falling back to the schema's
+func (s *Server) listFormat(entityName string) string {
+	if s.schema == nil {
+		return types.ListFormatArray
+	}
+	if entity, exists := s.schema.Entities[entityName]; exists && entity.ListFormat != "" {
+		return entity.ListFormat
+	}
+	if s.schema.ListFormat != "" {
+		return s.schema.ListFormat
+	}
+	return types.ListFormatArray
+}
+
+// Default pagination envelope keys
+const (
+	defaultDataKey        = "data"
+	defaultMetaKey        = "meta"
+	defaultNextTokenKey   = "next_token"
+	defaultResultCountKey = "result_count"
+	defaultHasMoreKey     = "hasMore"
+
+	// flattenMetaKey places meta keys next to the data key instead of nesting them
+	flattenMetaKey = "-"
+)
+
+// paginationEnvelope builds {"data": [...], "meta": {...}} using the configured key names
+func paginationEnvelope(config *types.PaginationConfig, items interface{}, result *types.QueryResult) map[string]interface{} {
+	keys := types.PaginationKeys{}
+	if config.Keys != nil {
+		keys = *config.Keys
+	}
+
+	meta := map[string]interface{}{
+		keyOrDefault(keys.ResultCount, defaultResultCountKey): len(result.Items),
+	}
+	if config.Style == "cursor" && result.NextCursor != "" {
+		meta[keyOrDefault(keys.NextToken, defaultNextTokenKey)] = result.NextCursor
+	}
+	total := result.TotalCount
+	if config.MaxReportedCount > 0 && total > config.MaxReportedCount {
+		total = config.MaxReportedCount
+	}
+	if keys.TotalCount != "" {
+		meta[keys.TotalCount] = total
+	}
+	if keys.HasMore != "" || config.MaxReportedCount > 0 {
+		meta[keyOrDefault(keys.HasMore, defaultHasMoreKey)] = result.NextCursor != ""
+	}
+	if config.Style == pageStyle && result.Limit > 0 {
+		meta["page"] = result.Offset/result.Limit + 1
+		meta["per_page"] = result.Limit
+		meta["total_pages"] = (total + result.Limit - 1) / result.Limit
+		meta["total"] = total
+	}
+
+	response := map[string]interface{}{
+		keyOrDefault(keys.Data, defaultDataKey): items,
+	}
+	metaKey := keyOrDefault(keys.Meta, defaultMetaKey)
+	if metaKey == flattenMetaKey {
+		for k, v := range meta {
+			response[k] = v
 		}
+	} else {
+		response[metaKey] = meta
 	}
+	return response
+}
 
-	s.respondJSON(w, http.StatusOK, result.Items)
+// keyOrDefault returns key, or fallback if key is empty
+func keyOrDefault(key, fallback string) string {
+	if key == "" {
+		return fallback
+	}
+	return key
 }
 
 // applyTemplate recursively processes a template structure, substituting variables
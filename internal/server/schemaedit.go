@@ -0,0 +1,108 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/ticktockbent/ape_my/internal/schema"
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+// redactedToken replaces the auth token in schemas returned by /__schema
+const redactedToken = "[REDACTED]"
+
+// handleSchema handles GET /__schema - return the schema currently being
+// served. When the schema configures auth the endpoint requires the token,
+// and the token itself is never echoed back.
+func (s *Server) handleSchema(w http.ResponseWriter, r *http.Request) {
+	s.respondJSON(w, http.StatusOK, s.redactedSchema())
+}
+
+// redactedSchema returns a copy of the current schema safe to hand to clients
+func (s *Server) redactedSchema() *types.Schema {
+	if s.schema == nil || s.schema.Auth == nil {
+		return s.schema
+	}
+	redacted := *s.schema
+	redacted.Auth = &types.AuthConfig{Token: redactedToken}
+	return &redacted
+}
+
+// handleReplaceSchema handles PUT /__schema - validate a new schema and swap it
+// in, rebuilding the routes and validator. Data for entity types that remain
+// is kept and store settings such as versioning and indexes follow the new
+// schema; types the new schema drops are cleared. An invalid schema leaves
+// the current one in place. When the current schema configures auth, the
+// request must carry its token.
+func (s *Server) handleReplaceSchema(w http.ResponseWriter, r *http.Request) {
+	s.reload.RLock()
+	authorized := s.authorized(r)
+	s.reload.RUnlock()
+	if !authorized {
+		s.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	// Build everything for the new schema before taking the lock, so requests
+	// keep being served while it is checked
+	s.reload.RLock()
+	loader := schema.NewLoader()
+	loader.SetLimits(s.validator.loader.Limits())
+	s.reload.RUnlock()
+	if err := s.loadSchema(loader, body); err != nil {
+		s.respondError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	routeMap, err := loader.BuildRouteMap()
+	if err != nil {
+		s.respondError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Failed to build routes: %v", err))
+		return
+	}
+
+	s.reload.Lock()
+	defer s.reload.Unlock()
+
+	if err := s.store.Initialize(loader.GetEntityNames()); err != nil {
+		log.Printf("Error initializing storage for new schema: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "Failed to initialize storage")
+		return
+	}
+	for entityName := range s.routeMap {
+		if _, kept := routeMap[entityName]; !kept {
+			clearStoreSettings(s.store, entityName)
+			s.store.Reset(entityName)
+		}
+	}
+	ConfigureStore(s.store, loader)
+
+	s.schema = loader.GetSchema()
+	s.routeMap = routeMap
+	s.validator = NewValidator(loader)
+	s.mux = http.NewServeMux()
+	s.lazyInit.reset()
+	s.RegisterRoutes()
+	log.Printf("Schema replaced: now serving %d entities", len(routeMap))
+
+	s.respondJSON(w, http.StatusOK, s.redactedSchema())
+}
+
+// loadSchema parses a replacement schema in the format the server was
+// started with
+func (s *Server) loadSchema(loader *schema.Loader, body []byte) error {
+	if !s.options.JSONSchema {
+		return loader.Load(body)
+	}
+	warnings, err := loader.LoadJSONSchema(body)
+	for _, warning := range warnings {
+		log.Printf("Warning: %s", warning)
+	}
+	return err
+}
@@ -3,11 +3,16 @@ package server
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/ticktockbent/ape_my/internal/browser"
 	"github.com/ticktockbent/ape_my/internal/schema"
 	"github.com/ticktockbent/ape_my/internal/storage"
 	"github.com/ticktockbent/ape_my/pkg/types"
@@ -22,10 +27,97 @@ type Server struct {
 	validator *Validator
 	schema    *types.Schema
 	server    *http.Server
+	options   Options
+
+	idempotency *idempotencyCache
+	maintenance maintenanceState
+	limiter     *concurrencyLimiter // nil when requests are unlimited
+	started     time.Time           // warmup delays are measured from here
+	lazyInit    lazyInitState
+	slugLocks   keyedLocks     // per entity, held while generated slugs are checked and stored
+	capture     *captureWriter // nil unless Options.Capture is set
+
+	// reload is held for reading while a request is dispatched and for
+	// writing while PUT /__schema swaps mux, routeMap, validator and schema
+	reload sync.RWMutex
+}
+
+// Options holds runtime settings that come from the command line rather than the schema
+type Options struct {
+	LogLevel LogLevel
+
+	// RequestTimeout bounds handler processing; zero means no timeout
+	RequestTimeout time.Duration
+
+	// AllowExport enables GET /__export
+	AllowExport bool
+
+	// AllowReset enables POST /__import, which can overwrite the store
+	AllowReset bool
+
+	// IdempotencyTTL is how long Idempotency-Key values are remembered
+	// (DefaultIdempotencyTTL if zero)
+	IdempotencyTTL time.Duration
+
+	// Debug enables POST /__echo and adds _index to create responses
+	Debug bool
+
+	// OpenBrowser opens /__routes in the default browser once listening
+	OpenBrowser bool
+
+	// AllowMaintenance enables POST /__maintenance
+	AllowMaintenance bool
+
+	// AllowMockOverride lets X-Mock-Response force a request's response
+	AllowMockOverride bool
+
+	// AllowMethodOverride dispatches a POST carrying X-HTTP-Method-Override
+	// as the method it names
+	AllowMethodOverride bool
+
+	// AllowBulkUpdate enables PATCH on collections, which patches every
+	// entity matching the query's filters
+	AllowBulkUpdate bool
+
+	// AllowSchemaEdit enables PUT /__schema, which replaces the schema at runtime
+	AllowSchemaEdit bool
+
+	// JSONSchema parses PUT /__schema bodies as JSON Schema (draft-07), the
+	// format the schema file was loaded in
+	JSONSchema bool
+
+	// Metrics enables GET /__metrics
+	Metrics bool
+
+	// StaticDir, if set, is served at / for paths no API route matches
+	StaticDir string
+
+	// LenientContentType treats a write with no Content-Type as JSON; other
+	// non-JSON types are still rejected
+	LenientContentType bool
+
+	// MaxConcurrent bounds how many API requests are processed at once;
+	// zero means unlimited
+	MaxConcurrent int
+
+	// QueueTimeout is how long a request over MaxConcurrent waits for a
+	// slot before getting a 503; zero rejects it immediately
+	QueueTimeout time.Duration
+
+	// Capture, if set, receives one NDJSON CaptureRecord per request
+	Capture io.Writer
 }
 
-// New creates a new server instance
+// openBrowser launches a browser; replaced in tests
+var openBrowser = browser.Open
+
+// New creates a new server instance with default options
 func New(port int, store storage.Store, routeMap schema.RouteMap, loader *schema.Loader) *Server {
+	return NewWithOptions(port, store, routeMap, loader, Options{})
+}
+
+// NewWithOptions creates a new server instance with the given runtime options
+func NewWithOptions(port int, store storage.Store, routeMap schema.RouteMap, loader *schema.Loader, opts Options) *Server {
 	return &Server{
 		port:      port,
 		mux:       http.NewServeMux(),
@@ -33,6 +125,12 @@ func New(port int, store storage.Store, routeMap schema.RouteMap, loader *schema
 		routeMap:  routeMap,
 		validator: NewValidator(loader),
 		schema:    loader.GetSchema(),
+		options:   opts,
+
+		idempotency: newIdempotencyCache(opts.IdempotencyTTL),
+		limiter:     newConcurrencyLimiter(opts.MaxConcurrent, opts.QueueTimeout),
+		started:     time.Now(),
+		capture:     newCaptureWriter(opts.Capture),
 	}
 }
 
@@ -42,34 +140,85 @@ func (s *Server) RegisterRoutes() {
 	for _, route := range s.routeMap.GetRoutes() {
 		entityName := route.EntityName
 		collectionPath := route.CollectionPath
+		wrap := func(handler http.HandlerFunc) http.HandlerFunc {
+			handler = s.withReadOnly(entityName, handler)
+			if entity, exists := s.schema.Entities[entityName]; exists {
+				handler = withDeprecation(entity.Deprecated, entity.Sunset, handler)
+			}
+			return s.withEntityMiddleware(entityName, s.withEntityHeaders(entityName, s.withLazyInit(entityName, handler)))
+		}
 
 		// Collection routes: POST /entities, GET /entities
-		s.mux.HandleFunc(collectionPath, s.withMiddleware(s.handleCollection(entityName, collectionPath)))
+		s.mux.HandleFunc(collectionPath, wrap(s.handleCollection(entityName, collectionPath)))
 
 		// Item routes: GET /entities/123, PUT /entities/123, PATCH /entities/123, DELETE /entities/123
 		// Use collection path with trailing slash to catch all sub-paths
 		itemPattern := collectionPath + "/"
-		s.mux.HandleFunc(itemPattern, s.withMiddleware(s.handleItem(entityName, collectionPath)))
+		s.mux.HandleFunc(itemPattern, wrap(s.handleItem(entityName, collectionPath)))
 
 		log.Printf("Registered routes: %s and %s", collectionPath, itemPattern)
 	}
 
 	// Register custom routes if configured
 	if s.schema != nil && s.schema.Routes != nil {
-		prefix := schema.NormalizeBasePath(s.schema.BasePath)
+		customPaths := newCustomPathMethods()
 		for _, route := range s.schema.Routes {
 			customRoute := route // capture loop variable
 			// Convert :param syntax to Go 1.22 {param} syntax for mux registration
-			routePath := prefix + convertPathParams(customRoute.Path)
+			routePath := schema.CustomRoutePath(s.schema.BasePath, convertPathParams(customRoute.Path))
 			// Use method prefix for Go 1.22 mux to avoid conflicts with CRUD routes
 			muxPattern := strings.ToUpper(customRoute.Method) + " " + routePath
-			s.mux.HandleFunc(muxPattern, s.withMiddleware(s.handleCustomRoute(customRoute)))
-			log.Printf("Registered custom route: %s %s -> %s", customRoute.Method, routePath, customRoute.Entity)
+			target := customRoute.Entity
+			var handler http.HandlerFunc
+			if customRoute.Response != nil {
+				target = "fixed response"
+				handler = withDeprecation(customRoute.Deprecated, customRoute.Sunset, handleFixedResponse(customRoute))
+			} else {
+				handler = withDeprecation(customRoute.Deprecated, customRoute.Sunset, s.handleCustomRoute(customRoute))
+				handler = s.withEntityHeaders(customRoute.Entity, s.withLazyInit(customRoute.Entity, handler))
+			}
+			s.mux.HandleFunc(muxPattern, s.withEntityMiddleware(customRoute.Entity, handler))
+			customPaths.add(routePath, strings.ToUpper(customRoute.Method))
+			log.Printf("Registered custom route: %s %s -> %s", customRoute.Method, routePath, target)
 		}
+		s.registerCustomMethodNotAllowed(customPaths)
+	}
+
+	// Register built-in endpoints (e.g. /__routes)
+	s.registerReservedRoutes()
+
+	// Handle 404 for all other routes, or serve a static file if one matches
+	notFound := s.withMiddleware(s.handle404)
+	if s.options.StaticDir != "" {
+		notFound = s.withStatic(s.options.StaticDir, notFound)
+	}
+	s.mux.HandleFunc("/", notFound)
+}
+
+// ServeHTTP dispatches requests to the mux, answering the server-wide
+// "OPTIONS *" request itself since mux patterns cannot match "*". Method
+// overrides are applied first so routing sees the effective method.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.options.AllowMethodOverride {
+		var ok bool
+		if r, ok = s.applyMethodOverride(w, r); !ok {
+			return
+		}
+	}
+
+	// Replacing the schema swaps the mux, so it runs outside the read lock
+	if s.options.AllowSchemaEdit && r.Method == http.MethodPut && r.URL.Path == schemaPath {
+		s.withReservedMiddleware(s.handleReplaceSchema)(w, r)
+		return
 	}
 
-	// Handle 404 for all other routes
-	s.mux.HandleFunc("/", s.withMiddleware(s.handle404))
+	s.reload.RLock()
+	defer s.reload.RUnlock()
+	if r.Method == http.MethodOptions && r.RequestURI == "*" {
+		s.withReservedMiddleware(s.handleOptionsAsterisk)(w, r)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
 }
 
 // handle404 handles unknown routes
@@ -85,63 +234,176 @@ func (s *Server) handle404(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if s.unknownCollection(r) {
+		s.respondJSON(w, http.StatusOK, []interface{}{})
+		return
+	}
+
 	s.respondError(w, http.StatusNotFound, "Route not found")
 }
 
+// unknownCollection reports whether r lists a collection the schema does not
+// define and emptyUnknownCollections asks for it to be served as empty
+func (s *Server) unknownCollection(r *http.Request) bool {
+	if s.schema == nil || !s.schema.EmptyUnknownCollections {
+		return false
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	name, ok := strings.CutPrefix(r.URL.Path, schema.NormalizeBasePath(s.schema.BasePath)+"/")
+	return ok && name != "" && !strings.Contains(name, "/") && !strings.HasPrefix(name, "__")
+}
+
 // protectedHeaders are headers that custom response headers cannot override
 var protectedHeaders = map[string]bool{
 	"content-type":   true,
 	"content-length": true,
 }
 
-// withMiddleware wraps a handler with logging, auth, and content-type checking
+// withMiddleware wraps a handler with logging, panic recovery, and the
+// schema-level middleware chain
 func (s *Server) withMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return s.withChain(s.middlewareNames(""), next)
+}
+
+// withEntityMiddleware wraps an entity's handler like withMiddleware, using
+// the entity's own middleware list when it has one
+func (s *Server) withEntityMiddleware(entityName string, next http.HandlerFunc) http.HandlerFunc {
+	return s.withChain(s.middlewareNames(entityName), next)
+}
+
+// withChain wraps a handler with logging and panic recovery around the named
+// middleware steps
+func (s *Server) withChain(names []string, next http.HandlerFunc) http.HandlerFunc {
+	chain := s.buildChain(names, s.withWarmup(next))
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Logging middleware
 		start := time.Now()
-		log.Printf("%s %s", r.Method, r.URL.Path)
-
-		// Auth middleware — validate Bearer token if configured
-		if s.schema != nil && s.schema.Auth != nil {
-			authHeader := r.Header.Get("Authorization")
-			expectedToken := "Bearer " + s.schema.Auth.Token
-			if authHeader != expectedToken {
-				w.Header().Set("Content-Type", "application/json")
-				s.respondError(w, http.StatusUnauthorized, "Unauthorized")
-				return
-			}
+		rec := newStatusRecorder(w, s.recordedBodyLimit())
+		requestBody := s.captureRequestBody(r)
+		s.logRequest(r)
+
+		// Responses are JSON unless a handler says otherwise
+		rec.Header().Set("Content-Type", s.responseMediaType())
+		s.callRecovered(rec, r, func() {
+			s.withConcurrencyLimit(rec, r, func() {
+				if s.respondIfMockOverride(rec, r) || s.respondIfMaintenance(rec) || s.respondIfUnsupportedVersion(rec, r) {
+					return
+				}
+				chain(rec, r)
+			})
+		})
+
+		// Log completion
+		s.logCompletion(r, rec, time.Since(start))
+		s.captureExchange(r, requestBody, rec, start)
+	}
+}
+
+// customPathMethods tracks the methods bound to each custom route path shape.
+// Paths differing only in parameter names (/a/{x} and /a/{y}) share a shape.
+type customPathMethods struct {
+	order   []string            // shapes in registration order
+	paths   map[string]string   // shape -> first registered mux path
+	methods map[string][]string // shape -> bound methods
+}
+
+func newCustomPathMethods() *customPathMethods {
+	return &customPathMethods{
+		paths:   make(map[string]string),
+		methods: make(map[string][]string),
+	}
+}
+
+// add records that method is bound on path
+func (c *customPathMethods) add(path, method string) {
+	shape := pathShape(path)
+	if _, exists := c.paths[shape]; !exists {
+		c.order = append(c.order, shape)
+		c.paths[shape] = path
+	}
+	c.methods[shape] = append(c.methods[shape], method)
+}
+
+// registerCustomMethodNotAllowed registers a method-less pattern for each
+// custom route path that answers other methods with 405 and an Allow header.
+// Paths that coincide with generated CRUD routes are skipped: those routes
+// already handle every method themselves.
+func (s *Server) registerCustomMethodNotAllowed(customPaths *customPathMethods) {
+	generated := make(map[string]bool)
+	for _, route := range s.routeMap.GetRoutes() {
+		generated[pathShape(route.CollectionPath)] = true
+		generated[pathShape(route.ItemPath)] = true
+	}
+
+	for _, shape := range customPaths.order {
+		if generated[shape] {
+			continue
 		}
+		allow := allowHeader(customPaths.methods[shape])
+		s.mux.HandleFunc(customPaths.paths[shape], s.withMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Allow", allow)
+			s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}))
+	}
+}
 
-		// Content-Type validation for POST, PUT, PATCH
-		if r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodPatch {
-			contentType := r.Header.Get("Content-Type")
-			if !strings.HasPrefix(contentType, "application/json") {
-				s.respondError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
-				return
-			}
+// allowHeader builds a sorted, de-duplicated Allow value. HEAD is included
+// for GET since the mux answers HEAD with the GET handler.
+func allowHeader(methods []string) string {
+	seen := make(map[string]bool)
+	for _, method := range methods {
+		seen[method] = true
+		if method == http.MethodGet {
+			seen[http.MethodHead] = true
+		}
+	}
+	allowed := make([]string, 0, len(seen))
+	for method := range seen {
+		allowed = append(allowed, method)
+	}
+	sort.Strings(allowed)
+	return strings.Join(allowed, ", ")
+}
+
+// pathShape replaces {param} segments with {} so paths can be compared by structure
+func pathShape(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			parts[i] = "{}"
 		}
+	}
+	return strings.Join(parts, "/")
+}
 
-		// Set JSON response header
-		w.Header().Set("Content-Type", "application/json")
+// withEntityHeaders sets the entity's own response headers. It runs inside
+// the middleware chain, after the schema-level headers, so entity values win on conflicts.
+func (s *Server) withEntityHeaders(entityName string, next http.HandlerFunc) http.HandlerFunc {
+	var headers map[string]string
+	if s.schema != nil {
+		if entity, exists := s.schema.Entities[entityName]; exists {
+			headers = entity.ResponseHeaders
+		}
+	}
+	if len(headers) == 0 {
+		return next
+	}
 
-		// Set custom response headers if configured
-		if s.schema != nil && s.schema.ResponseHeaders != nil {
-			for key, value := range s.schema.ResponseHeaders {
-				if !protectedHeaders[strings.ToLower(key)] {
-					w.Header().Set(key, value)
-				}
+	return func(w http.ResponseWriter, r *http.Request) {
+		for key, value := range headers {
+			if !protectedHeaders[strings.ToLower(key)] {
+				w.Header().Set(key, value)
 			}
 		}
-
-		// Call the handler
 		next(w, r)
-
-		// Log completion
-		duration := time.Since(start)
-		log.Printf("%s %s completed in %v", r.Method, r.URL.Path, duration)
 	}
 }
 
+// timeoutBody is the JSON error returned when a request exceeds the timeout
+const timeoutBody = `{"error":"Request timed out"}` + "\n"
+
 // convertPathParams converts :param syntax to Go 1.22 {param} syntax
 func convertPathParams(path string) string {
 	parts := strings.Split(path, "/")
@@ -153,39 +415,50 @@ func convertPathParams(path string) string {
 	return strings.Join(parts, "/")
 }
 
-// extractParamNames returns the parameter names from a route path using :param syntax
-func extractParamNames(path string) []string {
-	var names []string
-	for _, part := range strings.Split(path, "/") {
-		if strings.HasPrefix(part, ":") {
-			names = append(names, part[1:])
-		}
-	}
-	return names
-}
-
 // Start starts the HTTP server
 func (s *Server) Start() error {
 	s.server = &http.Server{
 		Addr:         fmt.Sprintf(":%d", s.port),
-		Handler:      s.mux,
+		Handler:      s,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
+
+		// Let ServeHTTP answer "OPTIONS *" instead of net/http's default
+		DisableGeneralOptionsHandler: true,
 	}
 
-	log.Printf("Starting server on http://localhost:%d", s.port)
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return fmt.Errorf("server error: %w", err)
+	}
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	log.Printf("Starting server on http://localhost:%d", port)
 	log.Printf("Press Ctrl+C to stop")
 
-	if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	if s.options.OpenBrowser {
+		// Opening is best-effort and must never hold up or stop the server
+		go func() {
+			url := fmt.Sprintf("http://localhost:%d%s", port, routesPath)
+			if err := openBrowser(url); err != nil {
+				log.Printf("Could not open %s: %v", url, err)
+			}
+		}()
+	}
+
+	if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("server error: %w", err)
 	}
 
 	return nil
 }
 
-// Shutdown gracefully shuts down the server
+// Shutdown gracefully shuts down the server and cancels pending scheduled updates
 func (s *Server) Shutdown(ctx context.Context) error {
+	if scheduler, ok := s.store.(storage.Scheduler); ok {
+		scheduler.CancelScheduled()
+	}
 	if s.server != nil {
 		return s.server.Shutdown(ctx)
 	}
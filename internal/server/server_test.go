@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -8,6 +9,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ticktockbent/ape_my/internal/schema"
 	"github.com/ticktockbent/ape_my/internal/storage"
@@ -97,6 +99,20 @@ func setupTestServer(t *testing.T) *Server {
 	return server
 }
 
+// setupTestServerWithOptions creates a test server from the standard test schema with runtime options
+func setupTestServerWithOptions(t *testing.T, opts Options) *Server {
+	store := storage.NewInMemoryStore()
+	store.Initialize([]string{"users", "posts"})
+	loader := setupTestSchema(t)
+	routeMap, err := loader.BuildRouteMap()
+	if err != nil {
+		t.Fatalf("failed to build route map: %v", err)
+	}
+	srv := NewWithOptions(8080, store, routeMap, loader, opts)
+	srv.RegisterRoutes()
+	return srv
+}
+
 func TestNew(t *testing.T) {
 	store := storage.NewInMemoryStore()
 	routeMap := schema.RouteMap{}
@@ -204,6 +220,27 @@ func TestMiddleware_ContentType(t *testing.T) {
 			contentType: "text/plain",
 			wantStatus:  http.StatusUnsupportedMediaType,
 		},
+		{
+			name:        "POST with JSON and charset",
+			method:      http.MethodPost,
+			path:        "/users",
+			contentType: "application/json; charset=utf-8",
+			wantStatus:  http.StatusBadRequest, // Empty body
+		},
+		{
+			name:        "POST with JSON:API media type",
+			method:      http.MethodPost,
+			path:        "/users",
+			contentType: "application/vnd.api+json",
+			wantStatus:  http.StatusBadRequest, // Empty body
+		},
+		{
+			name:        "POST with JSON-like prefix",
+			method:      http.MethodPost,
+			path:        "/users",
+			contentType: "application/jsonp",
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
 		{
 			name:        "PUT with JSON",
 			method:      http.MethodPut,
@@ -244,6 +281,36 @@ func TestMiddleware_ContentType(t *testing.T) {
 	}
 }
 
+func TestMiddleware_LenientContentType(t *testing.T) {
+	server := setupTestServerWithOptions(t, Options{LenientContentType: true})
+
+	tests := []struct {
+		name        string
+		contentType string
+		wantStatus  int
+	}{
+		{"missing content-type treated as JSON", "", http.StatusCreated},
+		{"JSON", "application/json", http.StatusCreated},
+		{"wrong content-type still rejected", "text/plain", http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name": "Alice"}`))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+
+			server.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
+
 func TestHandle404(t *testing.T) {
 	server := setupTestServer(t)
 
@@ -273,6 +340,45 @@ func TestHandle404(t *testing.T) {
 	}
 }
 
+func TestEmptyUnknownCollections(t *testing.T) {
+	server := setupTestServerWithSchema(t, `{
+		"basePath": "/api",
+		"emptyUnknownCollections": true,
+		"entities": {"users": {"fields": {"id": {"type": "string"}}}}
+	}`)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"unknown collection", http.MethodGet, "/api/widgets", http.StatusOK, "[]\n"},
+		{"known collection", http.MethodGet, "/api/users", http.StatusOK, "[]\n"},
+		{"unknown item", http.MethodGet, "/api/widgets/1", http.StatusNotFound, ""},
+		{"unknown collection DELETE", http.MethodDelete, "/api/widgets", http.StatusNotFound, ""},
+		{"outside base path", http.MethodGet, "/widgets", http.StatusNotFound, ""},
+		{"reserved prefix", http.MethodGet, "/api/__nope", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			server.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d for %s %s", w.Code, tt.wantStatus, tt.method, tt.path)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
 func TestHandleItem_IDExtraction(t *testing.T) {
 	server := setupTestServer(t)
 
@@ -469,6 +575,67 @@ func TestCustomResponseHeaders(t *testing.T) {
 	}
 }
 
+func TestEntityResponseHeaders(t *testing.T) {
+	schemaJSON := `{
+		"responseHeaders": {
+			"Cache-Control": "no-store",
+			"X-Api": "mock"
+		},
+		"entities": {
+			"products": {
+				"responseHeaders": {
+					"cache-control": "max-age=3600",
+					"Content-Type": "text/plain"
+				},
+				"fields": {
+					"id":   {"type": "string", "required": true},
+					"name": {"type": "string", "required": true}
+				}
+			},
+			"users": {
+				"fields": {
+					"id":   {"type": "string", "required": true},
+					"name": {"type": "string", "required": true}
+				}
+			}
+		},
+		"routes": [
+			{"method": "GET", "path": "/featured/:id", "entity": "products", "filters": {"id": "id"}}
+		]
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+	srv.store.Seed("products", []map[string]interface{}{{"id": "1", "name": "Widget"}})
+
+	tests := []struct {
+		path             string
+		wantCacheControl string
+	}{
+		{"/products", "max-age=3600"},
+		{"/products/1", "max-age=3600"},
+		{"/featured/1", "max-age=3600"},
+		{"/users", "no-store"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, http.NoBody)
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Cache-Control"); got != tt.wantCacheControl {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.wantCacheControl)
+			}
+			// Global headers without an entity override still apply
+			if got := w.Header().Get("X-Api"); got != "mock" {
+				t.Errorf("X-Api = %q, want %q", got, "mock")
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want %q", got, "application/json")
+			}
+		})
+	}
+}
+
 func TestCustomHeadersDoNotOverrideProtected(t *testing.T) {
 	schemaJSON := `{
 		"responseHeaders": {
@@ -555,6 +722,60 @@ func TestAuthMiddleware(t *testing.T) {
 	}
 }
 
+func TestMiddlewareConfig(t *testing.T) {
+	schemaJSON := `{
+		"auth": {"token": "secret"},
+		"middleware": ["contentType", "auth"],
+		"entities": {
+			"users": {
+				"fields": {
+					"id":   {"type": "string", "required": true},
+					"name": {"type": "string"}
+				}
+			},
+			"status": {
+				"middleware": [],
+				"fields": {
+					"id": {"type": "string", "required": true}
+				}
+			}
+		}
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		wantStatus  int
+	}{
+		// contentType runs before auth, so a bad body type wins over a missing token
+		{name: "order", method: http.MethodPost, path: "/users", contentType: "text/plain", wantStatus: http.StatusUnsupportedMediaType},
+		{name: "auth still applies", method: http.MethodGet, path: "/users", wantStatus: http.StatusUnauthorized},
+		{name: "entity without middleware", method: http.MethodGet, path: "/status", wantStatus: http.StatusOK},
+		{name: "entity skips content type check", method: http.MethodPost, path: "/status", contentType: "text/plain", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader("not json"))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+		})
+	}
+}
+
 func TestNoAuthWhenNotConfigured(t *testing.T) {
 	// Default server has no auth configured — all requests should pass
 	server := setupTestServer(t)
@@ -620,6 +841,60 @@ func TestQueryParameterFiltering(t *testing.T) {
 	}
 }
 
+func TestOperatorFilters(t *testing.T) {
+	schemaJSON := `{
+		"entities": {
+			"users": {
+				"fields": {
+					"id":         {"type": "string", "required": true},
+					"name":       {"type": "string", "required": true},
+					"email":      {"type": "string", "required": false},
+					"age":        {"type": "integer", "required": false},
+					"last_login": {"type": "string", "required": false}
+				}
+			}
+		}
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+
+	srv.store.Create("users", map[string]interface{}{"name": "Alice", "email": "alice@example.com", "age": json.Number("17"), "last_login": "2024-01-01"})
+	srv.store.Create("users", map[string]interface{}{"name": "Bob", "email": "bob@example.com", "age": json.Number("40"), "last_login": "2024-03-01"})
+	srv.store.Create("users", map[string]interface{}{"name": "Alice", "email": "alice2@example.com", "age": json.Number("70")})
+
+	tests := []struct {
+		name      string
+		query     string
+		wantCount int
+	}{
+		{"like operator", "/users?email_like=ALICE", 2},
+		{"range operators", "/users?age_gte=18&age_lt=65", 1},
+		{"in operator", "/users?name_in=Bob,Carol", 1},
+		{"ne operator", "/users?name_ne=Alice", 1},
+		{"operator on underscored field", "/users?last_login_gt=2024-02-01", 1},
+		{"operator on unknown field ignored", "/users?unknown_gte=1", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.query, http.NoBody)
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+
+			var response []map[string]interface{}
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(response) != tt.wantCount {
+				t.Errorf("got %d results, want %d", len(response), tt.wantCount)
+			}
+		})
+	}
+}
+
 func TestResponseWrapper(t *testing.T) {
 	schemaJSON := `{
 		"responseWrapper": {
@@ -669,6 +944,112 @@ func TestResponseWrapper(t *testing.T) {
 	}
 }
 
+func TestResponseWrapperListKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		wrapper  string
+		wantKeys []string
+	}{
+		{"list key", `{"listKey": "results"}`, []string{"results", "meta"}},
+		{"list key with single template", `{"listKey": "items", "single": {"data": "$entity"}}`, []string{"items", "meta"}},
+		{"list template wins", `{"listKey": "results", "list": {"records": "$entities"}}`, []string{"records"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := setupTestServerWithSchema(t, `{
+				"responseWrapper": `+tt.wrapper+`,
+				"entities": {"users": {"fields": {"id": {"type": "string", "required": true}, "name": {"type": "string"}}}}
+			}`)
+
+			createReq := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name": "Alice"}`))
+			createReq.Header.Set("Content-Type", "application/json")
+			srv.mux.ServeHTTP(httptest.NewRecorder(), createReq)
+
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", http.NoBody))
+
+			var resp map[string]interface{}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(resp) != len(tt.wantKeys) {
+				t.Fatalf("response keys = %v, want %v", resp, tt.wantKeys)
+			}
+			for _, key := range tt.wantKeys {
+				if _, ok := resp[key]; !ok {
+					t.Errorf("response missing %q: %v", key, resp)
+				}
+			}
+			items, _ := resp[tt.wantKeys[0]].([]interface{})
+			if len(items) != 1 {
+				t.Errorf("%s = %v, want one item", tt.wantKeys[0], resp[tt.wantKeys[0]])
+			}
+			if meta, ok := resp["meta"].(map[string]interface{}); ok && meta["result_count"] != float64(1) {
+				t.Errorf("meta.result_count = %v, want 1", meta["result_count"])
+			}
+		})
+	}
+}
+
+func TestErrorWrapper(t *testing.T) {
+	entities := `"entities": {
+			"users": {
+				"fields": {
+					"id":   {"type": "string", "required": true},
+					"name": {"type": "string", "required": true}
+				}
+			}
+		}`
+
+	t.Run("error template wraps errors", func(t *testing.T) {
+		srv := setupTestServerWithSchema(t, `{
+		"responseWrapper": {
+			"single": {"data": "$entity"},
+			"error": {"errors": [{"status": "$status", "detail": "$error"}]}
+		},
+		`+entities+`
+	}`)
+
+		req := httptest.NewRequest(http.MethodGet, "/users/missing", http.NoBody)
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+		}
+		var resp struct {
+			Errors []struct {
+				Status float64 `json:"status"`
+				Detail string  `json:"detail"`
+			} `json:"errors"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if len(resp.Errors) != 1 || resp.Errors[0].Status != http.StatusNotFound || resp.Errors[0].Detail == "" {
+			t.Fatalf("wrapped error = %+v, want one 404 entry with a detail", resp)
+		}
+	})
+
+	t.Run("errors stay bare without error template", func(t *testing.T) {
+		srv := setupTestServerWithSchema(t, `{
+		"responseWrapper": {"single": {"data": "$entity"}},
+		`+entities+`
+	}`)
+
+		req := httptest.NewRequest(http.MethodGet, "/users/missing", http.NoBody)
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+
+		var resp map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&resp)
+		if _, ok := resp["error"]; !ok || len(resp) != 1 {
+			t.Fatalf("error response = %v, want bare {\"error\": ...}", resp)
+		}
+	})
+}
+
 func TestPaginationCursor(t *testing.T) {
 	schemaJSON := `{
 		"pagination": {
@@ -717,23 +1098,97 @@ func TestPaginationCursor(t *testing.T) {
 	}
 }
 
-func TestPaginationOffset(t *testing.T) {
-	schemaJSON := `{
-		"pagination": {
-			"style": "offset",
-			"defaultLimit": 2,
-			"maxLimit": 10
+func TestPaginationKeyOverrides(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     string
+		wantKeys []string
+		wantMeta string // nested meta key, empty when flattened
+	}{
+		{
+			name:     "defaults",
+			keys:     `{}`,
+			wantKeys: []string{"result_count", "next_token"},
+			wantMeta: "meta",
 		},
-		"entities": {
-			"users": {
-				"fields": {
-					"id":   {"type": "string", "required": true},
-					"name": {"type": "string", "required": true}
-				}
-			}
-		}
-	}`
-	srv := setupTestServerWithSchema(t, schemaJSON)
+		{
+			name:     "google style top-level",
+			keys:     `{"data": "items", "meta": "-", "nextToken": "nextPageToken", "resultCount": "count", "totalCount": "totalSize"}`,
+			wantKeys: []string{"count", "nextPageToken", "totalSize"},
+		},
+		{
+			name:     "nested renamed meta",
+			keys:     `{"meta": "pagination", "totalCount": "totalCount", "hasMore": "hasMore"}`,
+			wantKeys: []string{"result_count", "next_token", "totalCount", "hasMore"},
+			wantMeta: "pagination",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schemaJSON := `{
+				"pagination": {"style": "cursor", "defaultLimit": 2, "keys": ` + tt.keys + `},
+				"entities": {
+					"users": {
+						"fields": {
+							"id":   {"type": "string", "required": true},
+							"name": {"type": "string", "required": true}
+						}
+					}
+				}
+			}`
+			srv := setupTestServerWithSchema(t, schemaJSON)
+			srv.store.Seed("users", []map[string]interface{}{
+				{"id": "1", "name": "A"}, {"id": "2", "name": "B"}, {"id": "3", "name": "C"},
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/users", http.NoBody)
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			var resp map[string]interface{}
+			json.NewDecoder(w.Body).Decode(&resp)
+
+			meta := resp
+			if tt.wantMeta != "" {
+				nested, ok := resp[tt.wantMeta].(map[string]interface{})
+				if !ok {
+					t.Fatalf("expected %q object in response, got: %v", tt.wantMeta, resp)
+				}
+				meta = nested
+			}
+			for _, key := range tt.wantKeys {
+				if _, ok := meta[key]; !ok {
+					t.Errorf("missing meta key %q in %v", key, meta)
+				}
+			}
+			if hasMore, ok := meta["hasMore"]; ok && hasMore != true {
+				t.Errorf("hasMore = %v, want true", hasMore)
+			}
+			if total, ok := meta["totalSize"]; ok && total != float64(3) {
+				t.Errorf("totalSize = %v, want 3", total)
+			}
+		})
+	}
+}
+
+func TestPaginationOffset(t *testing.T) {
+	schemaJSON := `{
+		"pagination": {
+			"style": "offset",
+			"defaultLimit": 2,
+			"maxLimit": 10
+		},
+		"entities": {
+			"users": {
+				"fields": {
+					"id":   {"type": "string", "required": true},
+					"name": {"type": "string", "required": true}
+				}
+			}
+		}
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
 
 	// Create 5 users
 	for i := 0; i < 5; i++ {
@@ -779,6 +1234,152 @@ func TestPaginationOffset(t *testing.T) {
 	}
 }
 
+func TestPaginationMaxReportedCount(t *testing.T) {
+	schemaJSON := `{
+		"pagination": {
+			"style": "offset",
+			"defaultLimit": 2,
+			"maxReportedCount": 3,
+			"keys": {"totalCount": "total"}
+		},
+		"entities": {
+			"users": {
+				"fields": {
+					"id":   {"type": "string", "required": true},
+					"name": {"type": "string", "required": true}
+				}
+			}
+		}
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+
+	for i := 0; i < 5; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(fmt.Sprintf(`{"name": "User%d"}`, i)))
+		req.Header.Set("Content-Type", "application/json")
+		srv.mux.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	tests := []struct {
+		query       string
+		wantItems   int
+		wantTotal   float64
+		wantHasMore bool
+	}{
+		{"?offset=0", 2, 3, true},
+		{"?offset=2", 2, 3, true},
+		{"?offset=4", 1, 3, false},
+		{"?offset=0&name=User1", 1, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users"+tt.query, http.NoBody))
+
+			var resp struct {
+				Data []interface{}          `json:"data"`
+				Meta map[string]interface{} `json:"meta"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(resp.Data) != tt.wantItems {
+				t.Errorf("got %d items, want %d", len(resp.Data), tt.wantItems)
+			}
+			if resp.Meta["total"] != tt.wantTotal {
+				t.Errorf("meta.total = %v, want %v", resp.Meta["total"], tt.wantTotal)
+			}
+			if resp.Meta["hasMore"] != tt.wantHasMore {
+				t.Errorf("meta.hasMore = %v, want %v", resp.Meta["hasMore"], tt.wantHasMore)
+			}
+		})
+	}
+}
+
+func TestPaginationPage(t *testing.T) {
+	schemaJSON := `{
+		"pagination": {
+			"style": "page",
+			"defaultLimit": 2,
+			"maxLimit": 10
+		},
+		"entities": {
+			"users": {
+				"fields": {
+					"id":   {"type": "string", "required": true},
+					"name": {"type": "string", "required": true}
+				}
+			}
+		}
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+	for i := 1; i <= 5; i++ {
+		srv.store.Create("users", map[string]interface{}{"name": fmt.Sprintf("User%d", i)})
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		wantNames []string
+		wantMeta  map[string]float64
+	}{
+		{
+			name:      "default first page",
+			query:     "/users",
+			wantNames: []string{"User1", "User2"},
+			wantMeta:  map[string]float64{"page": 1, "per_page": 2, "total_pages": 3, "total": 5},
+		},
+		{
+			name:      "last partial page",
+			query:     "/users?page=3",
+			wantNames: []string{"User5"},
+			wantMeta:  map[string]float64{"page": 3, "per_page": 2, "total_pages": 3, "total": 5},
+		},
+		{
+			name:      "per_page",
+			query:     "/users?page=2&per_page=4",
+			wantNames: []string{"User5"},
+			wantMeta:  map[string]float64{"page": 2, "per_page": 4, "total_pages": 2, "total": 5},
+		},
+		{
+			name:      "out of range page is empty",
+			query:     "/users?page=9",
+			wantNames: nil,
+			wantMeta:  map[string]float64{"page": 9, "per_page": 2, "total_pages": 3, "total": 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.query, http.NoBody)
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+
+			var resp struct {
+				Data []map[string]interface{} `json:"data"`
+				Meta map[string]interface{}   `json:"meta"`
+			}
+			json.NewDecoder(w.Body).Decode(&resp)
+
+			var names []string
+			for _, item := range resp.Data {
+				names = append(names, item["name"].(string))
+			}
+			if fmt.Sprint(names) != fmt.Sprint(tt.wantNames) {
+				t.Errorf("names = %v, want %v", names, tt.wantNames)
+			}
+			for key, want := range tt.wantMeta {
+				if resp.Meta[key] != want {
+					t.Errorf("meta[%q] = %v, want %v", key, resp.Meta[key], want)
+				}
+			}
+		})
+	}
+}
+
 func TestCustomRoutes(t *testing.T) {
 	schemaJSON := `{
 		"entities": {
@@ -904,6 +1505,247 @@ func TestCustomRoutesWithBasePath(t *testing.T) {
 	}
 }
 
+func TestCustomRoutesAlreadyPrefixed(t *testing.T) {
+	schemaJSON := `{
+		"basePath": "/api/v2",
+		"entities": {
+			"tweets": {
+				"fields": {
+					"id":        {"type": "string", "required": true},
+					"author_id": {"type": "string", "required": false}
+				}
+			}
+		},
+		"routes": [
+			{"method": "GET", "path": "/api/v2/authors/:userId/tweets", "entity": "tweets", "filters": {"userId": "author_id"}}
+		]
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+	srv.store.Create("tweets", map[string]interface{}{"author_id": "42"})
+
+	for path, wantStatus := range map[string]int{
+		"/api/v2/authors/42/tweets":        http.StatusOK,
+		"/api/v2/api/v2/authors/42/tweets": http.StatusNotFound,
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		if w.Code != wantStatus {
+			t.Errorf("GET %s: status = %d, want %d", path, w.Code, wantStatus)
+		}
+	}
+}
+
+func TestCustomRouteSingle(t *testing.T) {
+	schemaJSON := `{
+		"entities": {
+			"users": {
+				"fields": {
+					"id":    {"type": "string", "required": true},
+					"email": {"type": "string", "required": true},
+					"team":  {"type": "string"}
+				}
+			}
+		},
+		"routes": [
+			{"method": "GET", "path": "/users/me", "entity": "users", "filters": {"email": "me@example.com"}, "single": true},
+			{"method": "GET", "path": "/teams/:team/lead", "entity": "users", "filters": {"team": "team"}, "single": true},
+			{"method": "GET", "path": "/teams/:team/members", "entity": "users", "filters": {"team": "team"}}
+		]
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+	srv.store.Seed("users", []map[string]interface{}{
+		{"id": "1", "email": "me@example.com", "team": "core"},
+		{"id": "2", "email": "lead@example.com", "team": "docs"},
+		{"id": "3", "email": "other@example.com", "team": "core"},
+	})
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantObject bool
+	}{
+		{path: "/users/me", wantStatus: http.StatusOK, wantObject: true},
+		{path: "/teams/docs/lead", wantStatus: http.StatusOK, wantObject: true},
+		{path: "/teams/none/lead", wantStatus: http.StatusNotFound},
+		{path: "/teams/core/lead", wantStatus: http.StatusConflict},
+		// Without the flag a single match stays a list
+		{path: "/teams/docs/members", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, http.NoBody)
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			body := strings.TrimSpace(w.Body.String())
+			if isObject := strings.HasPrefix(body, "{"); isObject != tt.wantObject {
+				t.Errorf("body = %s, want object response %v", body, tt.wantObject)
+			}
+		})
+	}
+}
+
+func TestCustomRouteCompositeKey(t *testing.T) {
+	schemaJSON := `{
+		"entities": {
+			"users": {
+				"fields": {
+					"id":     {"type": "string", "required": true},
+					"tenant": {"type": "string", "required": true},
+					"number": {"type": "string", "required": true}
+				}
+			}
+		},
+		"routes": [
+			{"method": "GET", "path": "/tenants/:tenant/users/:number", "entity": "users", "single": true}
+		]
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+	srv.store.Seed("users", []map[string]interface{}{
+		{"id": "1", "tenant": "acme", "number": "42"},
+		{"id": "2", "tenant": "globex", "number": "42"},
+		{"id": "3", "tenant": "acme", "number": "7"},
+	})
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantID     string
+	}{
+		{"/tenants/acme/users/42", http.StatusOK, "1"},
+		{"/tenants/globex/users/42", http.StatusOK, "2"},
+		{"/tenants/globex/users/7", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, http.NoBody)
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantID == "" {
+				return
+			}
+			var entity map[string]interface{}
+			if err := json.NewDecoder(w.Body).Decode(&entity); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if entity["id"] != tt.wantID {
+				t.Errorf("id = %v, want %s", entity["id"], tt.wantID)
+			}
+		})
+	}
+}
+
+func TestCustomRouteFixedResponse(t *testing.T) {
+	schemaJSON := `{
+		"entities": {
+			"users": {"fields": {"id": {"type": "string", "required": true}}}
+		},
+		"routes": [
+			{"method": "GET", "path": "/status", "response": {"ok": true, "version": "1.2.0"}},
+			{"method": "POST", "path": "/payments", "response": {"error": "card declined"}, "status": 402},
+			{"method": "DELETE", "path": "/cache", "response": null, "status": 204}
+		]
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+
+	tests := []struct {
+		method     string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{http.MethodGet, "/status", http.StatusOK, `{"ok": true, "version": "1.2.0"}`},
+		{http.MethodPost, "/payments", http.StatusPaymentRequired, `{"error": "card declined"}`},
+		{http.MethodDelete, "/cache", http.StatusNoContent, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, http.NoBody)
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+			if w.Code != http.StatusNoContent {
+				if got := w.Header().Get("Content-Type"); got != "application/json" {
+					t.Errorf("Content-Type = %q, want application/json", got)
+				}
+			}
+		})
+	}
+}
+
+func TestCustomRouteMatchedResponses(t *testing.T) {
+	schemaJSON := `{
+		"entities": {
+			"users": {"fields": {"id": {"type": "string", "required": true}}}
+		},
+		"routes": [
+			{
+				"method": "POST", "path": "/login",
+				"responses": [
+					{"match": {"user": "admin", "password": "secret"}, "response": {"token": "admin-token"}},
+					{"match": {"user": "guest"}, "response": {"token": "guest-token"}, "status": 201},
+					{"match": {"attempts": 3}, "response": {"error": "locked"}, "status": 423}
+				],
+				"response": {"error": "invalid credentials"},
+				"status": 401
+			}
+		]
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"all fields match", `{"user": "admin", "password": "secret"}`, http.StatusOK, `{"token": "admin-token"}`},
+		{"extra body fields are ignored", `{"user": "guest", "remember": true}`, http.StatusCreated, `{"token": "guest-token"}`},
+		{"number equality", `{"user": "bob", "attempts": 3}`, http.StatusLocked, `{"error": "locked"}`},
+		{"partial match falls through", `{"user": "admin", "password": "wrong"}`, http.StatusUnauthorized, `{"error": "invalid credentials"}`},
+		{"types must match", `{"user": "bob", "attempts": "3"}`, http.StatusUnauthorized, `{"error": "invalid credentials"}`},
+		{"non-object body uses default", `["admin"]`, http.StatusUnauthorized, `{"error": "invalid credentials"}`},
+		{"empty body uses default", ``, http.StatusUnauthorized, `{"error": "invalid credentials"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
 func TestCustomRouteMethodRestriction(t *testing.T) {
 	schemaJSON := `{
 		"entities": {
@@ -936,6 +1778,58 @@ func TestCustomRouteMethodRestriction(t *testing.T) {
 	}
 }
 
+func TestCustomRouteMethodNotAllowed(t *testing.T) {
+	schemaJSON := `{
+		"basePath": "/api",
+		"entities": {
+			"users": {
+				"fields": {
+					"id":   {"type": "string", "required": true},
+					"name": {"type": "string", "required": true}
+				}
+			}
+		},
+		"routes": [
+			{"method": "GET", "path": "/users/:userId/profile", "entity": "users", "filters": {"userId": "id"}},
+			{"method": "GET", "path": "/featured", "entity": "users"},
+			{"method": "DELETE", "path": "/featured", "entity": "users"},
+			{"method": "GET", "path": "/users/:id", "entity": "users"}
+		]
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+	srv.store.Seed("users", []map[string]interface{}{{"id": "1", "name": "Alice"}})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantAllow  string
+	}{
+		{"GET-only route hit with POST", http.MethodPost, "/api/users/1/profile", http.StatusMethodNotAllowed, "GET, HEAD"},
+		{"declared method still works", http.MethodGet, "/api/users/1/profile", http.StatusOK, ""},
+		{"allow lists every bound method", http.MethodPut, "/api/featured", http.StatusMethodNotAllowed, "DELETE, GET, HEAD"},
+		{"generated item route keeps its methods", http.MethodPatch, "/api/users/1", http.StatusOK, ""},
+		{"unknown path is still 404", http.MethodPost, "/api/nowhere", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"name": "Bob"}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d, body: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if got := w.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+		})
+	}
+}
+
 func TestRespondError(t *testing.T) {
 	server := setupTestServer(t)
 
@@ -954,3 +1848,184 @@ func TestRespondError(t *testing.T) {
 		t.Errorf("body = %s, want to contain 'error' key", body)
 	}
 }
+
+func TestSelectQueryParam(t *testing.T) {
+	schemaJSON := `{
+		"responseWrapper": {
+			"list": {"items": "$entities", "meta": {"result_count": "$count"}}
+		},
+		"entities": {
+			"users": {
+				"fields": {
+					"id":   {"type": "string", "required": true},
+					"name": {"type": "string", "required": true}
+				}
+			}
+		}
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+	srv.store.Create("users", map[string]interface{}{"name": "Alice"})
+	srv.store.Create("users", map[string]interface{}{"name": "Bob"})
+
+	// Projection composes with the list wrapper
+	req := httptest.NewRequest(http.MethodGet, "/users?select=$.items[*].name", http.NoBody)
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d, body: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var names []string
+	if err := json.NewDecoder(w.Body).Decode(&names); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(names) != 2 || names[0] != "Alice" || names[1] != "Bob" {
+		t.Errorf("names = %v, want [Alice Bob]", names)
+	}
+
+	// Invalid expressions are rejected before the handler runs
+	createReq := httptest.NewRequest(http.MethodPost, "/users?select=items", strings.NewReader(`{"name": "Carol"}`))
+	createReq.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, createReq)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid select: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if items, _ := srv.store.List("users"); len(items) != 2 {
+		t.Errorf("invalid select should not create an entity, got %d users", len(items))
+	}
+
+	// Without select the full response is returned
+	req = httptest.NewRequest(http.MethodGet, "/users", http.NoBody)
+	w = httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+
+	var full map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&full)
+	if _, ok := full["meta"]; !ok {
+		t.Errorf("response without select should be unmodified, got: %v", full)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	loader := setupTestSchema(t)
+	store := storage.NewInMemoryStore()
+	store.Initialize(loader.GetEntityNames())
+	routeMap, _ := loader.BuildRouteMap()
+	srv := NewWithOptions(8080, store, routeMap, loader, Options{RequestTimeout: 20 * time.Millisecond})
+
+	slow := srv.withMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+	fast := srv.withMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	slow(w, httptest.NewRequest(http.MethodGet, "/slow", http.NoBody))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("slow handler: status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if !strings.Contains(w.Body.String(), "Request timed out") {
+		t.Errorf("slow handler: body = %s, want timeout error", w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("slow handler: Content-Type = %q, want application/json", got)
+	}
+
+	w = httptest.NewRecorder()
+	fast(w, httptest.NewRequest(http.MethodGet, "/fast", http.NoBody))
+	if w.Code != http.StatusOK {
+		t.Errorf("fast handler: status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestMiddleware_RecoversPanic(t *testing.T) {
+	loader := setupTestSchema(t)
+	store := storage.NewInMemoryStore()
+	store.Initialize(loader.GetEntityNames())
+	routeMap, _ := loader.BuildRouteMap()
+
+	panicking := func(w http.ResponseWriter, r *http.Request) {
+		var id interface{} = 123
+		_ = id.(string)
+	}
+	partial := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		panic("after the response started")
+	}
+
+	for _, timeout := range []time.Duration{0, time.Second} {
+		srv := NewWithOptions(8080, store, routeMap, loader, Options{RequestTimeout: timeout})
+
+		for name, handler := range map[string]http.HandlerFunc{
+			"generated": srv.withMiddleware(panicking),
+			"reserved":  srv.withReservedMiddleware(panicking),
+		} {
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(http.MethodGet, "/users", http.NoBody))
+			if w.Code != http.StatusInternalServerError {
+				t.Errorf("%s (timeout %v): status = %d, want %d", name, timeout, w.Code, http.StatusInternalServerError)
+			}
+			if !strings.Contains(w.Body.String(), "Internal server error") {
+				t.Errorf("%s (timeout %v): body = %s, want JSON error", name, timeout, w.Body.String())
+			}
+		}
+	}
+
+	// A panic after the status was sent can't change it
+	srv := NewWithOptions(8080, store, routeMap, loader, Options{})
+	w := httptest.NewRecorder()
+	srv.withMiddleware(partial)(w, httptest.NewRequest(http.MethodGet, "/users", http.NoBody))
+	if w.Code != http.StatusOK {
+		t.Errorf("partial: status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestStartOpensBrowser(t *testing.T) {
+	opened := make(chan string, 1)
+	original := openBrowser
+	openBrowser = func(url string) error {
+		opened <- url
+		return fmt.Errorf("no browser available")
+	}
+	defer func() { openBrowser = original }()
+
+	srv := setupTestServerWithOptions(t, Options{OpenBrowser: true})
+	srv.port = 0 // pick a free port
+
+	done := make(chan error, 1)
+	go func() { done <- srv.Start() }()
+
+	var url string
+	select {
+	case url = <-opened:
+	case <-time.After(5 * time.Second):
+		t.Fatal("browser was not opened after the server started listening")
+	}
+	if !strings.HasPrefix(url, "http://localhost:") || !strings.HasSuffix(url, "/__routes") {
+		t.Errorf("opened %q, want the /__routes URL", url)
+	}
+
+	// A failed open leaves the server running
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET %s: status = %d, want %d", url, resp.StatusCode, http.StatusOK)
+	}
+
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Start() error = %v", err)
+	}
+}
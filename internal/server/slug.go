@@ -0,0 +1,54 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/ticktockbent/ape_my/internal/schema"
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+// applySlugs fills each absent slugFrom field from its source field, adding
+// -2, -3, ... until no stored entity has the slug. Client-supplied slugs are
+// kept as they are. It returns the fields it filled, which createEntity
+// fills again under the entity's slug lock so that the check and the insert
+// happen together. Entities still hidden by consistencyLag are not seen by
+// the check.
+func (s *Server) applySlugs(entityName string, data map[string]interface{}) []string {
+	entity, exists := s.validator.loader.GetEntity(entityName)
+	if !exists {
+		return nil
+	}
+	var filled []string
+	for fieldName, field := range entity.Fields {
+		if field.SlugFrom == "" {
+			continue
+		}
+		if _, present := data[fieldName]; present {
+			continue
+		}
+		source, ok := data[field.SlugFrom].(string)
+		if !ok {
+			continue
+		}
+		slug := schema.Slugify(source)
+		if slug == "" {
+			continue
+		}
+		candidate := slug
+		for n := 2; s.slugTaken(entityName, fieldName, candidate); n++ {
+			candidate = fmt.Sprintf("%s-%d", slug, n)
+		}
+		data[fieldName] = candidate
+		filled = append(filled, fieldName)
+	}
+	return filled
+}
+
+// slugTaken reports whether a stored entity already uses slug in fieldName
+func (s *Server) slugTaken(entityName, fieldName, slug string) bool {
+	result, err := s.store.ListQuery(entityName, types.QueryOpts{
+		Filters: map[string]string{fieldName: slug},
+		Limit:   1,
+	})
+	return err == nil && result.TotalCount > 0
+}
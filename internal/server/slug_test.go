@@ -0,0 +1,97 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSlugFrom(t *testing.T) {
+	srv := setupTestServerWithSchema(t, `{
+		"entities": {
+			"posts": {
+				"fields": {
+					"id":    {"type": "string", "required": true},
+					"title": {"type": "string", "required": true},
+					"slug":  {"type": "string", "required": true, "slugFrom": "title"}
+				}
+			}
+		}
+	}`)
+
+	create := func(body string) (int, map[string]interface{}) {
+		req := httptest.NewRequest(http.MethodPost, "/posts", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		var entity map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&entity)
+		return w.Code, entity
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantSlug   interface{}
+	}{
+		{"derived from title", `{"title": "Hello, World!"}`, http.StatusCreated, "hello-world"},
+		{"collision gets a suffix", `{"title": "Hello World"}`, http.StatusCreated, "hello-world-2"},
+		{"second collision", `{"title": "hello world"}`, http.StatusCreated, "hello-world-3"},
+		{"client slug is kept", `{"title": "Hello World", "slug": "hello-world"}`, http.StatusCreated, "hello-world"},
+		{"missing source leaves required slug missing", `{"title": "!!!"}`, http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, entity := create(tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %v", status, tt.wantStatus, entity)
+			}
+			if tt.wantSlug != nil && entity["slug"] != tt.wantSlug {
+				t.Errorf("slug = %v, want %v", entity["slug"], tt.wantSlug)
+			}
+		})
+	}
+}
+
+func TestSlugFromConcurrentCreates(t *testing.T) {
+	srv := setupTestServerWithSchema(t, `{
+		"entities": {
+			"posts": {
+				"fields": {
+					"id":    {"type": "string", "required": true},
+					"title": {"type": "string", "required": true},
+					"slug":  {"type": "string", "slugFrom": "title"}
+				}
+			}
+		}
+	}`)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/posts", strings.NewReader(`{"title": "Hello"}`))
+			req.Header.Set("Content-Type", "application/json")
+			srv.mux.ServeHTTP(httptest.NewRecorder(), req)
+		}()
+	}
+	wg.Wait()
+
+	posts, _ := srv.store.List("posts")
+	seen := make(map[interface{}]bool)
+	for _, post := range posts {
+		if seen[post["slug"]] {
+			t.Errorf("slug %v generated twice", post["slug"])
+		}
+		seen[post["slug"]] = true
+	}
+	if len(posts) != 20 {
+		t.Errorf("got %d posts, want 20", len(posts))
+	}
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"net/http"
+	"path"
+)
+
+// withStatic serves files from dir for GET and HEAD requests that no API
+// route matched, falling back to notFound when there is no such file. Like
+// built-in endpoints, static files skip auth and the schema middleware.
+func (s *Server) withStatic(dir string, notFound http.HandlerFunc) http.HandlerFunc {
+	root := http.Dir(dir)
+	files := http.FileServer(root)
+	serveFile := s.withReservedMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		// Let the file server pick the type from the file name
+		w.Header().Del("Content-Type")
+		files.ServeHTTP(w, r)
+	})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if (r.Method == http.MethodGet || r.Method == http.MethodHead) && staticFileExists(root, r.URL.Path) {
+			serveFile(w, r)
+			return
+		}
+		notFound(w, r)
+	}
+}
+
+// staticFileExists reports whether name is a file under root, or a directory
+// with an index.html. Directories are never listed.
+func staticFileExists(root http.FileSystem, name string) bool {
+	file, err := root.Open(path.Clean("/" + name))
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	if !info.IsDir() {
+		return true
+	}
+	return staticFileExists(root, path.Join(name, "index.html"))
+}
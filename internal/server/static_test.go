@@ -0,0 +1,75 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStaticFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("<h1>demo</h1>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "js"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "js", "app.js"), []byte("fetch('/users')"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A file shadowed by the users collection is never served
+	if err := os.WriteFile(filepath.Join(dir, "users"), []byte("static users"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := setupTestServerWithOptions(t, Options{StaticDir: dir})
+
+	tests := []struct {
+		name            string
+		method          string
+		path            string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{"index", http.MethodGet, "/", http.StatusOK, "text/html", "<h1>demo</h1>"},
+		{"nested file", http.MethodGet, "/js/app.js", http.StatusOK, "javascript", "fetch('/users')"},
+		{"api route wins", http.MethodGet, "/users", http.StatusOK, "application/json", "[]"},
+		{"directory without index", http.MethodGet, "/js/", http.StatusNotFound, "application/json", "Route not found"},
+		{"missing file", http.MethodGet, "/missing.html", http.StatusNotFound, "application/json", "Route not found"},
+		{"write to static path", http.MethodPost, "/js/app.js", http.StatusNotFound, "application/json", "Route not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, http.NoBody)
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if got := w.Header().Get("Content-Type"); !strings.Contains(got, tt.wantContentType) {
+				t.Errorf("Content-Type = %q, want it to contain %q", got, tt.wantContentType)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestStaticFilesDisabled(t *testing.T) {
+	srv := setupTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,220 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ticktockbent/ape_my/internal/schema"
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+// shapeEntity applies output-only field transformations (random, mask, redact, fieldCase) to an
+// entity. The stored entity is never modified; a copy is returned when any
+// field needs transforming.
+func (s *Server) shapeEntity(entityName string, entity map[string]interface{}) map[string]interface{} {
+	if s.schema == nil || entity == nil {
+		return entity
+	}
+	def, exists := s.schema.Entities[entityName]
+	if !exists {
+		return entity
+	}
+	entity = fillRandomFields(entityName, def, entity)
+
+	var shaped map[string]interface{}
+	for fieldName, field := range def.Fields {
+		if !field.Redact && field.Mask == "" {
+			continue
+		}
+		value, present := entity[fieldName]
+		if !present {
+			continue
+		}
+		if shaped == nil {
+			shaped = make(map[string]interface{}, len(entity))
+			for k, v := range entity {
+				shaped[k] = v
+			}
+		}
+		if field.Redact {
+			delete(shaped, fieldName)
+			continue
+		}
+		if str, ok := value.(string); ok {
+			shaped[fieldName] = maskString(field.Mask, str)
+		}
+	}
+
+	if links := s.entityLinks(entityName, def, entity); links != nil {
+		if shaped == nil {
+			shaped = make(map[string]interface{}, len(entity)+1)
+			for k, v := range entity {
+				shaped[k] = v
+			}
+		}
+		shaped[linksField] = links
+	}
+
+	if shaped == nil {
+		shaped = entity
+	}
+	return s.outboundFields(entityName, shaped)
+}
+
+// fillRandomFields returns the entity with absent random fields generated from
+// its id. Stored values, including explicit nulls, are left as they are.
+func fillRandomFields(entityName string, def *types.Entity, entity map[string]interface{}) map[string]interface{} {
+	var filled map[string]interface{}
+	for fieldName, field := range def.Fields {
+		if !field.Random {
+			continue
+		}
+		if _, present := entity[fieldName]; present {
+			continue
+		}
+		if filled == nil {
+			filled = make(map[string]interface{}, len(entity)+1)
+			for k, v := range entity {
+				filled[k] = v
+			}
+		}
+		filled[fieldName] = schema.RandomValue(field, entityName, fmt.Sprintf("%v", entity["id"]), fieldName)
+	}
+	if filled == nil {
+		return entity
+	}
+	return filled
+}
+
+// linksField is the response field carrying HATEOAS links
+const linksField = "_links"
+
+// defaultLinksTemplate is used when an entity sets "links": true
+var defaultLinksTemplate = map[string]interface{}{
+	"self":       map[string]interface{}{"href": "$self"},
+	"collection": map[string]interface{}{"href": "$collection"},
+}
+
+// entityLinks builds the _links value for an entity from its links setting
+// and, with autoLinks, its foreign key fields. It returns nil when there are none.
+func (s *Server) entityLinks(entityName string, def *types.Entity, entity map[string]interface{}) interface{} {
+	links := s.templateLinks(entityName, def, entity)
+	if !s.schema.AutoLinks {
+		return links
+	}
+	related := s.foreignKeyLinks(def, entity)
+	if len(related) == 0 {
+		return links
+	}
+	merged, ok := links.(map[string]interface{})
+	if !ok {
+		merged = make(map[string]interface{}, len(related))
+	}
+	for name, link := range related {
+		// Links named in the template take precedence
+		if _, taken := merged[name]; !taken {
+			merged[name] = link
+		}
+	}
+	return merged
+}
+
+// foreignKeyLinks returns a link for each field such as authorId or author_id
+// whose value is set and whose name matches an entity by convention (author,
+// authors, ...). Fields that match nothing are skipped.
+func (s *Server) foreignKeyLinks(def *types.Entity, entity map[string]interface{}) map[string]interface{} {
+	var links map[string]interface{}
+	for fieldName := range def.Fields {
+		name, isKey := strings.CutSuffix(fieldName, "Id")
+		if !isKey {
+			name, isKey = strings.CutSuffix(fieldName, "_id")
+		}
+		if !isKey || name == "" {
+			continue
+		}
+		value, present := entity[fieldName]
+		if !present || value == nil || value == "" {
+			continue
+		}
+		route := s.relatedRoute(name)
+		if route == nil {
+			continue
+		}
+		if links == nil {
+			links = make(map[string]interface{})
+		}
+		links[name] = map[string]interface{}{"href": fmt.Sprintf("%s/%v", route.CollectionPath, value)}
+	}
+	return links
+}
+
+// relatedRoute finds the entity routes for a singular name such as "author"
+// or "category", trying the name as is and its common plural forms
+func (s *Server) relatedRoute(name string) *schema.RouteInfo {
+	candidates := []string{name, name + "s", name + "es"}
+	if stem, ok := strings.CutSuffix(name, "y"); ok {
+		candidates = append(candidates, stem+"ies")
+	}
+	for _, candidate := range candidates {
+		if route, exists := s.routeMap[candidate]; exists {
+			return route
+		}
+	}
+	return nil
+}
+
+// templateLinks builds the _links value from the entity's links setting, or
+// returns nil when links are off
+func (s *Server) templateLinks(entityName string, def *types.Entity, entity map[string]interface{}) interface{} {
+	template := def.Links
+	if enabled, ok := template.(bool); ok {
+		if !enabled {
+			return nil
+		}
+		template = defaultLinksTemplate
+	}
+	if template == nil {
+		return nil
+	}
+
+	route, exists := s.routeMap[entityName]
+	if !exists {
+		return nil
+	}
+	id, _ := entity["id"].(string)
+	return applyTemplate(template, map[string]interface{}{
+		"$self":       route.CollectionPath + "/" + id,
+		"$collection": route.CollectionPath,
+	})
+}
+
+// shapeEntities applies shapeEntity to each item of a list
+func (s *Server) shapeEntities(entityName string, entities []map[string]interface{}) []map[string]interface{} {
+	shaped := make([]map[string]interface{}, len(entities))
+	for i, entity := range entities {
+		shaped[i] = s.shapeEntity(entityName, entity)
+	}
+	return shaped
+}
+
+// maskString hides part of a string value according to the mask style
+func maskString(mask, value string) string {
+	runes := []rune(value)
+	switch mask {
+	case types.MaskEmail:
+		at := strings.LastIndex(value, "@")
+		if at <= 0 {
+			return maskString(types.MaskAll, value)
+		}
+		local := []rune(value[:at])
+		return string(local[0]) + "***" + value[at:]
+	case types.MaskLast4:
+		if len(runes) <= 4 {
+			return strings.Repeat("*", len(runes))
+		}
+		return strings.Repeat("*", len(runes)-4) + string(runes[len(runes)-4:])
+	case types.MaskAll:
+		return strings.Repeat("*", len(runes))
+	}
+	return value
+}
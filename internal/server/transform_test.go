@@ -0,0 +1,214 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestMaskString(t *testing.T) {
+	tests := []struct {
+		name  string
+		mask  string
+		value string
+		want  string
+	}{
+		{"email", "email", "alice@example.com", "a***@example.com"},
+		{"email without at", "email", "alice", "*****"},
+		{"email starting with at", "email", "@example.com", "************"},
+		{"last4", "last4", "123-45-6789", "*******6789"},
+		{"last4 short value", "last4", "123", "***"},
+		{"all", "all", "secret", "******"},
+		{"empty", "all", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maskString(tt.mask, tt.value); got != tt.want {
+				t.Errorf("maskString(%q, %q) = %q, want %q", tt.mask, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskAndRedactResponses(t *testing.T) {
+	schemaJSON := `{
+		"entities": {
+			"users": {
+				"fields": {
+					"id":    {"type": "string", "required": true},
+					"name":  {"type": "string", "required": true},
+					"email": {"type": "string", "mask": "email"},
+					"ssn":   {"type": "string", "redact": true}
+				}
+			}
+		}
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+	srv.store.Seed("users", []map[string]interface{}{
+		{"id": "1", "name": "Alice", "email": "alice@example.com", "ssn": "123-45-6789"},
+	})
+
+	// Single entity
+	req := httptest.NewRequest(http.MethodGet, "/users/1", http.NoBody)
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+
+	var user map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&user)
+	if user["email"] != "a***@example.com" {
+		t.Errorf("email = %v, want masked", user["email"])
+	}
+	if _, ok := user["ssn"]; ok {
+		t.Errorf("ssn should be redacted, got %v", user["ssn"])
+	}
+
+	// List
+	req = httptest.NewRequest(http.MethodGet, "/users", http.NoBody)
+	w = httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+
+	var users []map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&users)
+	if len(users) != 1 || users[0]["email"] != "a***@example.com" {
+		t.Errorf("list response not masked: %v", users)
+	}
+
+	// Stored values are untouched
+	stored, _ := srv.store.Get("users", "1")
+	if stored["email"] != "alice@example.com" || stored["ssn"] != "123-45-6789" {
+		t.Errorf("stored entity was modified: %v", stored)
+	}
+
+	// Composes with ?select
+	req = httptest.NewRequest(http.MethodGet, "/users/1?select=$.email", http.NoBody)
+	w = httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+
+	var email string
+	json.NewDecoder(w.Body).Decode(&email)
+	if email != "a***@example.com" {
+		t.Errorf("select on masked field = %q, want masked", email)
+	}
+}
+
+func TestEntityLinks(t *testing.T) {
+	schemaJSON := `{
+		"basePath": "/api/v1",
+		"entities": {
+			"users": {
+				"links": true,
+				"fields": {"id": {"type": "string", "required": true}}
+			},
+			"posts": {
+				"links": {"self": "$self", "all": "$collection", "kind": "post"},
+				"fields": {"id": {"type": "string", "required": true}}
+			},
+			"tags": {
+				"fields": {"id": {"type": "string", "required": true}}
+			}
+		}
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+	srv.store.Seed("users", []map[string]interface{}{{"id": "1"}})
+	srv.store.Seed("posts", []map[string]interface{}{{"id": "7"}})
+	srv.store.Seed("tags", []map[string]interface{}{{"id": "go"}})
+
+	get := func(path string, v interface{}) {
+		req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: status = %d, want %d", path, w.Code, http.StatusOK)
+		}
+		json.Unmarshal(w.Body.Bytes(), v)
+	}
+
+	var user map[string]interface{}
+	get("/api/v1/users/1", &user)
+	want := map[string]interface{}{
+		"self":       map[string]interface{}{"href": "/api/v1/users/1"},
+		"collection": map[string]interface{}{"href": "/api/v1/users"},
+	}
+	if !reflect.DeepEqual(user["_links"], want) {
+		t.Errorf("_links = %v, want %v", user["_links"], want)
+	}
+
+	var posts []map[string]interface{}
+	get("/api/v1/posts", &posts)
+	want = map[string]interface{}{"self": "/api/v1/posts/7", "all": "/api/v1/posts", "kind": "post"}
+	if len(posts) != 1 || !reflect.DeepEqual(posts[0]["_links"], want) {
+		t.Errorf("list _links = %v, want %v", posts, want)
+	}
+
+	var tag map[string]interface{}
+	get("/api/v1/tags/go", &tag)
+	if _, exists := tag["_links"]; exists {
+		t.Error("links should be off by default")
+	}
+
+	// Links are output-only
+	stored, _ := srv.store.Get("users", "1")
+	if _, exists := stored["_links"]; exists {
+		t.Error("_links should not be stored")
+	}
+}
+
+func TestAutoLinks(t *testing.T) {
+	schemaJSON := `{
+		"basePath": "/api",
+		"autoLinks": true,
+		"entities": {
+			"authors": {"fields": {"id": {"type": "string", "required": true}}},
+			"categories": {"fields": {"id": {"type": "string", "required": true}}},
+			"posts": {
+				"links": true,
+				"fields": {
+					"id":          {"type": "string", "required": true},
+					"authorId":    {"type": "string"},
+					"category_id": {"type": "string"},
+					"editorId":    {"type": "string"},
+					"reviewerId":  {"type": "string"}
+				}
+			}
+		}
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+	srv.store.Seed("posts", []map[string]interface{}{
+		{"id": "1", "authorId": "5", "category_id": "news", "editorId": "9"},
+		{"id": "2"},
+	})
+
+	get := func(path string) map[string]interface{} {
+		req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: status = %d, want %d", path, w.Code, http.StatusOK)
+		}
+		var entity map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &entity)
+		return entity
+	}
+
+	// editorId has no matching entity and reviewerId is unset: both are skipped
+	want := map[string]interface{}{
+		"self":       map[string]interface{}{"href": "/api/posts/1"},
+		"collection": map[string]interface{}{"href": "/api/posts"},
+		"author":     map[string]interface{}{"href": "/api/authors/5"},
+		"category":   map[string]interface{}{"href": "/api/categories/news"},
+	}
+	if got := get("/api/posts/1")["_links"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("_links = %v, want %v", got, want)
+	}
+
+	want = map[string]interface{}{
+		"self":       map[string]interface{}{"href": "/api/posts/2"},
+		"collection": map[string]interface{}{"href": "/api/posts"},
+	}
+	if got := get("/api/posts/2")["_links"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("_links without foreign keys = %v, want %v", got, want)
+	}
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"encoding/base64"
 	"fmt"
 
 	"github.com/ticktockbent/ape_my/internal/schema"
@@ -26,7 +27,7 @@ func (v *Validator) ValidateCreate(entityName string, data map[string]interface{
 		return fmt.Errorf("entity type %q not found in schema", entityName)
 	}
 
-	return v.validateEntityData(entity, data, true)
+	return v.validateEntityData(entity, data, true, true)
 }
 
 // ValidateUpdate validates data for updating an entity (PUT)
@@ -36,7 +37,7 @@ func (v *Validator) ValidateUpdate(entityName string, data map[string]interface{
 		return fmt.Errorf("entity type %q not found in schema", entityName)
 	}
 
-	return v.validateEntityData(entity, data, true)
+	return v.validateEntityData(entity, data, true, false)
 }
 
 // ValidatePatch validates data for patching an entity (PATCH)
@@ -47,11 +48,30 @@ func (v *Validator) ValidatePatch(entityName string, data map[string]interface{}
 	}
 
 	// For PATCH, required fields are not required (partial update)
-	return v.validateEntityData(entity, data, false)
+	return v.validateEntityData(entity, data, false, false)
 }
 
-// validateEntityData validates entity data against schema
-func (v *Validator) validateEntityData(entity *types.Entity, data map[string]interface{}, checkRequired bool) error {
+// ValidateFieldCount enforces the entity's maxFields on a request body. It
+// runs on the body as the client sent it, before the server adds slugs or
+// carries writeOnce values over, since those are not the client's fields.
+func (v *Validator) ValidateFieldCount(entityName string, data map[string]interface{}) error {
+	entity, exists := v.loader.GetEntity(entityName)
+	if !exists || entity.MaxFields <= 0 {
+		return nil
+	}
+	count := len(data)
+	if _, hasID := data["id"]; hasID {
+		count--
+	}
+	if count > entity.MaxFields {
+		return fmt.Errorf("request has %d fields, more than the maximum of %d", count, entity.MaxFields)
+	}
+	return nil
+}
+
+// validateEntityData validates entity data against schema. When defaultsApply
+// is set (creates), required fields with a default may be omitted.
+func (v *Validator) validateEntityData(entity *types.Entity, data map[string]interface{}, checkRequired, defaultsApply bool) error {
 	// Check required fields (except for PATCH)
 	if checkRequired {
 		for fieldName, field := range entity.Fields {
@@ -60,7 +80,7 @@ func (v *Validator) validateEntityData(entity *types.Entity, data map[string]int
 				continue
 			}
 
-			if field.Required {
+			if field.Required && !(defaultsApply && field.Default != nil) {
 				if _, exists := data[fieldName]; !exists {
 					return fmt.Errorf("required field %q is missing", fieldName)
 				}
@@ -70,8 +90,11 @@ func (v *Validator) validateEntityData(entity *types.Entity, data map[string]int
 
 	// Validate field types
 	for fieldName, value := range data {
-		// Skip ID field
+		// IDs may be omitted or null, but a provided one must be a string
 		if fieldName == "id" {
+			if _, isString := value.(string); value != nil && !isString {
+				return fmt.Errorf("id must be a string")
+			}
 			continue
 		}
 
@@ -86,6 +109,11 @@ func (v *Validator) validateEntityData(entity *types.Entity, data map[string]int
 		if err := validateFieldType(field.Type, value); err != nil {
 			return fmt.Errorf("field %q: %w", fieldName, err)
 		}
+
+		// Validate constraints such as size limits
+		if err := schema.CheckFieldConstraints(field, value); err != nil {
+			return fmt.Errorf("field %q: %w", fieldName, err)
+		}
 	}
 
 	return nil
@@ -103,10 +131,18 @@ func validateFieldType(expectedType string, value interface{}) error {
 			return fmt.Errorf("expected string, got %T", value)
 		}
 	case types.FieldTypeNumber:
-		// JSON numbers are float64
-		if _, ok := value.(float64); !ok {
+		// JSON numbers are decoded as json.Number (or float64)
+		if _, ok := schema.NumberValue(value); !ok {
 			return fmt.Errorf("expected number, got %T", value)
 		}
+	case types.FieldTypeInteger:
+		// Reject fractional values
+		if _, ok := schema.NumberValue(value); !ok {
+			return fmt.Errorf("expected integer, got %T", value)
+		}
+		if !schema.IsWholeNumber(value) {
+			return fmt.Errorf("expected integer, got %v", value)
+		}
 	case types.FieldTypeBoolean:
 		if _, ok := value.(bool); !ok {
 			return fmt.Errorf("expected boolean, got %T", value)
@@ -119,6 +155,19 @@ func validateFieldType(expectedType string, value interface{}) error {
 		if _, ok := value.([]interface{}); !ok {
 			return fmt.Errorf("expected array, got %T", value)
 		}
+	case types.FieldTypeBinary:
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("expected base64 string, got %T", value)
+		}
+		if _, err := base64.StdEncoding.DecodeString(str); err != nil {
+			return fmt.Errorf("expected valid base64: %w", err)
+		}
+	case types.FieldTypeDatetime:
+		// Layout parsing happens in schema.CheckFieldConstraints
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("expected datetime string, got %T", value)
+		}
 	default:
 		return fmt.Errorf("unknown field type: %s", expectedType)
 	}
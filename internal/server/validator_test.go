@@ -1,6 +1,10 @@
 package server
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -66,6 +70,24 @@ func TestValidateCreate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name:       "numeric id",
+			entityName: "users",
+			data: map[string]interface{}{
+				"id":   json.Number("123"),
+				"name": "Alice",
+			},
+			wantErr: true,
+		},
+		{
+			name:       "null id allowed",
+			entityName: "users",
+			data: map[string]interface{}{
+				"id":   nil,
+				"name": "Alice",
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -193,6 +215,17 @@ func TestValidateFieldType(t *testing.T) {
 		{"valid number", "number", float64(42), false},
 		{"invalid number", "number", "not a number", true},
 
+		// Integer tests
+		{"valid integer", "integer", float64(42), false},
+		{"negative integer", "integer", float64(-7), false},
+		{"fractional integer", "integer", 3.5, true},
+		{"string integer", "integer", "42", true},
+
+		// json.Number values from UseNumber decoding
+		{"json.Number number", "number", json.Number("3.14"), false},
+		{"json.Number integer", "integer", json.Number("9007199254740993"), false},
+		{"json.Number fractional integer", "integer", json.Number("2.5"), true},
+
 		// Boolean tests
 		{"valid boolean", "boolean", true, false},
 		{"invalid boolean", "boolean", "not a bool", true},
@@ -205,6 +238,15 @@ func TestValidateFieldType(t *testing.T) {
 		{"valid array", "array", []interface{}{1, 2, 3}, false},
 		{"invalid array", "array", map[string]interface{}{}, true},
 
+		// Binary tests
+		{"valid binary", "binary", "aGVsbG8=", false},
+		{"malformed binary", "binary", "not base64!", true},
+		{"non-string binary", "binary", float64(1), true},
+
+		// Datetime tests (layout checked separately)
+		{"datetime string", "datetime", "2024-01-02T15:04:05Z", false},
+		{"datetime non-string", "datetime", float64(1), true},
+
 		// Null values
 		{"null string", "string", nil, false},
 		{"null number", "number", nil, false},
@@ -236,3 +278,204 @@ func TestNewValidator(t *testing.T) {
 		t.Error("expected validator.loader to not be nil")
 	}
 }
+
+func TestValidateBinaryMaxSize(t *testing.T) {
+	schemaJSON := `{
+		"entities": {
+			"avatars": {
+				"fields": {
+					"id":   {"type": "string", "required": true},
+					"data": {"type": "binary", "required": true, "maxSize": 5}
+				}
+			}
+		}
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"within limit", "aGVsbG8=", false},      // "hello", 5 bytes
+		{"over limit", "aGVsbG8gd29ybGQ=", true}, // "hello world", 11 bytes
+		{"malformed", "%%%", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := srv.validator.ValidateCreate("avatars", map[string]interface{}{"data": tt.data})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateDatetime(t *testing.T) {
+	schemaJSON := `{
+		"entities": {
+			"events": {
+				"fields": {
+					"id":    {"type": "string", "required": true},
+					"start": {"type": "datetime", "required": true},
+					"day":   {"type": "datetime", "layout": "2006-01-02"}
+				}
+			}
+		}
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+
+	tests := []struct {
+		name    string
+		data    map[string]interface{}
+		wantErr string
+	}{
+		{"valid RFC3339", map[string]interface{}{"start": "2024-03-01T09:30:00Z"}, ""},
+		{"valid with offset", map[string]interface{}{"start": "2024-03-01T09:30:00+02:00"}, ""},
+		{"typo in date", map[string]interface{}{"start": "2024-13-01T09:30:00Z"}, "expected datetime in format"},
+		{"date only for RFC3339", map[string]interface{}{"start": "2024-03-01"}, "expected datetime in format"},
+		{"custom layout", map[string]interface{}{"start": "2024-03-01T09:30:00Z", "day": "2024-03-01"}, ""},
+		{"custom layout mismatch", map[string]interface{}{"start": "2024-03-01T09:30:00Z", "day": "03/01/2024"}, "2006-01-02"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := srv.validator.ValidateCreate("events", tt.data)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateCreate() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateCreate() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateNumericBounds(t *testing.T) {
+	schemaJSON := `{
+		"entities": {
+			"people": {
+				"fields": {
+					"id":     {"type": "string", "required": true},
+					"age":    {"type": "integer", "min": 0, "max": 150},
+					"rating": {"type": "number", "max": 5}
+				}
+			}
+		}
+	}`
+	srv := setupTestServerWithSchema(t, schemaJSON)
+
+	tests := []struct {
+		name    string
+		data    map[string]interface{}
+		wantErr string
+	}{
+		{"within bounds", map[string]interface{}{"age": float64(30), "rating": 4.5}, ""},
+		{"integer at bounds", map[string]interface{}{"age": float64(0)}, ""},
+		{"integer below min", map[string]interface{}{"age": float64(-1)}, "less than min"},
+		{"integer above max", map[string]interface{}{"age": float64(151)}, "greater than max"},
+		{"fractional integer", map[string]interface{}{"age": 30.5}, "expected integer"},
+		{"number above max", map[string]interface{}{"rating": 5.1}, "greater than max"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := srv.validator.ValidatePatch("people", tt.data)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidatePatch() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidatePatch() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateMaxFields(t *testing.T) {
+	srv := setupTestServerWithSchema(t, `{
+		"entities": {
+			"users": {
+				"maxFields": 2,
+				"fields": {
+					"id":   {"type": "string", "required": true},
+					"name": {"type": "string", "required": true}
+				}
+			}
+		}
+	}`)
+
+	tests := []struct {
+		name    string
+		data    map[string]interface{}
+		wantErr bool
+	}{
+		{"at the cap", map[string]interface{}{"name": "Alice", "age": 30}, false},
+		{"id is not counted", map[string]interface{}{"id": "1", "name": "Alice", "age": 30}, false},
+		{"undeclared fields count too", map[string]interface{}{"name": "Alice", "age": 30, "extra": true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := srv.validator.ValidateFieldCount("users", tt.data); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateFieldCount() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Posting more fields than the cap is a 400
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name": "Alice", "a": 1, "b": 2}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("POST status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "more than the maximum of 2") {
+		t.Errorf("POST body = %s, want the field cap named", w.Body.String())
+	}
+}
+
+func TestMaxFieldsCountsOnlyClientFields(t *testing.T) {
+	srv := setupTestServerWithSchema(t, `{
+		"entities": {
+			"posts": {
+				"maxFields": 2,
+				"fields": {
+					"id":    {"type": "string", "required": true},
+					"title": {"type": "string", "required": true},
+					"slug":  {"type": "string", "slugFrom": "title"},
+					"owner": {"type": "string", "writeOnce": true}
+				}
+			}
+		}
+	}`)
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		return w
+	}
+
+	// The generated slug would be a third field
+	w := send(http.MethodPost, "/posts", `{"title": "Hello", "owner": "alice"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	var created map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &created)
+
+	// A PUT omitting owner has it carried over, again a third field
+	w = send(http.MethodPut, "/posts/"+created["id"].(string), `{"title": "Hello again", "slug": "hello"}`)
+	if w.Code != http.StatusOK {
+		t.Errorf("PUT status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+}
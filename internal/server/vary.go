@@ -0,0 +1,21 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+)
+
+// addVary lists a request header in the response's Vary header, so caches
+// keep the representations it selects apart. Headers already listed (or a
+// Vary of "*") are left alone.
+func addVary(w http.ResponseWriter, header string) {
+	for _, value := range w.Header().Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "*" || strings.EqualFold(name, header) {
+				return
+			}
+		}
+	}
+	w.Header().Add("Vary", header)
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVaryAccept(t *testing.T) {
+	plain := setupTestServer(t)
+	versioned := setupTestServerWithSchema(t, `{
+		"apiVersions": {"variants": {"2": {"responseWrapper": {"single": {"user": "$entity"}}}}},
+		"entities": {"users": {"fields": {"id": {"type": "string", "required": true}}}}
+	}`)
+	plain.store.Create("users", map[string]interface{}{"name": "Alice", "email": "alice@example.com"})
+	versioned.store.Create("users", map[string]interface{}{})
+
+	tests := []struct {
+		name     string
+		srv      *Server
+		path     string
+		accept   string
+		wantVary string
+	}{
+		{"json list", plain, "/users", "", "Accept"},
+		{"ndjson list", plain, "/users", ndjsonMediaType, "Accept"},
+		{"csv list", plain, "/users", csvMediaType, "Accept"},
+		{"single entity without negotiation", plain, "/users/1", "", ""},
+		{"versioned single entity", versioned, "/users/1", "application/vnd.myapi.v2+json", "Accept"},
+		{"versioned list lists Accept once", versioned, "/users", "", "Accept"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			tt.srv.mux.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+			}
+			vary := w.Header().Values("Vary")
+			if tt.wantVary == "" {
+				if len(vary) != 0 {
+					t.Errorf("Vary = %v, want none", vary)
+				}
+				return
+			}
+			if len(vary) != 1 || vary[0] != tt.wantVary {
+				t.Errorf("Vary = %v, want [%s]", vary, tt.wantVary)
+			}
+		})
+	}
+}
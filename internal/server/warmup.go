@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+	"time"
+)
+
+// warmupDelay is the latency added to a request arriving elapsed after
+// startup: initial at first, falling linearly to zero at the end of window
+func warmupDelay(initial, window, elapsed time.Duration) time.Duration {
+	if initial <= 0 || window <= 0 || elapsed >= window {
+		return 0
+	}
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	remaining := float64(window-elapsed) / float64(window)
+	return time.Duration(float64(initial) * remaining)
+}
+
+// withWarmup holds the request for the schema's warmup delay before next
+// runs. It sits inside the middleware chain, so the timeout step can cut the
+// wait short with a 503; a request whose client went away or timed out
+// during the wait is not handled at all.
+func (s *Server) withWarmup(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.waitForWarmup(r) {
+			return
+		}
+		next(w, r)
+	}
+}
+
+// waitForWarmup holds the request for the schema's warmup delay. It returns
+// false if the request's context ended first.
+func (s *Server) waitForWarmup(r *http.Request) bool {
+	initial, window := s.validator.loader.Warmup()
+	delay := warmupDelay(initial, window, time.Since(s.started))
+	if delay <= 0 {
+		return true
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+func TestWarmupDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		want    time.Duration
+	}{
+		{"at startup", 0, 2 * time.Second},
+		{"a quarter in", 7500 * time.Millisecond, 1500 * time.Millisecond},
+		{"halfway", 15 * time.Second, time.Second},
+		{"at the end", 30 * time.Second, 0},
+		{"after the window", time.Minute, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := warmupDelay(2*time.Second, 30*time.Second, tt.elapsed); got != tt.want {
+				t.Errorf("warmupDelay(%v) = %v, want %v", tt.elapsed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWarmupSlowsEarlyRequests(t *testing.T) {
+	srv := setupTestServerWithSchema(t, `{
+		"warmup": {"initialLatency": "200ms", "duration": "1s"},
+		"entities": {
+			"users": {"fields": {"id": {"type": "string", "required": true}}}
+		}
+	}`)
+
+	elapsed := func() time.Duration {
+		start := time.Now()
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", http.NoBody))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+		return time.Since(start)
+	}
+
+	srv.started = time.Now()
+	if took := elapsed(); took < 100*time.Millisecond {
+		t.Errorf("request at startup took %v, want a warmup delay", took)
+	}
+
+	srv.started = time.Now().Add(-2 * time.Second)
+	if took := elapsed(); took > 100*time.Millisecond {
+		t.Errorf("request after warmup took %v, want no delay", took)
+	}
+}
+
+func TestWarmupRespectsRequestTimeout(t *testing.T) {
+	srv := setupTestServerWithOptions(t, Options{RequestTimeout: 50 * time.Millisecond})
+	srv.validator.loader.GetSchema().Warmup = &types.WarmupConfig{InitialLatency: "5s", Duration: "1m"}
+	srv.started = time.Now()
+
+	start := time.Now()
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name": "Alice", "email": "alice@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("request took %v, want it cut short by the timeout", took)
+	}
+	if users, _ := srv.store.List("users"); len(users) != 0 {
+		t.Errorf("timed out create stored %v", users)
+	}
+}
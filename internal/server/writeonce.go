@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// checkWriteOnce compares the update's writeOnce fields with the stored
+// entity and returns the name of the first one it would change. A PUT that
+// omits a writeOnce field keeps the stored value rather than clearing it.
+// An entity that cannot be read is left for the update itself to report.
+// The stored entity is read past any consistency lag, but the check and the
+// write that follows are separate store calls, so two racing updates can
+// both pass it.
+func (s *Server) checkWriteOnce(entityName, id string, data map[string]interface{}, replace bool) (string, bool) {
+	if s.schema == nil {
+		return "", true
+	}
+	entity, exists := s.schema.Entities[entityName]
+	if !exists || entity == nil {
+		return "", true
+	}
+
+	var names []string
+	for name, field := range entity.Fields {
+		if field.WriteOnce {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var stored map[string]interface{}
+	for _, name := range names {
+		if stored == nil {
+			current, err := s.getWritten(entityName, id)
+			if err != nil {
+				return "", true
+			}
+			stored = current
+		}
+		previous, wasSet := stored[name]
+		value, present := data[name]
+		if !present {
+			if replace && wasSet {
+				data[name] = previous
+			}
+			continue
+		}
+		if wasSet && !sameJSONValue(value, previous) {
+			return name, false
+		}
+	}
+	return "", true
+}
+
+// sameJSONValue reports whether a and b encode to the same JSON value, so
+// 1 and 1.0 compare equal however they were decoded
+func sameJSONValue(a, b interface{}) bool {
+	return reflect.DeepEqual(plainJSON(a), plainJSON(b))
+}
+
+// plainJSON round-trips value through JSON with numbers as float64
+func plainJSON(value interface{}) interface{} {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var plain interface{}
+	if err := json.Unmarshal(raw, &plain); err != nil {
+		return value
+	}
+	return plain
+}
+
+// writeOnceField returns the first writeOnce field data sets, or "". Bulk
+// updates use it to refuse writeOnce fields outright, since the stored
+// values differ from entity to entity.
+func (s *Server) writeOnceField(entityName string, data map[string]interface{}) string {
+	if s.schema == nil {
+		return ""
+	}
+	entity, exists := s.schema.Entities[entityName]
+	if !exists || entity == nil {
+		return ""
+	}
+	var names []string
+	for name := range data {
+		if field, declared := entity.Fields[name]; declared && field.WriteOnce {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return names[0]
+}
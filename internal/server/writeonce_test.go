@@ -0,0 +1,107 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ticktockbent/ape_my/internal/storage"
+)
+
+func TestWriteOnce(t *testing.T) {
+	srv := setupTestServerWithSchema(t, `{
+		"entities": {
+			"users": {
+				"fields": {
+					"id":    {"type": "string", "required": true},
+					"name":  {"type": "string", "required": true},
+					"email": {"type": "string", "required": true, "writeOnce": true},
+					"age":   {"type": "integer", "writeOnce": true}
+				}
+			}
+		}
+	}`)
+
+	send := func(method, path, body string) (int, map[string]interface{}) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		var entity map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&entity)
+		return w.Code, entity
+	}
+
+	status, created := send(http.MethodPost, "/users", `{"name": "Alice", "email": "alice@example.com"}`)
+	if status != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d: %v", status, http.StatusCreated, created)
+	}
+	path := "/users/" + created["id"].(string)
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantEmail  string
+	}{
+		{"patch changing email", http.MethodPatch, `{"email": "mallory@example.com"}`, http.StatusConflict, ""},
+		{"put changing email", http.MethodPut, `{"name": "Alice", "email": "mallory@example.com"}`, http.StatusConflict, ""},
+		{"patch clearing email", http.MethodPatch, `{"email": null}`, http.StatusConflict, ""},
+		{"patch repeating email", http.MethodPatch, `{"name": "Alicia", "email": "alice@example.com"}`, http.StatusOK, "alice@example.com"},
+		{"put omitting email keeps it", http.MethodPut, `{"name": "Alice"}`, http.StatusOK, "alice@example.com"},
+		{"unset field can be set once", http.MethodPatch, `{"age": 30}`, http.StatusOK, "alice@example.com"},
+		{"then it is fixed", http.MethodPatch, `{"age": 31}`, http.StatusConflict, ""},
+		{"same number in another form", http.MethodPatch, `{"age": 30.0}`, http.StatusOK, "alice@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, entity := send(tt.method, path, tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %v", status, tt.wantStatus, entity)
+			}
+			if tt.wantEmail != "" && entity["email"] != tt.wantEmail {
+				t.Errorf("email = %v, want %v", entity["email"], tt.wantEmail)
+			}
+		})
+	}
+
+	if status, _ := send(http.MethodPatch, "/users/missing", `{"email": "x@example.com"}`); status != http.StatusNotFound {
+		t.Errorf("patch on missing entity status = %d, want %d", status, http.StatusNotFound)
+	}
+}
+
+func TestWriteOnceIgnoresConsistencyLag(t *testing.T) {
+	srv := setupTestServerWithSchema(t, `{
+		"entities": {
+			"users": {
+				"fields": {
+					"id":    {"type": "string", "required": true},
+					"email": {"type": "string", "writeOnce": true}
+				}
+			}
+		}
+	}`)
+	srv.store.(*storage.InMemoryStore).SetConsistencyLag(time.Hour)
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(http.MethodPost, "/users", `{"email": "alice@example.com"}`)
+	var created map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&created)
+	path := "/users/" + created["id"].(string)
+
+	if w := send(http.MethodPatch, path, `{"email": "mallory@example.com"}`); w.Code != http.StatusConflict {
+		t.Errorf("patch during lag: status = %d, want %d", w.Code, http.StatusConflict)
+	}
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+// matchesConditions checks if an entity satisfies all operator filters (AND logic)
+func matchesConditions(entity map[string]interface{}, conditions []types.FilterCondition) bool {
+	for _, cond := range conditions {
+		value, exists := entity[cond.Field]
+		if !exists || value == nil {
+			// Only "not equal" can match a missing value
+			if cond.Op != types.FilterOpNe {
+				return false
+			}
+			continue
+		}
+		if !matchesCondition(value, cond) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesCondition applies one operator to an entity value
+func matchesCondition(value interface{}, cond types.FilterCondition) bool {
+	switch cond.Op {
+	case types.FilterOpLike:
+		return strings.Contains(strings.ToLower(valueString(value)), strings.ToLower(cond.Value))
+	case types.FilterOpNe:
+		return !matchesFilters(map[string]interface{}{cond.Field: value}, map[string]string{cond.Field: cond.Value})
+	case types.FilterOpIn:
+		for _, option := range strings.Split(cond.Value, ",") {
+			if matchesFilters(map[string]interface{}{cond.Field: value}, map[string]string{cond.Field: strings.TrimSpace(option)}) {
+				return true
+			}
+		}
+		return false
+	case types.FilterOpGt, types.FilterOpGte, types.FilterOpLt, types.FilterOpLte:
+		cmp, ok := compareValues(value, cond.Value)
+		if !ok {
+			return false
+		}
+		switch cond.Op {
+		case types.FilterOpGt:
+			return cmp > 0
+		case types.FilterOpGte:
+			return cmp >= 0
+		case types.FilterOpLt:
+			return cmp < 0
+		default:
+			return cmp <= 0
+		}
+	}
+	return false
+}
+
+// compareValues orders an entity value against a filter value: numerically
+// for numbers, lexically for strings (which also orders RFC3339 timestamps)
+func compareValues(value interface{}, filterValue string) (int, bool) {
+	switch v := value.(type) {
+	case string:
+		return strings.Compare(v, filterValue), true
+	case float64, json.Number, int:
+		entityNum, entityOK := new(big.Rat).SetString(valueString(v))
+		filterNum, filterOK := new(big.Rat).SetString(filterValue)
+		if !entityOK || !filterOK {
+			return 0, false
+		}
+		return entityNum.Cmp(filterNum), true
+	}
+	return 0, false
+}
+
+// valueString renders a scalar entity value for text comparison
+func valueString(value interface{}) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+	return fmt.Sprintf("%v", value)
+}
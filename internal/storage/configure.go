@@ -0,0 +1,55 @@
+package storage
+
+import "time"
+
+// EntitySettings are the per-type behaviours a schema can switch on
+type EntitySettings struct {
+	Versioned    bool     // see SetVersioned
+	TrackHistory bool     // see SetTrackHistory
+	StartID      int      // see SetStartID; zero for none
+	Indexes      []string // see SetIndexes
+}
+
+// Configurer is implemented by stores whose behaviour follows the schema, so
+// it can be applied again when the schema is replaced at runtime
+type Configurer interface {
+	// Configure replaces an entity type's settings, turning off any that
+	// settings leaves unset
+	Configure(entityType string, settings EntitySettings)
+
+	// SetConsistencyLag sets the simulated replication lag; zero turns it off
+	SetConsistencyLag(lag time.Duration)
+}
+
+// Configure replaces an entity type's settings. History already kept for a
+// type that still tracks it is preserved; indexes are rebuilt.
+func (s *InMemoryStore) Configure(entityType string, settings EntitySettings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if settings.Versioned {
+		s.versioned[entityType] = true
+	} else {
+		delete(s.versioned, entityType)
+	}
+
+	if settings.TrackHistory {
+		if _, tracked := s.history[entityType]; !tracked {
+			s.history[entityType] = make(map[string][]HistoryEntry)
+		}
+	} else {
+		delete(s.history, entityType)
+	}
+
+	if settings.StartID > 0 {
+		s.setStartID(entityType, settings.StartID)
+	} else {
+		delete(s.startID, entityType)
+	}
+
+	if len(settings.Indexes) > 0 {
+		s.setIndexes(entityType, settings.Indexes)
+	} else {
+		delete(s.indexes, entityType)
+	}
+}
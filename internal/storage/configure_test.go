@@ -0,0 +1,54 @@
+package storage
+
+import "testing"
+
+func TestConfigure(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Initialize([]string{"tasks"})
+	store.Configure("tasks", EntitySettings{
+		Versioned:    true,
+		TrackHistory: true,
+		StartID:      100,
+		Indexes:      []string{"status"},
+	})
+
+	id, _ := store.Create("tasks", map[string]interface{}{"status": "open"})
+	if id != "101" {
+		t.Errorf("id = %q, want %q", id, "101")
+	}
+	store.Patch("tasks", id, map[string]interface{}{"status": "closed"})
+	task, _ := store.Get("tasks", id)
+	if task[VersionField] != 2 {
+		t.Errorf("version = %v, want 2", task[VersionField])
+	}
+	if history, _ := store.History("tasks", id); len(history) != 1 {
+		t.Errorf("history has %d entries, want 1", len(history))
+	}
+	if _, indexed := store.indexes["tasks"]["status"]; !indexed {
+		t.Error("status should be indexed")
+	}
+
+	// Configuring again keeps the history already recorded
+	store.Configure("tasks", EntitySettings{TrackHistory: true})
+	if history, _ := store.History("tasks", id); len(history) != 1 {
+		t.Errorf("history after reconfigure has %d entries, want 1", len(history))
+	}
+
+	// Settings left unset are turned off
+	store.Configure("tasks", EntitySettings{})
+	store.Patch("tasks", id, map[string]interface{}{"status": "open"})
+	task, _ = store.Get("tasks", id)
+	if task[VersionField] != 2 {
+		t.Errorf("unversioned patch changed version to %v", task[VersionField])
+	}
+	if history, _ := store.History("tasks", id); len(history) != 0 {
+		t.Errorf("history after clearing has %d entries, want none", len(history))
+	}
+	if len(store.indexes["tasks"]) != 0 {
+		t.Errorf("indexes = %v, want none", store.indexes["tasks"])
+	}
+	store.Reset("tasks")
+	if id, _ := store.Create("tasks", map[string]interface{}{}); id != "1" {
+		t.Errorf("id after clearing start id and reset = %q, want %q", id, "1")
+	}
+}
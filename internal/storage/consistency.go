@@ -0,0 +1,91 @@
+package storage
+
+import "time"
+
+// LatestGetter is implemented by stores that can delay read visibility. It
+// returns the most recent write regardless of simulated replication lag, so a
+// writer can echo back what it just wrote.
+type LatestGetter interface {
+	GetLatest(entityType, id string) (map[string]interface{}, error)
+}
+
+// pendingWrite holds the version readers see until a recent write becomes visible
+type pendingWrite struct {
+	previous  map[string]interface{} // nil when the entity did not exist before
+	visibleAt time.Time
+}
+
+// SetConsistencyLag makes created and updated entities invisible to Get, List
+// and ListQuery until lag has elapsed, simulating read replicas that trail the
+// primary. Readers see the previous version of an updated entity, and nothing
+// for a newly created one. Deletes and seeded data are visible immediately.
+// Zero (the default) disables the simulation.
+func (s *InMemoryStore) SetConsistencyLag(lag time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lag = lag
+}
+
+// GetLatest retrieves an entity by ID, ignoring consistency lag
+func (s *InMemoryStore) GetLatest(entityType, id string) (map[string]interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.data[entityType] == nil {
+		return nil, ErrEntityTypeNotFound
+	}
+
+	entity, exists := s.data[entityType][id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	return copyMap(entity), nil
+}
+
+// markWritten records a write so readers keep seeing the prior version until
+// the lag elapses. Must be called with the write lock held, before the entity
+// is modified.
+func (s *InMemoryStore) markWritten(entityType, id string) {
+	if s.lag <= 0 {
+		return
+	}
+
+	now := s.now()
+	if s.pending[entityType] == nil {
+		s.pending[entityType] = make(map[string]pendingWrite)
+	}
+
+	// Drop writes that have become visible
+	for pendingID, write := range s.pending[entityType] {
+		if !now.Before(write.visibleAt) {
+			delete(s.pending[entityType], pendingID)
+		}
+	}
+
+	// Consecutive writes within the lag keep the oldest visible version
+	previous := s.pending[entityType][id].previous
+	if _, stillPending := s.pending[entityType][id]; !stillPending {
+		if current, exists := s.data[entityType][id]; exists {
+			previous = copyMap(current)
+		}
+	}
+	s.pending[entityType][id] = pendingWrite{previous: previous, visibleAt: now.Add(s.lag)}
+}
+
+// visibleVersion returns the version of an entity readers may currently see.
+// Must be called with at least the read lock held.
+func (s *InMemoryStore) visibleVersion(entityType, id string, entity map[string]interface{}) (map[string]interface{}, bool) {
+	if s.lag <= 0 {
+		return entity, true
+	}
+	write, isPending := s.pending[entityType][id]
+	if !isPending || !s.now().Before(write.visibleAt) {
+		return entity, true
+	}
+	if write.previous == nil {
+		return nil, false
+	}
+	return write.previous, true
+}
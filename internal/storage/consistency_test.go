@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+func TestConsistencyLag(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Initialize([]string{"users"})
+	store.Seed("users", []map[string]interface{}{
+		{"id": "1", "name": "Alice"},
+	})
+
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	store.SetConsistencyLag(500 * time.Millisecond)
+
+	// Seeded data is visible immediately
+	if _, err := store.Get("users", "1"); err != nil {
+		t.Fatalf("seeded entity should be visible: %v", err)
+	}
+
+	// A new entity is hidden until the lag elapses
+	id, _ := store.Create("users", map[string]interface{}{"name": "Bob"})
+	if _, err := store.Get("users", id); err != ErrNotFound {
+		t.Errorf("Get() during lag error = %v, want %v", err, ErrNotFound)
+	}
+	if latest, err := store.GetLatest("users", id); err != nil || latest["name"] != "Bob" {
+		t.Errorf("GetLatest() = %v, %v, want the new entity", latest, err)
+	}
+	if list, _ := store.List("users"); len(list) != 1 {
+		t.Errorf("List() during lag returned %d items, want 1", len(list))
+	}
+
+	// An update shows the previous version until the lag elapses
+	store.Patch("users", "1", map[string]interface{}{"name": "Alicia"})
+	store.Update("users", "1", map[string]interface{}{"name": "Ali"})
+	entity, _ := store.Get("users", "1")
+	if entity["name"] != "Alice" {
+		t.Errorf("Get() during lag name = %v, want previous value Alice", entity["name"])
+	}
+	result, _ := store.ListQuery("users", types.QueryOpts{Filters: map[string]string{"name": "Ali"}})
+	if result.TotalCount != 0 {
+		t.Errorf("ListQuery() matched a write that is not yet visible")
+	}
+
+	// After the lag, the latest writes are visible
+	now = now.Add(500 * time.Millisecond)
+	if _, err := store.Get("users", id); err != nil {
+		t.Errorf("Get() after lag error = %v", err)
+	}
+	entity, _ = store.Get("users", "1")
+	if entity["name"] != "Ali" {
+		t.Errorf("Get() after lag name = %v, want Ali", entity["name"])
+	}
+
+	// Deletes are immediate
+	store.Delete("users", id)
+	if _, err := store.GetLatest("users", id); err != ErrNotFound {
+		t.Errorf("GetLatest() after delete error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestConsistencyLagDisabled(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Initialize([]string{"users"})
+
+	id, _ := store.Create("users", map[string]interface{}{"name": "Bob"})
+	if _, err := store.Get("users", id); err != nil {
+		t.Errorf("writes should be visible immediately by default: %v", err)
+	}
+}
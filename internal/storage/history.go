@@ -0,0 +1,66 @@
+package storage
+
+import "time"
+
+// History actions recorded by stores that track entity history
+const (
+	HistoryUpdate = "update"
+	HistoryPatch  = "patch"
+	HistoryDelete = "delete"
+)
+
+// HistoryEntry is one past state of an entity: what it looked like before
+// the write that replaced it, and when that write happened
+type HistoryEntry struct {
+	Action    string                 `json:"action"`
+	Timestamp time.Time              `json:"timestamp"`
+	State     map[string]interface{} `json:"state"`
+}
+
+// HistoryReader is implemented by stores that can keep past versions of
+// entities for types registered with SetTrackHistory
+type HistoryReader interface {
+	// History returns an entity's past states, oldest first. It is
+	// ErrNotFound when the entity neither exists nor has history.
+	History(entityType, id string) ([]HistoryEntry, error)
+}
+
+// SetTrackHistory makes the store keep the prior state of an entity type's
+// entities on every update, patch and delete. Histories live as long as the
+// store, outliving deleted entities, until the type is Reset.
+func (s *InMemoryStore) SetTrackHistory(entityType string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.history[entityType] = make(map[string][]HistoryEntry)
+}
+
+// History returns an entity's past states, oldest first
+func (s *InMemoryStore) History(entityType, id string) ([]HistoryEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.data[entityType] == nil {
+		return nil, ErrEntityTypeNotFound
+	}
+	entries := s.history[entityType][id]
+	if _, exists := s.data[entityType][id]; !exists && len(entries) == 0 {
+		return nil, ErrNotFound
+	}
+
+	out := make([]HistoryEntry, len(entries))
+	for i, entry := range entries {
+		out[i] = HistoryEntry{Action: entry.Action, Timestamp: entry.Timestamp, State: copyMap(entry.State)}
+	}
+	return out, nil
+}
+
+// recordHistory appends the state an entity had before a write, if its type
+// tracks history. Callers hold s.mu.
+func (s *InMemoryStore) recordHistory(entityType, id, action string, prior map[string]interface{}) {
+	byID, tracked := s.history[entityType]
+	if !tracked {
+		return
+	}
+	byID[id] = append(byID[id], HistoryEntry{Action: action, Timestamp: s.now(), State: copyMap(prior)})
+}
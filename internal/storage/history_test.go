@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Initialize([]string{"users", "posts"})
+	store.SetTrackHistory("users")
+
+	id, _ := store.Create("users", map[string]interface{}{"name": "Alice", "role": "viewer"})
+	if entries, err := store.History("users", id); err != nil || len(entries) != 0 {
+		t.Fatalf("History() after create = %v, %v; want empty", entries, err)
+	}
+
+	store.Update("users", id, map[string]interface{}{"name": "Alicia", "role": "viewer"})
+	store.Patch("users", id, map[string]interface{}{"role": "admin"})
+	store.Delete("users", id)
+
+	entries, err := store.History("users", id)
+	if err != nil {
+		t.Fatalf("History() after delete error = %v", err)
+	}
+	want := []struct{ action, name, role string }{
+		{HistoryUpdate, "Alice", "viewer"},
+		{HistoryPatch, "Alicia", "viewer"},
+		{HistoryDelete, "Alicia", "admin"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %v", len(entries), len(want), entries)
+	}
+	for i, w := range want {
+		entry := entries[i]
+		if entry.Action != w.action || entry.State["name"] != w.name || entry.State["role"] != w.role {
+			t.Errorf("entry %d = %s %v, want %s name=%s role=%s", i, entry.Action, entry.State, w.action, w.name, w.role)
+		}
+		if entry.Timestamp.IsZero() {
+			t.Errorf("entry %d has no timestamp", i)
+		}
+	}
+
+	// Returned states are copies
+	entries[0].State["name"] = "Mallory"
+	if again, _ := store.History("users", id); again[0].State["name"] != "Alice" {
+		t.Error("modifying a returned entry changed the stored history")
+	}
+
+	// Untracked types keep nothing
+	postID, _ := store.Create("posts", map[string]interface{}{"title": "Hello"})
+	store.Patch("posts", postID, map[string]interface{}{"title": "Hi"})
+	if entries, _ := store.History("posts", postID); len(entries) != 0 {
+		t.Errorf("untracked type recorded history: %v", entries)
+	}
+
+	if _, err := store.History("users", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("History() for unknown id error = %v, want ErrNotFound", err)
+	}
+
+	// Reset clears the history along with the data
+	store.Reset("users")
+	if _, err := store.History("users", id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("History() after Reset error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+)
+
+// fieldIndex maps an index key (see indexKey) to the ids of the entities
+// whose field holds that value
+type fieldIndex map[string]map[string]struct{}
+
+// SetIndexes has the store keep a secondary index on each of fields, so
+// equality filters on them (?status=open) only visit matching entities.
+// Existing entities are indexed immediately.
+func (s *InMemoryStore) SetIndexes(entityType string, fields []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.setIndexes(entityType, fields)
+}
+
+// setIndexes builds the indexes on fields from the stored entities. Callers
+// hold s.mu.
+func (s *InMemoryStore) setIndexes(entityType string, fields []string) {
+	indexes := make(map[string]fieldIndex, len(fields))
+	for _, field := range fields {
+		indexes[field] = make(fieldIndex)
+	}
+	s.indexes[entityType] = indexes
+	for id, entity := range s.data[entityType] {
+		s.indexEntity(entityType, id, entity)
+	}
+}
+
+// indexEntity adds the entity's indexed field values. Callers hold s.mu.
+func (s *InMemoryStore) indexEntity(entityType, id string, entity map[string]interface{}) {
+	for field, index := range s.indexes[entityType] {
+		value, exists := entity[field]
+		if !exists {
+			continue
+		}
+		key := indexKey(value)
+		if index[key] == nil {
+			index[key] = make(map[string]struct{})
+		}
+		index[key][id] = struct{}{}
+	}
+}
+
+// unindexEntity removes the entity's indexed field values. Callers hold s.mu.
+func (s *InMemoryStore) unindexEntity(entityType, id string, entity map[string]interface{}) {
+	for field, index := range s.indexes[entityType] {
+		value, exists := entity[field]
+		if !exists {
+			continue
+		}
+		key := indexKey(value)
+		delete(index[key], id)
+		if len(index[key]) == 0 {
+			delete(index, key)
+		}
+	}
+}
+
+// clearIndexes empties the entity type's indexes, keeping the indexed fields.
+// Callers hold s.mu.
+func (s *InMemoryStore) clearIndexes(entityType string) {
+	for field := range s.indexes[entityType] {
+		s.indexes[entityType][field] = make(fieldIndex)
+	}
+}
+
+// indexKey normalizes a stored value so that values matchesFilters treats as
+// equal share a key. matchesFilters compares float64 values as float64s and
+// json.Number values as exact rationals, so the two are keyed separately:
+// float64 by its shortest formatting, json.Number by its rational value.
+func indexKey(value interface{}) string {
+	switch typed := value.(type) {
+	case string:
+		return "s:" + typed
+	case float64:
+		return "f:" + strconv.FormatFloat(typed, 'g', -1, 64)
+	case json.Number:
+		if rat, ok := new(big.Rat).SetString(string(typed)); ok {
+			return "n:" + rat.RatString()
+		}
+	case bool:
+		return "b:" + strconv.FormatBool(typed)
+	}
+	return "v:" + fmt.Sprintf("%v", value)
+}
+
+// filterIndexKeys returns every index key a stored value could have and still
+// match the filter value
+func filterIndexKeys(filterValue string) []string {
+	keys := []string{"s:" + filterValue, "v:" + filterValue}
+	if f, err := strconv.ParseFloat(filterValue, 64); err == nil {
+		keys = append(keys, "f:"+strconv.FormatFloat(f, 'g', -1, 64))
+	}
+	if rat, ok := new(big.Rat).SetString(filterValue); ok {
+		keys = append(keys, "n:"+rat.RatString())
+	}
+	if b, err := strconv.ParseBool(filterValue); err == nil {
+		keys = append(keys, "b:"+strconv.FormatBool(b))
+	}
+	return keys
+}
+
+// indexedCandidates returns, sorted, the ids that can match filters according
+// to the smallest applicable index, plus any with a write not yet visible
+// (readers may still see the old version). ok is false when no filter is on
+// an indexed field, and the caller must scan. Callers hold s.mu.
+func (s *InMemoryStore) indexedCandidates(entityType string, filters map[string]string) (ids []string, ok bool) {
+	var best map[string]struct{}
+	for field, filterValue := range filters {
+		index, indexed := s.indexes[entityType][field]
+		if !indexed {
+			continue
+		}
+		candidates := make(map[string]struct{})
+		for _, key := range filterIndexKeys(filterValue) {
+			for id := range index[key] {
+				candidates[id] = struct{}{}
+			}
+		}
+		if best == nil || len(candidates) < len(best) {
+			best = candidates
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+
+	for id := range s.pending[entityType] {
+		best[id] = struct{}{}
+	}
+	ids = make([]string, 0, len(best))
+	for id := range best {
+		if _, exists := s.data[entityType][id]; exists {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, true
+}
@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+// queryIDs runs ListQuery and returns the matching ids in order
+func queryIDs(t *testing.T, store *InMemoryStore, filters map[string]string) []string {
+	t.Helper()
+	result, err := store.ListQuery("tasks", types.QueryOpts{Filters: filters})
+	if err != nil {
+		t.Fatalf("ListQuery() error = %v", err)
+	}
+	ids := make([]string, 0, len(result.Items))
+	for _, item := range result.Items {
+		ids = append(ids, item["id"].(string))
+	}
+	return ids
+}
+
+func TestIndexedQueriesMatchScans(t *testing.T) {
+	indexed := NewInMemoryStore()
+	scanned := NewInMemoryStore()
+	for _, store := range []*InMemoryStore{indexed, scanned} {
+		store.Initialize([]string{"tasks"})
+		store.Seed("tasks", []map[string]interface{}{
+			{"id": "1", "status": "open", "priority": json.Number("2"), "done": false},
+			{"id": "2", "status": "closed", "priority": 2.0, "done": true},
+		})
+	}
+	indexed.SetIndexes("tasks", []string{"status", "priority", "done"})
+
+	apply := func(op func(store *InMemoryStore)) {
+		op(indexed)
+		op(scanned)
+	}
+	apply(func(s *InMemoryStore) { s.Create("tasks", map[string]interface{}{"status": "open", "priority": 1.0}) })
+	apply(func(s *InMemoryStore) {
+		s.Update("tasks", "2", map[string]interface{}{"status": "open", "priority": json.Number("2.0")})
+	})
+	apply(func(s *InMemoryStore) { s.Patch("tasks", "1", map[string]interface{}{"status": "closed"}) })
+	apply(func(s *InMemoryStore) { s.Create("tasks", map[string]interface{}{"status": "open", "done": true}) })
+	apply(func(s *InMemoryStore) { s.Delete("tasks", "4") })
+
+	filters := []map[string]string{
+		{"status": "open"},
+		{"status": "closed"},
+		{"status": "missing"},
+		{"priority": "2"},
+		{"priority": "2.00"},
+		{"priority": "1"},
+		{"priority": "Inf"},
+		{"done": "false"},
+		{"done": "t"},
+		{"status": "open", "priority": "2"},
+	}
+	for _, f := range filters {
+		got, want := queryIDs(t, indexed, f), queryIDs(t, scanned, f)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("filters %v: indexed = %v, scanned = %v", f, got, want)
+		}
+	}
+
+	if got := queryIDs(t, indexed, map[string]string{"status": "open"}); !reflect.DeepEqual(got, []string{"2", "3"}) {
+		t.Errorf("status=open = %v, want [2 3]", got)
+	}
+
+	apply(func(s *InMemoryStore) { s.Reset("tasks") })
+	if got := queryIDs(t, indexed, map[string]string{"status": "open"}); len(got) != 0 {
+		t.Errorf("after Reset = %v, want none", got)
+	}
+}
+
+func TestIndexedFloatQueriesMatchScans(t *testing.T) {
+	indexed := NewInMemoryStore()
+	scanned := NewInMemoryStore()
+	for _, store := range []*InMemoryStore{indexed, scanned} {
+		store.Initialize([]string{"tasks"})
+		// float64 values come from schema defaults and generated fields,
+		// json.Number values from request bodies
+		store.Seed("tasks", []map[string]interface{}{
+			{"id": "1", "price": 0.1},
+			{"id": "2", "price": json.Number("0.1")},
+			{"id": "3", "price": 8.0},
+			{"id": "4", "price": math.Inf(1)},
+		})
+	}
+	indexed.SetIndexes("tasks", []string{"price"})
+
+	filters := []string{"0.1", "0.10", "1e-1", "0.10000000000000001", "8", "0x1p3", "Inf", "+infinity", "NaN"}
+	for _, f := range filters {
+		filter := map[string]string{"price": f}
+		got, want := queryIDs(t, indexed, filter), queryIDs(t, scanned, filter)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("price=%s: indexed = %v, scanned = %v", f, got, want)
+		}
+	}
+
+	if got := queryIDs(t, indexed, map[string]string{"price": "0.1"}); !reflect.DeepEqual(got, []string{"1", "2"}) {
+		t.Errorf("price=0.1 = %v, want [1 2]", got)
+	}
+}
+
+func TestIndexedQueriesWithConsistencyLag(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Initialize([]string{"tasks"})
+	store.SetIndexes("tasks", []string{"status"})
+	store.Seed("tasks", []map[string]interface{}{{"id": "1", "status": "open"}})
+	store.SetConsistencyLag(time.Hour)
+
+	// Readers still see the old status until the lag elapses
+	store.Patch("tasks", "1", map[string]interface{}{"status": "closed"})
+	if got := queryIDs(t, store, map[string]string{"status": "open"}); !reflect.DeepEqual(got, []string{"1"}) {
+		t.Errorf("status=open during lag = %v, want [1]", got)
+	}
+	if got := queryIDs(t, store, map[string]string{"status": "closed"}); len(got) != 0 {
+		t.Errorf("status=closed during lag = %v, want none", got)
+	}
+}
+
+func TestIndexConcurrentWrites(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Initialize([]string{"tasks"})
+	store.SetIndexes("tasks", []string{"status"})
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				id, _ := store.Create("tasks", map[string]interface{}{"status": "open"})
+				store.Patch("tasks", id, map[string]interface{}{"status": fmt.Sprintf("s%d", i%3)})
+				store.ListQuery("tasks", types.QueryOpts{Filters: map[string]string{"status": "s0"}})
+				if i%5 == 0 {
+					store.Delete("tasks", id)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	all, _ := store.List("tasks")
+	want := 0
+	for _, entity := range all {
+		if entity["status"] == "s0" {
+			want++
+		}
+	}
+	if got := queryIDs(t, store, map[string]string{"status": "s0"}); len(got) != want {
+		t.Errorf("indexed status=s0 count = %d, want %d", len(got), want)
+	}
+}
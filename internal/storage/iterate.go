@@ -0,0 +1,72 @@
+package storage
+
+import "github.com/ticktockbent/ape_my/pkg/types"
+
+// Iterator is implemented by stores that can hand out a query's matches one
+// at a time, so streaming responses need not hold a copy of the whole result
+type Iterator interface {
+	// Each calls fn with every entity ListQuery would return for opts, in
+	// the same order, until fn returns false. fn runs without the store's
+	// lock held, so entities written meanwhile may or may not be seen.
+	Each(entityType string, opts types.QueryOpts, fn func(map[string]interface{}) bool) error
+}
+
+// Each iterates a query's matches. Only the candidate IDs are collected up
+// front; each entity is read and copied as it is reached.
+func (s *InMemoryStore) Each(entityType string, opts types.QueryOpts, fn func(map[string]interface{}) bool) error {
+	s.mu.RLock()
+	if s.data[entityType] == nil {
+		s.mu.RUnlock()
+		return ErrEntityTypeNotFound
+	}
+	ids := s.candidateIDs(entityType, opts.Filters)
+	s.mu.RUnlock()
+
+	// A cursor skips up to and including its entity; an unknown cursor
+	// matches nothing, as in ListQuery
+	skipping := opts.Cursor != ""
+	skip := 0
+	if !skipping {
+		skip = opts.Offset
+	}
+	yielded := 0
+	for _, id := range ids {
+		entity, matched := s.match(entityType, id, opts)
+		if !matched {
+			continue
+		}
+		if skipping {
+			skipping = id != opts.Cursor
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		if opts.Limit > 0 && yielded == opts.Limit {
+			return nil
+		}
+		yielded++
+		if !fn(entity) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// match returns a copy of the entity if it is visible and matches the
+// query's filters and conditions
+func (s *InMemoryStore) match(entityType, id string, opts types.QueryOpts) (map[string]interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored, exists := s.data[entityType][id]
+	if !exists {
+		return nil, false
+	}
+	entity, visible := s.visibleVersion(entityType, id, stored)
+	if !visible || !matchesFilters(entity, opts.Filters) || !matchesConditions(entity, opts.Conditions) {
+		return nil, false
+	}
+	return copyMap(entity), true
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+func TestEachMatchesListQuery(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Initialize([]string{"tasks"})
+	for _, status := range []string{"open", "closed", "open", "open", "closed", "open"} {
+		store.Create("tasks", map[string]interface{}{"status": status})
+	}
+
+	tests := []struct {
+		name string
+		opts types.QueryOpts
+	}{
+		{"everything", types.QueryOpts{}},
+		{"filtered", types.QueryOpts{Filters: map[string]string{"status": "open"}}},
+		{"offset and limit", types.QueryOpts{Offset: 1, Limit: 2}},
+		{"offset past the end", types.QueryOpts{Offset: 10}},
+		{"cursor", types.QueryOpts{Cursor: "3", Limit: 2}},
+		{"filtered cursor", types.QueryOpts{Filters: map[string]string{"status": "open"}, Cursor: "3"}},
+		{"unknown cursor", types.QueryOpts{Cursor: "missing"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := store.ListQuery("tasks", tt.opts)
+			if err != nil {
+				t.Fatalf("ListQuery() error = %v", err)
+			}
+			var items []map[string]interface{}
+			if err := store.Each("tasks", tt.opts, func(item map[string]interface{}) bool {
+				items = append(items, item)
+				return true
+			}); err != nil {
+				t.Fatalf("Each() error = %v", err)
+			}
+			if len(items) != len(result.Items) || (len(items) > 0 && !reflect.DeepEqual(items, result.Items)) {
+				t.Errorf("Each() = %v, want %v", items, result.Items)
+			}
+		})
+	}
+
+	// Returning false stops the iteration
+	calls := 0
+	store.Each("tasks", types.QueryOpts{}, func(map[string]interface{}) bool {
+		calls++
+		return false
+	})
+	if calls != 1 {
+		t.Errorf("fn called %d times after returning false, want 1", calls)
+	}
+
+	if err := store.Each("missing", types.QueryOpts{}, func(map[string]interface{}) bool { return true }); err != ErrEntityTypeNotFound {
+		t.Errorf("Each() on unknown type error = %v, want %v", err, ErrEntityTypeNotFound)
+	}
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"sync"
+	"time"
+)
+
+// Scheduler is implemented by stores that can apply a patch after a delay,
+// used to mock asynchronous jobs that complete in the background
+type Scheduler interface {
+	// SchedulePatch patches the entity once after has elapsed. Entities
+	// deleted in the meantime are skipped.
+	SchedulePatch(entityType, id string, after time.Duration, data map[string]interface{})
+
+	// CancelScheduled stops all pending patches; later calls to
+	// SchedulePatch are ignored
+	CancelScheduled()
+}
+
+// scheduledPatches tracks the timers behind SchedulePatch
+type scheduledPatches struct {
+	mu        sync.Mutex
+	timers    map[*time.Timer]struct{}
+	cancelled bool
+}
+
+// SchedulePatch patches the entity once after has elapsed
+func (s *InMemoryStore) SchedulePatch(entityType, id string, after time.Duration, data map[string]interface{}) {
+	data = copyMap(data)
+
+	s.scheduled.mu.Lock()
+	defer s.scheduled.mu.Unlock()
+
+	if s.scheduled.cancelled {
+		return
+	}
+	if s.scheduled.timers == nil {
+		s.scheduled.timers = make(map[*time.Timer]struct{})
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(after, func() {
+		s.scheduled.mu.Lock()
+		_, pending := s.scheduled.timers[timer]
+		delete(s.scheduled.timers, timer)
+		s.scheduled.mu.Unlock()
+
+		if pending {
+			// The entity may have been deleted since; nothing to complete then
+			_ = s.Patch(entityType, id, data)
+		}
+	})
+	s.scheduled.timers[timer] = struct{}{}
+}
+
+// CancelScheduled stops all pending patches
+func (s *InMemoryStore) CancelScheduled() {
+	s.scheduled.mu.Lock()
+	defer s.scheduled.mu.Unlock()
+
+	s.scheduled.cancelled = true
+	for timer := range s.scheduled.timers {
+		timer.Stop()
+		delete(s.scheduled.timers, timer)
+	}
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+// waitFor polls until cond holds or the deadline passes
+func waitFor(t *testing.T, cond func() bool) bool {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return false
+}
+
+func TestSchedulePatch(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Initialize([]string{"jobs"})
+
+	id, _ := store.Create("jobs", map[string]interface{}{"status": "pending"})
+	store.SchedulePatch("jobs", id, 20*time.Millisecond, map[string]interface{}{"status": "complete"})
+
+	entity, _ := store.Get("jobs", id)
+	if entity["status"] != "pending" {
+		t.Fatalf("status = %v before the delay, want pending", entity["status"])
+	}
+
+	completed := waitFor(t, func() bool {
+		entity, _ := store.Get("jobs", id)
+		return entity["status"] == "complete"
+	})
+	if !completed {
+		t.Error("scheduled patch was never applied")
+	}
+
+	// Deleting the entity first is not an error
+	deleted, _ := store.Create("jobs", map[string]interface{}{"status": "pending"})
+	store.SchedulePatch("jobs", deleted, time.Millisecond, map[string]interface{}{"status": "complete"})
+	store.Delete("jobs", deleted)
+	time.Sleep(20 * time.Millisecond)
+	if _, err := store.Get("jobs", deleted); err != ErrNotFound {
+		t.Errorf("Get() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCancelScheduled(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Initialize([]string{"jobs"})
+
+	id, _ := store.Create("jobs", map[string]interface{}{"status": "pending"})
+	store.SchedulePatch("jobs", id, 20*time.Millisecond, map[string]interface{}{"status": "complete"})
+	store.CancelScheduled()
+
+	// Scheduling after cancellation is a no-op
+	store.SchedulePatch("jobs", id, time.Millisecond, map[string]interface{}{"status": "complete"})
+
+	time.Sleep(50 * time.Millisecond)
+	entity, _ := store.Get("jobs", id)
+	if entity["status"] != "pending" {
+		t.Errorf("status = %v, want pending after cancellation", entity["status"])
+	}
+}
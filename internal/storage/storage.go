@@ -1,11 +1,14 @@
 package storage
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"sort"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/ticktockbent/ape_my/pkg/types"
 )
@@ -16,16 +19,31 @@ var (
 
 	// ErrEntityTypeNotFound is returned when an entity type doesn't exist in schema
 	ErrEntityTypeNotFound = errors.New("entity type not found")
+
+	// ErrInvalidID is returned when a provided id is not a string
+	ErrInvalidID = errors.New("id must be a string")
+
+	// ErrIDTaken is returned by Create when a provided id is already in use
+	ErrIDTaken = errors.New("id is already taken")
+
+	// ErrNotArray is returned by UpdateArray when the stored field holds
+	// something other than an array
+	ErrNotArray = errors.New("field is not an array")
 )
 
 // Store defines the interface for data storage operations
 type Store interface {
-	// Create adds a new entity and returns its ID
+	// Create adds a new entity and returns its ID. A provided id that is
+	// already in use is ErrIDTaken.
 	Create(entityType string, data map[string]interface{}) (string, error)
 
 	// Get retrieves a single entity by ID
 	Get(entityType string, id string) (map[string]interface{}, error)
 
+	// GetMany retrieves several entities by ID in one call, in the order
+	// requested; IDs that don't exist yield nil entries
+	GetMany(entityType string, ids []string) ([]map[string]interface{}, error)
+
 	// List retrieves all entities of a given type
 	List(entityType string) ([]map[string]interface{}, error)
 
@@ -38,14 +56,28 @@ type Store interface {
 	// Patch partially updates an entity
 	Patch(entityType string, id string, data map[string]interface{}) error
 
+	// PatchWhere applies one partial update to every entity matching the
+	// equality filters and returns how many were updated
+	PatchWhere(entityType string, filters map[string]string, data map[string]interface{}) (int, error)
+
+	// UpdateArray replaces an array field with the result of fn under one
+	// lock, so concurrent appends and removals are not lost
+	UpdateArray(entityType string, id string, field string, fn func([]interface{}) ([]interface{}, error)) error
+
 	// Delete removes an entity
 	Delete(entityType string, id string) error
 
+	// Remove deletes an entity and returns it as it was stored
+	Remove(entityType string, id string) (map[string]interface{}, error)
+
 	// Initialize sets up storage for entity types
 	Initialize(entityTypes []string) error
 
 	// Seed loads initial data into storage
 	Seed(entityType string, entities []map[string]interface{}) error
+
+	// Reset removes all entities of a type and restarts its ID counter
+	Reset(entityType string) error
 }
 
 // InMemoryStore implements Store using in-memory storage
@@ -53,6 +85,24 @@ type InMemoryStore struct {
 	mu      sync.RWMutex
 	data    map[string]map[string]map[string]interface{} // entityType -> id -> entity
 	counter map[string]int                               // entityType -> counter for ID generation
+	startID map[string]int                               // entityType -> counter value after Reset (see SetStartID)
+
+	// Simulated replication lag (see SetConsistencyLag)
+	lag     time.Duration
+	pending map[string]map[string]pendingWrite // entityType -> id -> write not yet visible
+	now     func() time.Time
+
+	// Entity types whose VersionField is store-managed (see SetVersioned)
+	versioned map[string]bool
+
+	// Secondary indexes on filterable fields (see SetIndexes)
+	indexes map[string]map[string]fieldIndex // entityType -> field -> index
+
+	// Past entity states for types that track history (see SetTrackHistory)
+	history map[string]map[string][]HistoryEntry // entityType -> id -> oldest first
+
+	// Deferred patches (see SchedulePatch)
+	scheduled scheduledPatches
 }
 
 // NewInMemoryStore creates a new in-memory store
@@ -60,6 +110,13 @@ func NewInMemoryStore() *InMemoryStore {
 	return &InMemoryStore{
 		data:    make(map[string]map[string]map[string]interface{}),
 		counter: make(map[string]int),
+		startID: make(map[string]int),
+		pending: make(map[string]map[string]pendingWrite),
+		now:     time.Now,
+
+		versioned: make(map[string]bool),
+		indexes:   make(map[string]map[string]fieldIndex),
+		history:   make(map[string]map[string][]HistoryEntry),
 	}
 }
 
@@ -80,6 +137,24 @@ func (s *InMemoryStore) Initialize(entityTypes []string) error {
 	return nil
 }
 
+// SetStartID starts ID generation for an entity type after start, so the
+// first created entity gets start+1. Seeded ids above start still advance the
+// counter, and Reset returns to start.
+func (s *InMemoryStore) SetStartID(entityType string, start int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.setStartID(entityType, start)
+}
+
+// setStartID records start and advances the counter to it. Callers hold s.mu.
+func (s *InMemoryStore) setStartID(entityType string, start int) {
+	s.startID[entityType] = start
+	if s.counter[entityType] < start {
+		s.counter[entityType] = start
+	}
+}
+
 // Create adds a new entity and returns its ID
 func (s *InMemoryStore) Create(entityType string, data map[string]interface{}) (string, error) {
 	s.mu.Lock()
@@ -93,15 +168,27 @@ func (s *InMemoryStore) Create(entityType string, data map[string]interface{}) (
 	// Generate ID if not provided
 	var id string
 	if providedID, exists := data["id"]; exists && providedID != nil {
-		id = providedID.(string)
+		providedString, ok := providedID.(string)
+		if !ok {
+			return "", ErrInvalidID
+		}
+		id = providedString
+		if _, taken := s.data[entityType][id]; taken {
+			return "", ErrIDTaken
+		}
 	} else {
-		s.counter[entityType]++
-		id = formatID(s.counter[entityType])
+		id = s.nextID(entityType)
 		data["id"] = id
 	}
 
+	if s.versioned[entityType] {
+		data[VersionField] = 1
+	}
+
 	// Store the entity
+	s.markWritten(entityType, id)
 	s.data[entityType][id] = copyMap(data)
+	s.indexEntity(entityType, id, s.data[entityType][id])
 
 	return id, nil
 }
@@ -121,10 +208,38 @@ func (s *InMemoryStore) Get(entityType, id string) (map[string]interface{}, erro
 	if !exists {
 		return nil, ErrNotFound
 	}
+	entity, visible := s.visibleVersion(entityType, id, entity)
+	if !visible {
+		return nil, ErrNotFound
+	}
 
 	return copyMap(entity), nil
 }
 
+// GetMany retrieves several entities by ID under a single lock, in the order
+// requested. IDs that don't exist (or aren't visible yet) yield nil entries.
+func (s *InMemoryStore) GetMany(entityType string, ids []string) ([]map[string]interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.data[entityType] == nil {
+		return nil, ErrEntityTypeNotFound
+	}
+
+	entities := make([]map[string]interface{}, len(ids))
+	for i, id := range ids {
+		entity, exists := s.data[entityType][id]
+		if !exists {
+			continue
+		}
+		if entity, visible := s.visibleVersion(entityType, id, entity); visible {
+			entities[i] = copyMap(entity)
+		}
+	}
+
+	return entities, nil
+}
+
 // List retrieves all entities of a given type
 func (s *InMemoryStore) List(entityType string) ([]map[string]interface{}, error) {
 	s.mu.RLock()
@@ -137,13 +252,31 @@ func (s *InMemoryStore) List(entityType string) ([]map[string]interface{}, error
 
 	// Collect all entities
 	entities := make([]map[string]interface{}, 0, len(s.data[entityType]))
-	for _, entity := range s.data[entityType] {
-		entities = append(entities, copyMap(entity))
+	for id, entity := range s.data[entityType] {
+		if entity, visible := s.visibleVersion(entityType, id, entity); visible {
+			entities = append(entities, copyMap(entity))
+		}
 	}
 
 	return entities, nil
 }
 
+// candidateIDs returns the IDs a query could match, sorted for deterministic
+// ordering: those an index narrows the filters to, or else all of them.
+// Callers hold s.mu.
+func (s *InMemoryStore) candidateIDs(entityType string, filters map[string]string) []string {
+	ids, indexed := s.indexedCandidates(entityType, filters)
+	if indexed {
+		return ids
+	}
+	ids = make([]string, 0, len(s.data[entityType]))
+	for id := range s.data[entityType] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
 // ListQuery retrieves entities with filtering, pagination, and cursor support
 func (s *InMemoryStore) ListQuery(entityType string, opts types.QueryOpts) (*types.QueryResult, error) {
 	s.mu.RLock()
@@ -153,18 +286,13 @@ func (s *InMemoryStore) ListQuery(entityType string, opts types.QueryOpts) (*typ
 		return nil, ErrEntityTypeNotFound
 	}
 
-	// Collect all entities sorted by ID for deterministic ordering
-	allIDs := make([]string, 0, len(s.data[entityType]))
-	for id := range s.data[entityType] {
-		allIDs = append(allIDs, id)
-	}
-	sort.Strings(allIDs)
+	allIDs := s.candidateIDs(entityType, opts.Filters)
 
 	// Apply filters
 	var filtered []map[string]interface{}
 	for _, id := range allIDs {
-		entity := s.data[entityType][id]
-		if matchesFilters(entity, opts.Filters) {
+		entity, visible := s.visibleVersion(entityType, id, s.data[entityType][id])
+		if visible && matchesFilters(entity, opts.Filters) && matchesConditions(entity, opts.Conditions) {
 			filtered = append(filtered, copyMap(entity))
 		}
 	}
@@ -210,10 +338,17 @@ func (s *InMemoryStore) ListQuery(entityType string, opts types.QueryOpts) (*typ
 		filtered = []map[string]interface{}{}
 	}
 
+	offset := 0
+	if opts.Cursor == "" {
+		offset = opts.Offset
+	}
+
 	return &types.QueryResult{
 		Items:      filtered,
 		TotalCount: totalCount,
 		NextCursor: nextCursor,
+		Offset:     offset,
+		Limit:      opts.Limit,
 	}, nil
 }
 
@@ -236,6 +371,13 @@ func matchesFilters(entity map[string]interface{}, filters map[string]string) bo
 			if err != nil || typedValue != filterNum {
 				return false
 			}
+		case json.Number:
+			// Compare as exact rationals so large integers are not rounded
+			entityNum, entityOK := new(big.Rat).SetString(string(typedValue))
+			filterNum, filterOK := new(big.Rat).SetString(filterValue)
+			if !entityOK || !filterOK || entityNum.Cmp(filterNum) != 0 {
+				return false
+			}
 		case bool:
 			filterBool, err := strconv.ParseBool(filterValue)
 			if err != nil || typedValue != filterBool {
@@ -262,15 +404,28 @@ func (s *InMemoryStore) Update(entityType, id string, data map[string]interface{
 	}
 
 	// Check if entity exists
-	if _, exists := s.data[entityType][id]; !exists {
+	current, exists := s.data[entityType][id]
+	if !exists {
 		return ErrNotFound
 	}
 
+	if s.versioned[entityType] {
+		version, err := NextVersion(current, data)
+		if err != nil {
+			return err
+		}
+		data[VersionField] = version
+	}
+
 	// Ensure ID is preserved
 	data["id"] = id
 
 	// Replace the entity
+	s.recordHistory(entityType, id, HistoryUpdate, current)
+	s.markWritten(entityType, id)
+	s.unindexEntity(entityType, id, current)
 	s.data[entityType][id] = copyMap(data)
+	s.indexEntity(entityType, id, s.data[entityType][id])
 
 	return nil
 }
@@ -291,36 +446,166 @@ func (s *InMemoryStore) Patch(entityType, id string, data map[string]interface{}
 		return ErrNotFound
 	}
 
+	version := 0
+	if s.versioned[entityType] {
+		next, err := NextVersion(entity, data)
+		if err != nil {
+			return err
+		}
+		version = next
+	}
+
 	// Merge the data
+	s.recordHistory(entityType, id, HistoryPatch, entity)
+	s.markWritten(entityType, id)
+	s.unindexEntity(entityType, id, entity)
+	defer s.indexEntity(entityType, id, entity)
 	for key, value := range data {
 		// Don't allow changing the ID
 		if key != "id" {
-			entity[key] = value
+			entity[key] = types.CopyValue(value)
 		}
 	}
+	if version > 0 {
+		entity[VersionField] = version
+	}
+
+	return nil
+}
+
+// PatchWhere merges data into every entity matching filters under a single
+// lock. For versioned types every match must accept the data's version (if
+// any) before any of them is changed.
+func (s *InMemoryStore) PatchWhere(entityType string, filters map[string]string, data map[string]interface{}) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data[entityType] == nil {
+		return 0, ErrEntityTypeNotFound
+	}
+
+	ids, indexed := s.indexedCandidates(entityType, filters)
+	if !indexed {
+		ids = make([]string, 0, len(s.data[entityType]))
+		for id := range s.data[entityType] {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+	}
+
+	var matched []string
+	versions := make(map[string]int)
+	for _, id := range ids {
+		entity := s.data[entityType][id]
+		if !matchesFilters(entity, filters) {
+			continue
+		}
+		if s.versioned[entityType] {
+			version, err := NextVersion(entity, data)
+			if err != nil {
+				return 0, err
+			}
+			versions[id] = version
+		}
+		matched = append(matched, id)
+	}
+
+	for _, id := range matched {
+		entity := s.data[entityType][id]
+		s.recordHistory(entityType, id, HistoryPatch, entity)
+		s.markWritten(entityType, id)
+		s.unindexEntity(entityType, id, entity)
+		for key, value := range data {
+			if key != "id" {
+				entity[key] = types.CopyValue(value)
+			}
+		}
+		if version, ok := versions[id]; ok {
+			entity[VersionField] = version
+		}
+		s.indexEntity(entityType, id, entity)
+	}
+
+	return len(matched), nil
+}
+
+// UpdateArray passes a copy of the entity's array field (nil if unset) to fn
+// and stores what it returns. An error from fn is returned as is, with the
+// entity unchanged.
+func (s *InMemoryStore) UpdateArray(entityType, id, field string, fn func([]interface{}) ([]interface{}, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data[entityType] == nil {
+		return ErrEntityTypeNotFound
+	}
+	entity, exists := s.data[entityType][id]
+	if !exists {
+		return ErrNotFound
+	}
+
+	var current []interface{}
+	switch value := entity[field].(type) {
+	case nil:
+	case []interface{}:
+		current = types.CopyValue(value).([]interface{})
+	default:
+		return ErrNotArray
+	}
+	next, err := fn(current)
+	if err != nil {
+		return err
+	}
+
+	version := 0
+	if s.versioned[entityType] {
+		if version, err = NextVersion(entity, nil); err != nil {
+			return err
+		}
+	}
+
+	s.recordHistory(entityType, id, HistoryPatch, entity)
+	s.markWritten(entityType, id)
+	s.unindexEntity(entityType, id, entity)
+	entity[field] = types.CopyValue(next)
+	if version > 0 {
+		entity[VersionField] = version
+	}
+	s.indexEntity(entityType, id, entity)
 
 	return nil
 }
 
 // Delete removes an entity
 func (s *InMemoryStore) Delete(entityType, id string) error {
+	_, err := s.Remove(entityType, id)
+	return err
+}
+
+// Remove deletes an entity and returns it as it was stored. The read and
+// the delete happen under one lock, so no other write can slip in between.
+func (s *InMemoryStore) Remove(entityType, id string) (map[string]interface{}, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	// Check if entity type exists
 	if s.data[entityType] == nil {
-		return ErrEntityTypeNotFound
+		return nil, ErrEntityTypeNotFound
 	}
 
 	// Check if entity exists
-	if _, exists := s.data[entityType][id]; !exists {
-		return ErrNotFound
+	entity, exists := s.data[entityType][id]
+	if !exists {
+		return nil, ErrNotFound
 	}
 
 	// Delete the entity
+	s.recordHistory(entityType, id, HistoryDelete, entity)
+	s.unindexEntity(entityType, id, entity)
 	delete(s.data[entityType], id)
+	delete(s.pending[entityType], id)
 
-	return nil
+	return entity, nil
 }
 
 // Seed loads initial data into storage
@@ -348,8 +633,16 @@ func (s *InMemoryStore) Seed(entityType string, entities []map[string]interface{
 			continue
 		}
 
-		// Store the entity
+		// Store the entity; seeded data is visible immediately
+		if previous, exists := s.data[entityType][id]; exists {
+			s.unindexEntity(entityType, id, previous)
+		}
 		s.data[entityType][id] = copyMap(entity)
+		if _, hasVersion := s.data[entityType][id][VersionField]; s.versioned[entityType] && !hasVersion {
+			s.data[entityType][id][VersionField] = 1
+		}
+		s.indexEntity(entityType, id, s.data[entityType][id])
+		delete(s.pending[entityType], id)
 
 		// Update counter to ensure we don't generate duplicate IDs
 		if numID := parseIDNumber(id); numID > s.counter[entityType] {
@@ -360,13 +653,46 @@ func (s *InMemoryStore) Seed(entityType string, entities []map[string]interface{
 	return nil
 }
 
+// nextID advances the entity type's counter to the next id not already
+// taken. Client-provided ids share the numeric namespace, so a custom "5"
+// makes the counter skip 5 rather than overwrite it. Callers hold the lock.
+func (s *InMemoryStore) nextID(entityType string) string {
+	for {
+		s.counter[entityType]++
+		id := formatID(s.counter[entityType])
+		if _, taken := s.data[entityType][id]; !taken {
+			return id
+		}
+	}
+}
+
+// Reset removes all entities of a type and restarts its ID counter
+func (s *InMemoryStore) Reset(entityType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data[entityType] == nil {
+		return ErrEntityTypeNotFound
+	}
+
+	s.data[entityType] = make(map[string]map[string]interface{})
+	s.counter[entityType] = s.startID[entityType]
+	delete(s.pending, entityType)
+	s.clearIndexes(entityType)
+	if _, tracked := s.history[entityType]; tracked {
+		s.history[entityType] = make(map[string][]HistoryEntry)
+	}
+
+	return nil
+}
+
 // Helper functions
 
 // copyMap creates a deep copy of a map
 func copyMap(src map[string]interface{}) map[string]interface{} {
 	dst := make(map[string]interface{}, len(src))
 	for key, value := range src {
-		dst[key] = value
+		dst[key] = types.CopyValue(value)
 	}
 	return dst
 }
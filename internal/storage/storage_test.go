@@ -1,6 +1,9 @@
 package storage
 
 import (
+	"encoding/json"
+	"errors"
+	"reflect"
 	"sync"
 	"testing"
 
@@ -74,6 +77,18 @@ func TestCreate(t *testing.T) {
 			data:       map[string]interface{}{"name": "Charlie"},
 			wantErr:    true,
 		},
+		{
+			name:       "create with numeric ID",
+			entityType: "users",
+			data:       map[string]interface{}{"id": json.Number("123"), "name": "Dan"},
+			wantErr:    true,
+		},
+		{
+			name:       "create with boolean ID",
+			entityType: "users",
+			data:       map[string]interface{}{"id": true, "name": "Eve"},
+			wantErr:    true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -312,6 +327,149 @@ func TestPatch(t *testing.T) {
 	}
 }
 
+func TestPatchWhere(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Initialize([]string{"users"})
+	store.SetVersioned("users")
+	store.Seed("users", []map[string]interface{}{
+		{"id": "1", "role": "trial"},
+		{"id": "2", "role": "admin"},
+		{"id": "3", "role": "trial"},
+	})
+
+	updated, err := store.PatchWhere("users", map[string]string{"role": "trial"}, map[string]interface{}{"role": "active", "id": "9"})
+	if err != nil {
+		t.Fatalf("PatchWhere() error = %v", err)
+	}
+	if updated != 2 {
+		t.Errorf("PatchWhere() updated = %d, want 2", updated)
+	}
+	for id, want := range map[string]string{"1": "active", "2": "admin", "3": "active"} {
+		entity, err := store.Get("users", id)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", id, err)
+		}
+		if entity["role"] != want {
+			t.Errorf("user %s role = %v, want %v", id, entity["role"], want)
+		}
+	}
+	if entity, _ := store.Get("users", "1"); entity[VersionField] != 2 {
+		t.Errorf("version = %v, want 2", entity[VersionField])
+	}
+
+	// A stale version on any match leaves every entity untouched
+	store.Patch("users", "3", map[string]interface{}{"note": "bumped"})
+	_, err = store.PatchWhere("users", map[string]string{"role": "active"}, map[string]interface{}{"role": "gone", VersionField: 2})
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("PatchWhere() with stale version error = %v, want ErrVersionConflict", err)
+	}
+	if entity, _ := store.Get("users", "1"); entity["role"] != "active" {
+		t.Errorf("user 1 role = %v after a rejected bulk patch, want active", entity["role"])
+	}
+
+	if updated, err := store.PatchWhere("users", map[string]string{"role": "none"}, map[string]interface{}{"role": "x"}); err != nil || updated != 0 {
+		t.Errorf("PatchWhere() with no matches = %d, %v; want 0, nil", updated, err)
+	}
+	if _, err := store.PatchWhere("ghosts", nil, nil); err != ErrEntityTypeNotFound {
+		t.Errorf("PatchWhere() unknown type error = %v, want ErrEntityTypeNotFound", err)
+	}
+}
+
+func TestUpdateArray(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Initialize([]string{"posts"})
+	store.Seed("posts", []map[string]interface{}{
+		{"id": "1", "tags": []interface{}{"a"}, "title": "First"},
+		{"id": "2"},
+	})
+
+	appendTag := func(tag string) func([]interface{}) ([]interface{}, error) {
+		return func(current []interface{}) ([]interface{}, error) {
+			return append(current, tag), nil
+		}
+	}
+
+	// Concurrent appends are all kept
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := store.UpdateArray("posts", "1", "tags", appendTag("x")); err != nil {
+				t.Errorf("UpdateArray() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if entity, _ := store.Get("posts", "1"); len(entity["tags"].([]interface{})) != 51 {
+		t.Errorf("tags has %d elements, want 51", len(entity["tags"].([]interface{})))
+	}
+
+	if err := store.UpdateArray("posts", "2", "tags", appendTag("new")); err != nil {
+		t.Fatalf("UpdateArray() on unset field error = %v", err)
+	}
+	if entity, _ := store.Get("posts", "2"); !reflect.DeepEqual(entity["tags"], []interface{}{"new"}) {
+		t.Errorf("tags = %v, want [new]", entity["tags"])
+	}
+
+	errFromFn := errors.New("rejected")
+	err := store.UpdateArray("posts", "2", "tags", func([]interface{}) ([]interface{}, error) { return nil, errFromFn })
+	if err != errFromFn {
+		t.Errorf("UpdateArray() error = %v, want the callback's error", err)
+	}
+	if entity, _ := store.Get("posts", "2"); !reflect.DeepEqual(entity["tags"], []interface{}{"new"}) {
+		t.Errorf("tags = %v after a rejected update, want [new]", entity["tags"])
+	}
+
+	if err := store.UpdateArray("posts", "1", "title", appendTag("x")); err != ErrNotArray {
+		t.Errorf("UpdateArray() on a string field error = %v, want ErrNotArray", err)
+	}
+	if err := store.UpdateArray("posts", "9", "tags", appendTag("x")); err != ErrNotFound {
+		t.Errorf("UpdateArray() on a missing entity error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Initialize([]string{"users"})
+	id, _ := store.Create("users", map[string]interface{}{"name": "Alice"})
+
+	removed, err := store.Remove("users", id)
+	if err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if removed["id"] != id || removed["name"] != "Alice" {
+		t.Errorf("Remove() = %v, want the stored entity", removed)
+	}
+	if _, err := store.Get("users", id); err != ErrNotFound {
+		t.Errorf("Get() after Remove() error = %v, want ErrNotFound", err)
+	}
+	if _, err := store.Remove("users", id); err != ErrNotFound {
+		t.Errorf("second Remove() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGetMany(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Initialize([]string{"users"})
+	store.Seed("users", []map[string]interface{}{
+		{"id": "1", "name": "Alice"},
+		{"id": "2", "name": "Bob"},
+	})
+
+	entities, err := store.GetMany("users", []string{"2", "9", "1"})
+	if err != nil {
+		t.Fatalf("GetMany() error = %v", err)
+	}
+	if len(entities) != 3 || entities[0]["name"] != "Bob" || entities[1] != nil || entities[2]["name"] != "Alice" {
+		t.Errorf("GetMany() = %v, want [Bob nil Alice]", entities)
+	}
+
+	if _, err := store.GetMany("nonexistent", []string{"1"}); err != ErrEntityTypeNotFound {
+		t.Errorf("GetMany() error = %v, want ErrEntityTypeNotFound", err)
+	}
+}
+
 func TestDelete(t *testing.T) {
 	store := NewInMemoryStore()
 	store.Initialize([]string{"users"})
@@ -452,6 +610,102 @@ func TestConcurrentAccess(t *testing.T) {
 	}
 }
 
+func TestCreateSkipsTakenIDs(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Initialize([]string{"users"})
+
+	store.Create("users", map[string]interface{}{"name": "Alice"})
+	store.Create("users", map[string]interface{}{"id": "2", "name": "Custom"})
+	store.Create("users", map[string]interface{}{"id": "3", "name": "Custom"})
+
+	id, err := store.Create("users", map[string]interface{}{"name": "Bob"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if id != "4" {
+		t.Errorf("generated id = %q, want %q", id, "4")
+	}
+	if custom, _ := store.Get("users", "2"); custom["name"] != "Custom" {
+		t.Errorf("custom entity 2 = %v, want it untouched", custom)
+	}
+
+	// A provided id already in use is rejected rather than replacing it
+	if _, err := store.Create("users", map[string]interface{}{"id": "2", "name": "Mallory"}); !errors.Is(err, ErrIDTaken) {
+		t.Errorf("Create() with taken id error = %v, want %v", err, ErrIDTaken)
+	}
+	if custom, _ := store.Get("users", "2"); custom["name"] != "Custom" {
+		t.Errorf("custom entity 2 = %v, want it untouched", custom)
+	}
+}
+
+func TestConcurrentCustomAndGeneratedIDs(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Initialize([]string{"users"})
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	generated := make(map[string]bool)
+	rejected := make(map[string]bool)
+	iterations := 100
+
+	// Custom ids land in the range the counter is counting through
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(offset int) {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				id := formatID(offset + j*3 + 1)
+				_, err := store.Create("users", map[string]interface{}{"id": id, "name": "Custom"})
+				if err != nil && !errors.Is(err, ErrIDTaken) {
+					t.Errorf("Create(%s) error = %v", id, err)
+				}
+				mu.Lock()
+				rejected[id] = err != nil
+				mu.Unlock()
+			}
+		}(i)
+	}
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				id, err := store.Create("users", map[string]interface{}{"name": "Generated"})
+				if err != nil {
+					t.Errorf("Create() error = %v", err)
+					return
+				}
+				mu.Lock()
+				if generated[id] {
+					t.Errorf("id %q generated twice", id)
+				}
+				generated[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// A custom id the counter took first is rejected, and a generated id
+	// never replaces a custom one
+	for n := 1; n <= 3*iterations; n++ {
+		id := formatID(n)
+		want := "Custom"
+		if rejected[id] {
+			want = "Generated"
+		}
+		entity, err := store.Get("users", id)
+		if err != nil || entity["name"] != want {
+			t.Errorf("entity %s = %v (%v), want name %s", id, entity, err, want)
+		}
+	}
+	for id := range generated {
+		if _, err := store.Get("users", id); err != nil {
+			t.Errorf("generated entity %s: %v", id, err)
+		}
+	}
+}
+
 func TestFormatID(t *testing.T) {
 	tests := []struct {
 		counter int
@@ -538,6 +792,83 @@ func TestListQuery_Filtering(t *testing.T) {
 	}
 }
 
+func TestListQuery_Conditions(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Initialize([]string{"users"})
+	store.Seed("users", []map[string]interface{}{
+		{"id": "1", "name": "Alice", "age": float64(17), "status": "active", "joined": "2023-01-10T00:00:00Z"},
+		{"id": "2", "name": "Malice", "age": json.Number("30"), "status": "pending", "joined": "2024-05-01T00:00:00Z"},
+		{"id": "3", "name": "Bob", "age": float64(65), "status": "banned"},
+	})
+
+	cond := func(field, op, value string) types.FilterCondition {
+		return types.FilterCondition{Field: field, Op: op, Value: value}
+	}
+
+	tests := []struct {
+		name       string
+		conditions []types.FilterCondition
+		wantIDs    []string
+	}{
+		{"like is case-insensitive", []types.FilterCondition{cond("name", types.FilterOpLike, "ALI")}, []string{"1", "2"}},
+		{"gte", []types.FilterCondition{cond("age", types.FilterOpGte, "18")}, []string{"2", "3"}},
+		{"lt", []types.FilterCondition{cond("age", types.FilterOpLt, "65")}, []string{"1", "2"}},
+		{"range", []types.FilterCondition{cond("age", types.FilterOpGt, "17"), cond("age", types.FilterOpLte, "30")}, []string{"2"}},
+		{"in", []types.FilterCondition{cond("status", types.FilterOpIn, "active, pending")}, []string{"1", "2"}},
+		{"ne includes missing", []types.FilterCondition{cond("joined", types.FilterOpNe, "2023-01-10T00:00:00Z")}, []string{"2", "3"}},
+		{"string order", []types.FilterCondition{cond("joined", types.FilterOpGte, "2024-01-01")}, []string{"2"}},
+		{"non-numeric bound", []types.FilterCondition{cond("age", types.FilterOpGt, "old")}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := store.ListQuery("users", types.QueryOpts{Conditions: tt.conditions})
+			if err != nil {
+				t.Fatalf("ListQuery() error = %v", err)
+			}
+			var ids []string
+			for _, item := range result.Items {
+				ids = append(ids, item["id"].(string))
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("ListQuery() ids = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestListQuery_FilterJSONNumber(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Initialize([]string{"accounts"})
+	store.Seed("accounts", []map[string]interface{}{
+		{"id": "1", "balance": json.Number("9007199254740993")},
+		{"id": "2", "balance": json.Number("9007199254740992")},
+		{"id": "3", "balance": json.Number("1.50")},
+	})
+
+	tests := []struct {
+		name      string
+		value     string
+		wantCount int
+	}{
+		{"exact large integer", "9007199254740993", 1},
+		{"numerically equal", "1.5", 1},
+		{"no match", "42", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := store.ListQuery("accounts", types.QueryOpts{Filters: map[string]string{"balance": tt.value}})
+			if err != nil {
+				t.Fatalf("ListQuery() error = %v", err)
+			}
+			if len(result.Items) != tt.wantCount {
+				t.Errorf("ListQuery() returned %d items, want %d", len(result.Items), tt.wantCount)
+			}
+		})
+	}
+}
+
 func TestListQuery_OffsetPagination(t *testing.T) {
 	store := NewInMemoryStore()
 	store.Initialize([]string{"users"})
@@ -650,3 +981,108 @@ func TestCopyMap(t *testing.T) {
 		t.Error("modifying copied map affected original map")
 	}
 }
+
+func TestReset(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Initialize([]string{"users", "posts"})
+	store.Seed("users", []map[string]interface{}{
+		{"id": "5", "name": "Alice"},
+	})
+	store.Seed("posts", []map[string]interface{}{
+		{"id": "1", "title": "Hello"},
+	})
+
+	if err := store.Reset("users"); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+
+	users, _ := store.List("users")
+	if len(users) != 0 {
+		t.Errorf("expected no users after reset, got %d", len(users))
+	}
+	posts, _ := store.List("posts")
+	if len(posts) != 1 {
+		t.Errorf("reset should not affect other types, got %d posts", len(posts))
+	}
+
+	// ID counter restarts
+	id, _ := store.Create("users", map[string]interface{}{"name": "Bob"})
+	if id != "1" {
+		t.Errorf("expected ID 1 after reset, got %s", id)
+	}
+
+	if err := store.Reset("unknown"); err != ErrEntityTypeNotFound {
+		t.Errorf("Reset(unknown) error = %v, want %v", err, ErrEntityTypeNotFound)
+	}
+}
+
+func TestSetStartID(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Initialize([]string{"users", "posts"})
+	store.SetStartID("users", 1000)
+
+	id, _ := store.Create("users", map[string]interface{}{"name": "Alice"})
+	if id != "1001" {
+		t.Errorf("first id = %s, want 1001", id)
+	}
+	if id, _ := store.Create("posts", map[string]interface{}{}); id != "1" {
+		t.Errorf("other types should start at 1, got %s", id)
+	}
+
+	// Seeded ids past the start still advance the counter
+	store.Seed("users", []map[string]interface{}{{"id": "2000", "name": "Bob"}})
+	if id, _ := store.Create("users", map[string]interface{}{"name": "Carol"}); id != "2001" {
+		t.Errorf("id after seeding 2000 = %s, want 2001", id)
+	}
+
+	// Reset returns to the configured start
+	store.Reset("users")
+	if id, _ := store.Create("users", map[string]interface{}{"name": "Dan"}); id != "1001" {
+		t.Errorf("id after reset = %s, want 1001", id)
+	}
+}
+
+func TestStoredEntitiesAreDeepCopies(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Initialize([]string{"users"})
+
+	tags := []interface{}{"a"}
+	id, _ := store.Create("users", map[string]interface{}{"tags": tags, "prefs": map[string]interface{}{"theme": "dark"}})
+
+	// Mutating the caller's data or a returned copy must not reach the store
+	tags[0] = "changed"
+	got, _ := store.Get("users", id)
+	got["prefs"].(map[string]interface{})["theme"] = "light"
+
+	stored, _ := store.Get("users", id)
+	if stored["tags"].([]interface{})[0] != "a" {
+		t.Errorf("tags = %v, want [a]", stored["tags"])
+	}
+	if stored["prefs"].(map[string]interface{})["theme"] != "dark" {
+		t.Errorf("prefs = %v, want theme dark", stored["prefs"])
+	}
+}
+
+func TestPatchedValuesAreDeepCopies(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Initialize([]string{"users"})
+	id, _ := store.Create("users", map[string]interface{}{"name": "Alice"})
+
+	tags := []interface{}{"a"}
+	prefs := map[string]interface{}{"theme": "dark"}
+	if err := store.Patch("users", id, map[string]interface{}{"tags": tags, "prefs": prefs}); err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+
+	// Mutating the caller's patch data must not reach the store
+	tags[0] = "changed"
+	prefs["theme"] = "light"
+
+	stored, _ := store.Get("users", id)
+	if stored["tags"].([]interface{})[0] != "a" {
+		t.Errorf("tags = %v, want [a]", stored["tags"])
+	}
+	if stored["prefs"].(map[string]interface{})["theme"] != "dark" {
+		t.Errorf("prefs = %v, want theme dark", stored["prefs"])
+	}
+}
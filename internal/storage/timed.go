@@ -0,0 +1,267 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ticktockbent/ape_my/pkg/types"
+)
+
+// timingSamples is how many recent durations each operation keeps for percentiles
+const timingSamples = 1024
+
+// OperationStats summarizes the timings recorded for one store operation
+type OperationStats struct {
+	Count int64   `json:"count"`
+	AvgMs float64 `json:"avgMs"`
+	P50Ms float64 `json:"p50Ms"`
+	P95Ms float64 `json:"p95Ms"`
+	P99Ms float64 `json:"p99Ms"`
+	MaxMs float64 `json:"maxMs"`
+}
+
+// StatsReporter is implemented by stores that time their operations
+type StatsReporter interface {
+	// OperationStats returns the stats for each operation called so far,
+	// keyed by operation name (create, get, list, ...)
+	OperationStats() map[string]OperationStats
+}
+
+// operationTimings accumulates the durations of one operation. Count, total
+// and max cover every call; percentiles use the most recent timingSamples.
+type operationTimings struct {
+	count   int64
+	total   time.Duration
+	max     time.Duration
+	samples []time.Duration // ring buffer, next write at count % timingSamples
+}
+
+// TimedStore wraps a Store and records how long each operation takes
+type TimedStore struct {
+	inner Store
+
+	mu      sync.Mutex
+	timings map[string]*operationTimings
+}
+
+// NewTimedStore returns a Store that times every call before passing it to inner
+func NewTimedStore(inner Store) *TimedStore {
+	return &TimedStore{
+		inner:   inner,
+		timings: make(map[string]*operationTimings),
+	}
+}
+
+// record adds the time elapsed since start to the operation's timings
+func (t *TimedStore) record(operation string, start time.Time) {
+	elapsed := time.Since(start)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timings := t.timings[operation]
+	if timings == nil {
+		timings = &operationTimings{}
+		t.timings[operation] = timings
+	}
+	if len(timings.samples) < timingSamples {
+		timings.samples = append(timings.samples, elapsed)
+	} else {
+		timings.samples[timings.count%timingSamples] = elapsed
+	}
+	timings.count++
+	timings.total += elapsed
+	if elapsed > timings.max {
+		timings.max = elapsed
+	}
+}
+
+// OperationStats returns the stats for each operation called so far
+func (t *TimedStore) OperationStats() map[string]OperationStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := make(map[string]OperationStats, len(t.timings))
+	for operation, timings := range t.timings {
+		sorted := append([]time.Duration(nil), timings.samples...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		stats[operation] = OperationStats{
+			Count: timings.count,
+			AvgMs: milliseconds(timings.total / time.Duration(timings.count)),
+			P50Ms: milliseconds(percentile(sorted, 50)),
+			P95Ms: milliseconds(percentile(sorted, 95)),
+			P99Ms: milliseconds(percentile(sorted, 99)),
+			MaxMs: milliseconds(timings.max),
+		}
+	}
+	return stats
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted durations
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// Create adds a new entity and returns its ID
+func (t *TimedStore) Create(entityType string, data map[string]interface{}) (string, error) {
+	defer t.record("create", time.Now())
+	return t.inner.Create(entityType, data)
+}
+
+// Get retrieves a single entity by ID
+func (t *TimedStore) Get(entityType string, id string) (map[string]interface{}, error) {
+	defer t.record("get", time.Now())
+	return t.inner.Get(entityType, id)
+}
+
+// GetMany retrieves several entities by ID in one call
+func (t *TimedStore) GetMany(entityType string, ids []string) ([]map[string]interface{}, error) {
+	defer t.record("getMany", time.Now())
+	return t.inner.GetMany(entityType, ids)
+}
+
+// List retrieves all entities of a given type
+func (t *TimedStore) List(entityType string) ([]map[string]interface{}, error) {
+	defer t.record("list", time.Now())
+	return t.inner.List(entityType)
+}
+
+// ListQuery retrieves entities with filtering, pagination, and cursor support
+func (t *TimedStore) ListQuery(entityType string, opts types.QueryOpts) (*types.QueryResult, error) {
+	defer t.record("listQuery", time.Now())
+	return t.inner.ListQuery(entityType, opts)
+}
+
+// Update replaces an entire entity
+func (t *TimedStore) Update(entityType string, id string, data map[string]interface{}) error {
+	defer t.record("update", time.Now())
+	return t.inner.Update(entityType, id, data)
+}
+
+// Patch partially updates an entity
+func (t *TimedStore) Patch(entityType string, id string, data map[string]interface{}) error {
+	defer t.record("patch", time.Now())
+	return t.inner.Patch(entityType, id, data)
+}
+
+// PatchWhere applies one partial update to every entity matching filters
+func (t *TimedStore) PatchWhere(entityType string, filters map[string]string, data map[string]interface{}) (int, error) {
+	defer t.record("patchWhere", time.Now())
+	return t.inner.PatchWhere(entityType, filters, data)
+}
+
+// UpdateArray replaces an array field with the result of fn under one lock
+func (t *TimedStore) UpdateArray(entityType, id, field string, fn func([]interface{}) ([]interface{}, error)) error {
+	defer t.record("updateArray", time.Now())
+	return t.inner.UpdateArray(entityType, id, field, fn)
+}
+
+// Delete removes an entity
+func (t *TimedStore) Delete(entityType string, id string) error {
+	defer t.record("delete", time.Now())
+	return t.inner.Delete(entityType, id)
+}
+
+// Remove deletes an entity and returns it as it was stored
+func (t *TimedStore) Remove(entityType string, id string) (map[string]interface{}, error) {
+	defer t.record("remove", time.Now())
+	return t.inner.Remove(entityType, id)
+}
+
+// Initialize sets up storage for entity types
+func (t *TimedStore) Initialize(entityTypes []string) error {
+	return t.inner.Initialize(entityTypes)
+}
+
+// Seed loads initial data into storage
+func (t *TimedStore) Seed(entityType string, entities []map[string]interface{}) error {
+	defer t.record("seed", time.Now())
+	return t.inner.Seed(entityType, entities)
+}
+
+// Reset removes all entities of a type and restarts its ID counter
+func (t *TimedStore) Reset(entityType string) error {
+	defer t.record("reset", time.Now())
+	return t.inner.Reset(entityType)
+}
+
+// GetLatest passes through to the wrapped store's LatestGetter, falling back
+// to Get when it has none
+func (t *TimedStore) GetLatest(entityType, id string) (map[string]interface{}, error) {
+	defer t.record("get", time.Now())
+	if latest, ok := t.inner.(LatestGetter); ok {
+		return latest.GetLatest(entityType, id)
+	}
+	return t.inner.Get(entityType, id)
+}
+
+// History passes through to the wrapped store's HistoryReader; stores
+// without one report no history
+func (t *TimedStore) History(entityType, id string) ([]HistoryEntry, error) {
+	defer t.record("history", time.Now())
+	if reader, ok := t.inner.(HistoryReader); ok {
+		return reader.History(entityType, id)
+	}
+	return nil, ErrNotFound
+}
+
+// Each passes through to the wrapped store's Iterator, falling back to
+// iterating a ListQuery result when it has none. It is not timed, since
+// most of its time is spent in fn.
+func (t *TimedStore) Each(entityType string, opts types.QueryOpts, fn func(map[string]interface{}) bool) error {
+	if iterator, ok := t.inner.(Iterator); ok {
+		return iterator.Each(entityType, opts, fn)
+	}
+	result, err := t.inner.ListQuery(entityType, opts)
+	if err != nil {
+		return err
+	}
+	for _, item := range result.Items {
+		if !fn(item) {
+			break
+		}
+	}
+	return nil
+}
+
+// Configure passes through to the wrapped store's Configurer; stores
+// without one ignore it
+func (t *TimedStore) Configure(entityType string, settings EntitySettings) {
+	if configurer, ok := t.inner.(Configurer); ok {
+		configurer.Configure(entityType, settings)
+	}
+}
+
+// SetConsistencyLag passes through to the wrapped store's Configurer
+func (t *TimedStore) SetConsistencyLag(lag time.Duration) {
+	if configurer, ok := t.inner.(Configurer); ok {
+		configurer.SetConsistencyLag(lag)
+	}
+}
+
+// SchedulePatch passes through to the wrapped store's Scheduler; stores
+// without one ignore it
+func (t *TimedStore) SchedulePatch(entityType, id string, after time.Duration, data map[string]interface{}) {
+	if scheduler, ok := t.inner.(Scheduler); ok {
+		scheduler.SchedulePatch(entityType, id, after, data)
+	}
+}
+
+// CancelScheduled passes through to the wrapped store's Scheduler
+func (t *TimedStore) CancelScheduled() {
+	if scheduler, ok := t.inner.(Scheduler); ok {
+		scheduler.CancelScheduled()
+	}
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimedStore(t *testing.T) {
+	store := NewTimedStore(NewInMemoryStore())
+	store.Initialize([]string{"users"})
+
+	id, err := store.Create("users", map[string]interface{}{"name": "Alice"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := store.Get("users", id); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+	}
+	if _, err := store.Get("users", "missing"); err != ErrNotFound {
+		t.Errorf("Get() error = %v, want ErrNotFound", err)
+	}
+
+	stats := store.OperationStats()
+	if stats["create"].Count != 1 {
+		t.Errorf("create count = %d, want 1", stats["create"].Count)
+	}
+	if stats["get"].Count != 4 {
+		t.Errorf("get count = %d, want 4 (failed calls included)", stats["get"].Count)
+	}
+	if _, exists := stats["delete"]; exists {
+		t.Error("operations never called should not be reported")
+	}
+	get := stats["get"]
+	if get.P50Ms > get.P99Ms || get.P99Ms > get.MaxMs {
+		t.Errorf("percentiles out of order: %+v", get)
+	}
+
+	// Optional interfaces reach the wrapped store
+	var _ LatestGetter = store
+	var _ Scheduler = store
+	if latest, err := store.GetLatest("users", id); err != nil || latest["name"] != "Alice" {
+		t.Errorf("GetLatest() = %v, %v", latest, err)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i))
+	}
+	tests := []struct {
+		p    int
+		want time.Duration
+	}{
+		{50, 50},
+		{95, 95},
+		{99, 99},
+		{100, 100},
+	}
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%d) = %d, want %d", tt.p, got, tt.want)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("percentile of no samples = %d, want 0", got)
+	}
+}
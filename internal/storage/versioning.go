@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// VersionField is the store-managed optimistic locking counter
+const VersionField = "version"
+
+// ErrVersionConflict is returned when an update carries a stale version
+var ErrVersionConflict = errors.New("version conflict")
+
+// VersionConflictError reports the stored version on a conflict; it matches
+// ErrVersionConflict with errors.Is
+type VersionConflictError struct {
+	Current int
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%v: current version is %d", ErrVersionConflict, e.Current)
+}
+
+// Is reports whether target is ErrVersionConflict
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+// SetVersioned makes the store manage VersionField for an entity type: it is
+// set to 1 on create and incremented on every update and patch. A write that
+// includes a version is rejected with ErrVersionConflict unless it matches
+// the stored one.
+func (s *InMemoryStore) SetVersioned(entityType string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.versioned[entityType] = true
+}
+
+// NextVersion checks the expected version in data (if any) against the
+// stored entity and returns the version the write should store
+func NextVersion(current, data map[string]interface{}) (int, error) {
+	stored, _ := versionNumber(current[VersionField])
+	if expected, present := data[VersionField]; present {
+		if got, ok := versionNumber(expected); !ok || got != stored {
+			return 0, &VersionConflictError{Current: stored}
+		}
+	}
+	return stored + 1, nil
+}
+
+// versionNumber converts a decoded JSON version value to an int
+func versionNumber(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), true
+		}
+	case json.Number:
+		if n, err := strconv.Atoi(string(v)); err == nil {
+			return n, true
+		}
+	}
+	return 0, false
+}
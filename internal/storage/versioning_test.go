@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestVersioning(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Initialize([]string{"users", "posts"})
+	store.SetVersioned("users")
+
+	id, _ := store.Create("users", map[string]interface{}{"name": "Alice", "version": 99})
+	assertVersion := func(want int) {
+		t.Helper()
+		entity, _ := store.Get("users", id)
+		if entity[VersionField] != want {
+			t.Errorf("version = %v, want %d", entity[VersionField], want)
+		}
+	}
+	assertVersion(1)
+
+	// Writes without a version always succeed and bump the counter
+	if err := store.Patch("users", id, map[string]interface{}{"name": "Bob"}); err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+	assertVersion(2)
+
+	// A matching version is accepted, however the number was decoded
+	if err := store.Update("users", id, map[string]interface{}{"name": "Carol", "version": json.Number("2")}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	assertVersion(3)
+
+	// A stale version is rejected and nothing changes
+	err := store.Patch("users", id, map[string]interface{}{"name": "Dan", "version": float64(2)})
+	var conflict *VersionConflictError
+	if !errors.Is(err, ErrVersionConflict) || !errors.As(err, &conflict) || conflict.Current != 3 {
+		t.Fatalf("Patch() error = %v, want version conflict at 3", err)
+	}
+	entity, _ := store.Get("users", id)
+	if entity["name"] != "Carol" {
+		t.Errorf("name = %v, want unchanged Carol", entity["name"])
+	}
+	assertVersion(3)
+
+	// Seeded entities start at 1; other types are untouched
+	store.Seed("users", []map[string]interface{}{{"id": "seeded"}})
+	if seeded, _ := store.Get("users", "seeded"); seeded[VersionField] != 1 {
+		t.Errorf("seeded version = %v, want 1", seeded[VersionField])
+	}
+	postID, _ := store.Create("posts", map[string]interface{}{"title": "Hi"})
+	if post, _ := store.Get("posts", postID); post[VersionField] != nil {
+		t.Errorf("unversioned entity got version %v", post[VersionField])
+	}
+}
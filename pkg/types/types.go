@@ -1,14 +1,72 @@
 package types
 
+import "encoding/json"
+
 // Schema represents the entire schema definition
 type Schema struct {
-	BasePath        string                `json:"basePath,omitempty"`
-	Entities        map[string]*Entity    `json:"entities"`
-	ResponseHeaders map[string]string     `json:"responseHeaders,omitempty"`
-	Auth            *AuthConfig           `json:"auth,omitempty"`
+	Port            int                    `json:"port,omitempty"` // default port; "on <port>" overrides it
+	BasePath        string                 `json:"basePath,omitempty"`
+	Entities        map[string]*Entity     `json:"entities"`
+	Definitions     map[string]*Definition `json:"definitions,omitempty"` // reusable field groups entities pull in with "extends"
+	ResponseHeaders map[string]string      `json:"responseHeaders,omitempty"`
+	Auth            *AuthConfig            `json:"auth,omitempty"`
 	ResponseWrapper *ResponseWrapperConfig `json:"responseWrapper,omitempty"`
-	Pagination      *PaginationConfig     `json:"pagination,omitempty"`
-	Routes          []*CustomRoute        `json:"routes,omitempty"`
+	Pagination      *PaginationConfig      `json:"pagination,omitempty"`
+	Routes          []*CustomRoute         `json:"routes,omitempty"`
+	Consistency     *ConsistencyConfig     `json:"consistency,omitempty"`
+	AsyncComplete   *AsyncCompleteConfig   `json:"asyncComplete,omitempty"`
+	Warmup          *WarmupConfig          `json:"warmup,omitempty"`
+	LazyInit        *LazyInitConfig        `json:"lazyInit,omitempty"`
+	ListFormat      string                 `json:"listFormat,omitempty"` // "array" (default) or "map"; entities may override
+	FieldCase       string                 `json:"fieldCase,omitempty"`  // "camel" or "snake": casing of field names in requests and responses
+	Middleware      []string               `json:"middleware,omitempty"` // request steps to run, in order (default DefaultMiddleware)
+	APIVersions     *APIVersionsConfig     `json:"apiVersions,omitempty"`
+	AutoLinks       bool                   `json:"autoLinks,omitempty"` // add _links for fields like authorId that name another entity
+	Format          string                 `json:"format,omitempty"`    // "jsonapi" shapes request and response bodies as JSON:API documents
+	Coerce          bool                   `json:"coerce,omitempty"`    // convert string values like "30" or "true" in request bodies to the field's type
+
+	// RequireSeed maps entities to the minimum number of records the seed
+	// data must provide; startup fails when one comes up short
+	RequireSeed map[string]int `json:"requireSeed,omitempty"`
+
+	// Respond 204 with no body on successful PUT/PATCH instead of 200 with the entity
+	PutReturnsNoContent   bool `json:"putReturnsNoContent,omitempty"`
+	PatchReturnsNoContent bool `json:"patchReturnsNoContent,omitempty"`
+
+	// Respond 200 with the deleted entity on DELETE instead of 204
+	DeleteReturnsEntity bool `json:"deleteReturnsEntity,omitempty"`
+
+	// Answer GET on the collection path of an entity the schema does not
+	// define with 200 and an empty array instead of 404
+	EmptyUnknownCollections bool `json:"emptyUnknownCollections,omitempty"`
+}
+
+// ConsistencyConfig simulates eventual consistency between writes and reads
+type ConsistencyConfig struct {
+	Lag string `json:"lag"` // Go duration, e.g. "500ms"; writes are hidden from reads until it elapses
+}
+
+// AsyncCompleteConfig mocks an asynchronous job API: creates on Entity are
+// answered with 202, and Field is set to Value once After has elapsed
+type AsyncCompleteConfig struct {
+	Entity string      `json:"entity"`
+	After  string      `json:"after"` // Go duration, e.g. "3s"
+	Field  string      `json:"field"`
+	Value  interface{} `json:"value"`
+}
+
+// WarmupConfig simulates a cold backend: requests are delayed by
+// InitialLatency right after startup, falling linearly to no delay once
+// Duration has passed
+type WarmupConfig struct {
+	InitialLatency string `json:"initialLatency"` // Go duration, e.g. "2s"
+	Duration       string `json:"duration"`       // Go duration, e.g. "30s"
+}
+
+// LazyInitConfig simulates per-resource cold starts: the first request to
+// each entity's routes is delayed by Delay, later ones are not
+type LazyInitConfig struct {
+	Delay string `json:"delay"` // Go duration, e.g. "1s"
 }
 
 // AuthConfig defines bearer token authentication settings
@@ -20,13 +78,49 @@ type AuthConfig struct {
 type ResponseWrapperConfig struct {
 	Single interface{} `json:"single,omitempty"`
 	List   interface{} `json:"list,omitempty"`
+	Error  interface{} `json:"error,omitempty"` // uses $error (the message) and $status; errors stay bare without it
+
+	// ListKey is shorthand for a list template: lists become
+	// {"<ListKey>": [...], "meta": {...}}. List wins when both are set.
+	ListKey string `json:"listKey,omitempty"`
+}
+
+// APIVersionsConfig serves alternative response shapes chosen per request by
+// ?apiVersion or a vendor Accept type such as application/vnd.myapi.v2+json
+type APIVersionsConfig struct {
+	Default  string                 `json:"default,omitempty"` // version used when the request names none
+	Variants map[string]*APIVersion `json:"variants"`
+}
+
+// APIVersion overrides response shaping for one version. A version without a
+// variant (such as the default) uses the top-level settings.
+type APIVersion struct {
+	ResponseWrapper *ResponseWrapperConfig `json:"responseWrapper,omitempty"` // replaces the top-level wrapper
+	Select          string                 `json:"select,omitempty"`          // JSONPath projection used when the request has no ?select
 }
 
 // PaginationConfig defines pagination behavior
 type PaginationConfig struct {
-	Style        string `json:"style"`                  // "cursor" or "offset"
-	DefaultLimit int    `json:"defaultLimit,omitempty"`
-	MaxLimit     int    `json:"maxLimit,omitempty"`
+	Style        string          `json:"style"` // "cursor", "offset" or "page"
+	DefaultLimit int             `json:"defaultLimit,omitempty"`
+	MaxLimit     int             `json:"maxLimit,omitempty"`
+	Keys         *PaginationKeys `json:"keys,omitempty"`
+
+	// MaxReportedCount caps the totals shown in meta, as APIs that report
+	// "1000+" do; paging itself continues past it. It also turns on hasMore.
+	MaxReportedCount int `json:"maxReportedCount,omitempty"`
+}
+
+// PaginationKeys overrides the key names used in the paginated list envelope.
+// Empty names fall back to the defaults; TotalCount and HasMore are only
+// emitted when named.
+type PaginationKeys struct {
+	Data        string `json:"data,omitempty"`        // default "data"
+	Meta        string `json:"meta,omitempty"`        // default "meta"; "-" puts meta keys at the top level
+	NextToken   string `json:"nextToken,omitempty"`   // default "next_token"
+	ResultCount string `json:"resultCount,omitempty"` // default "result_count"
+	TotalCount  string `json:"totalCount,omitempty"`
+	HasMore     string `json:"hasMore,omitempty"`
 }
 
 // CustomRoute defines a custom route pattern
@@ -35,41 +129,198 @@ type CustomRoute struct {
 	Path    string            `json:"path"`
 	Entity  string            `json:"entity"`
 	Filters map[string]string `json:"filters,omitempty"`
+	Single  bool              `json:"single,omitempty"` // respond with the one matching entity; 404 if none, 409 if several
+
+	// Response, instead of Entity, makes the route a static stub answering
+	// with this literal JSON and Status (default 200)
+	Response json.RawMessage `json:"response,omitempty"`
+	Status   int             `json:"status,omitempty"`
+
+	// Responses are checked in order against the request body; the first
+	// whose Match fields all equal the body's is sent instead of Response
+	Responses []RouteResponse `json:"responses,omitempty"`
+
+	// Deprecated adds a Deprecation header to the route's responses, and a
+	// Sunset header when Sunset is set (a date like "2025-12-31" or RFC 3339)
+	Deprecated bool   `json:"deprecated,omitempty"`
+	Sunset     string `json:"sunset,omitempty"`
+}
+
+// RouteResponse is a canned response sent when every Match field equals the
+// same top-level field of the request's JSON body
+type RouteResponse struct {
+	Match    map[string]interface{} `json:"match"`
+	Response json.RawMessage        `json:"response"`
+	Status   int                    `json:"status,omitempty"`
+}
+
+// Definition is a reusable group of fields. It may itself extend another
+// definition.
+type Definition struct {
+	Fields  map[string]*Field `json:"fields"`
+	Extends string            `json:"extends,omitempty"`
 }
 
 // Entity represents a single entity type (e.g., "users", "posts")
 type Entity struct {
-	Fields map[string]*Field `json:"fields"`
+	Fields          map[string]*Field `json:"fields"`
+	Extends         string            `json:"extends,omitempty"`         // definition whose fields are merged in at load time; own fields win
+	ResponseHeaders map[string]string `json:"responseHeaders,omitempty"` // merged over the schema-level headers
+	ListFormat      string            `json:"listFormat,omitempty"`      // overrides the schema-level listFormat
+	Middleware      []string          `json:"middleware,omitempty"`      // overrides the schema-level middleware list
+
+	// Versioning has the store manage an integer "version" field for
+	// optimistic locking: 1 on create, incremented on each PUT/PATCH
+	Versioning bool `json:"versioning,omitempty"`
+
+	// TrackHistory keeps each entity's prior state on every PUT, PATCH and
+	// DELETE, served at GET /<entity>/<id>/history
+	TrackHistory bool `json:"trackHistory,omitempty"`
+
+	// Indexes lists fields the store keeps a value -> ids index for, so
+	// equality filters on them don't scan every entity
+	Indexes []string `json:"indexes,omitempty"`
+
+	// Deprecated adds a Deprecation header to the generated routes'
+	// responses, and a Sunset header when Sunset is set (a date like
+	// "2025-12-31" or RFC 3339)
+	Deprecated bool   `json:"deprecated,omitempty"`
+	Sunset     string `json:"sunset,omitempty"`
+
+	// StartID is the ID counter's initial value: with 1000 the first
+	// generated id is "1001" (default 0)
+	StartID int `json:"startId,omitempty"`
+
+	// MaxFields caps how many fields (not counting id) a create, PUT or
+	// PATCH body may carry; larger bodies are rejected with 400 (default
+	// unlimited)
+	MaxFields int `json:"maxFields,omitempty"`
+
+	// Links adds a _links object to responses: true for self/collection
+	// hrefs, or a template using $self and $collection
+	Links interface{} `json:"links,omitempty"`
+
+	// ReadOnly makes the entity reference data: it is loaded from seed data
+	// and answers writes with 405, and /__import leaves it untouched
+	ReadOnly bool `json:"readOnly,omitempty"`
+
+	// ExampleWhenEmpty is returned by the list endpoint while the store holds
+	// no entities of this type (demo use; marked with X-Ape-Example)
+	ExampleWhenEmpty []map[string]interface{} `json:"exampleWhenEmpty,omitempty"`
 }
 
 // Field represents a field definition within an entity
 type Field struct {
-	Type     string `json:"type"`     // string, number, boolean, object, array
-	Required bool   `json:"required"` // whether the field is required
+	Type     string   `json:"type"`              // string, number, integer, boolean, object, array, binary, datetime
+	Required bool     `json:"required"`          // whether the field is required
+	MaxSize  int      `json:"maxSize,omitempty"` // max decoded size in bytes for binary fields
+	Layout   string   `json:"layout,omitempty"`  // Go time layout for datetime fields (default RFC3339)
+	Min      *float64 `json:"min,omitempty"`     // inclusive lower bound for number/integer fields
+	Max      *float64 `json:"max,omitempty"`     // inclusive upper bound for number/integer fields
+	Mask     string   `json:"mask,omitempty"`    // output mask for string fields: email, last4, all
+	Redact   bool     `json:"redact,omitempty"`  // omit the field from responses
+	Random   bool     `json:"random,omitempty"`  // fill from a generator seeded by the entity id when absent
+
+	// Default is applied on create when the field is absent. String defaults
+	// may contain {{now}}, {{timestamp}} and {{uuid}}, evaluated per request.
+	Default interface{} `json:"default,omitempty"`
+
+	// SlugFrom names a string field this one is derived from on create when
+	// absent: "Hello World" becomes "hello-world", with -2, -3, ... appended
+	// if another entity already has that slug
+	SlugFrom string `json:"slugFrom,omitempty"`
+
+	// WriteOnce fields may be set on create but not changed afterwards: a
+	// PUT or PATCH giving a different value is rejected with 409
+	WriteOnce bool `json:"writeOnce,omitempty"`
+}
+
+// Middleware step names for the schema's "middleware" lists
+const (
+	MiddlewareAuth        = "auth"        // bearer token check
+	MiddlewareSelect      = "select"      // reject malformed ?select expressions
+	MiddlewareContentType = "contentType" // require a JSON (or multipart) body on writes
+	MiddlewareHeaders     = "headers"     // schema-level responseHeaders
+	MiddlewareTimeout     = "timeout"     // --request-timeout for the steps after it and the handler
+)
+
+// DefaultMiddleware is the step order used when no list is configured
+var DefaultMiddleware = []string{
+	MiddlewareAuth,
+	MiddlewareSelect,
+	MiddlewareContentType,
+	MiddlewareHeaders,
+	MiddlewareTimeout,
 }
 
+// FieldCase constants for the external spelling of field names
+const (
+	FieldCaseCamel = "camel" // first_name is exposed as firstName
+	FieldCaseSnake = "snake" // firstName is exposed as first_name
+)
+
+// ListFormat constants for collection responses
+const (
+	ListFormatArray = "array" // [{...}, {...}]
+	ListFormatMap   = "map"   // {"<id>": {...}, ...}
+)
+
+// FormatJSONAPI shapes bodies as JSON:API documents: {"data": {"type", "id", "attributes"}}
+const FormatJSONAPI = "jsonapi"
+
+// Mask constants for output-only field masking
+const (
+	MaskEmail = "email" // a***@example.com
+	MaskLast4 = "last4" // ****1234
+	MaskAll   = "all"   // every character replaced with *
+)
+
 // FieldType constants for validation
 const (
-	FieldTypeString  = "string"
-	FieldTypeNumber  = "number"
-	FieldTypeBoolean = "boolean"
-	FieldTypeObject  = "object"
-	FieldTypeArray   = "array"
+	FieldTypeString   = "string"
+	FieldTypeNumber   = "number"
+	FieldTypeInteger  = "integer" // whole numbers only
+	FieldTypeBoolean  = "boolean"
+	FieldTypeObject   = "object"
+	FieldTypeArray    = "array"
+	FieldTypeBinary   = "binary"   // base64-encoded string
+	FieldTypeDatetime = "datetime" // string parsed with the field's layout
 )
 
 // QueryOpts defines options for querying entities from storage
 type QueryOpts struct {
-	Filters map[string]string
-	Limit   int
-	Offset  int
-	Cursor  string
+	Filters    map[string]string // field -> value, exact match
+	Conditions []FilterCondition // operator filters such as age_gte=18
+	Limit      int
+	Offset     int
+	Cursor     string
 }
 
+// FilterCondition is a field comparison parsed from a ?field_op=value parameter
+type FilterCondition struct {
+	Field string
+	Op    string
+	Value string
+}
+
+// Filter operators, used as query parameter suffixes (e.g. ?name_like=ali)
+const (
+	FilterOpLike = "like" // case-insensitive substring
+	FilterOpNe   = "ne"   // not equal
+	FilterOpGt   = "gt"
+	FilterOpGte  = "gte"
+	FilterOpLt   = "lt"
+	FilterOpLte  = "lte"
+	FilterOpIn   = "in" // comma-separated list of accepted values
+)
+
 // QueryResult holds the results of a storage query
 type QueryResult struct {
 	Items      []map[string]interface{}
 	TotalCount int
 	NextCursor string
+	Offset     int // offset applied (0 for cursor queries)
+	Limit      int // limit applied (0 = unlimited)
 }
 
 // SeedData represents the seed data structure
@@ -79,3 +330,24 @@ type SeedData struct {
 
 // EntityData represents a collection of entities of a specific type
 type EntityData map[string]interface{} // key is entity ID, value is entity data
+
+// CopyValue deep-copies decoded JSON objects and arrays so the copy shares no
+// nested values with the original
+func CopyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = CopyValue(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = CopyValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -10,9 +10,12 @@ func TestFieldTypeConstants(t *testing.T) {
 	}{
 		{"string type", FieldTypeString},
 		{"number type", FieldTypeNumber},
+		{"integer type", FieldTypeInteger},
 		{"boolean type", FieldTypeBoolean},
 		{"object type", FieldTypeObject},
 		{"array type", FieldTypeArray},
+		{"binary type", FieldTypeBinary},
+		{"datetime type", FieldTypeDatetime},
 	}
 
 	for _, tt := range tests {
@@ -61,3 +64,23 @@ func TestFieldStructure(t *testing.T) {
 		t.Error("expected Required to be true")
 	}
 }
+
+// TestCopyValue verifies nested objects and arrays are not shared with the copy
+func TestCopyValue(t *testing.T) {
+	original := map[string]interface{}{
+		"tags":  []interface{}{"a", map[string]interface{}{"b": "c"}},
+		"count": 1,
+	}
+	copied := CopyValue(original).(map[string]interface{})
+
+	copied["tags"].([]interface{})[0] = "changed"
+	copied["tags"].([]interface{})[1].(map[string]interface{})["b"] = "changed"
+
+	tags := original["tags"].([]interface{})
+	if tags[0] != "a" || tags[1].(map[string]interface{})["b"] != "c" {
+		t.Errorf("original modified through copy: %v", original)
+	}
+	if copied["count"] != 1 {
+		t.Errorf("expected count 1, got %v", copied["count"])
+	}
+}